@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joao-rrondon/wipeOs/internal/audit"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+var auditLogCmd = &cobra.Command{
+	Use:   "audit-log",
+	Short: "🧾 Manage the tamper-evident audit log",
+	Long: ui.StyleHeader("Audit Log") + `
+
+Once enabled, every file, directory, device and free space wipe and every
+forensic operation appends a record to an audit log in the configuration
+directory: sequence number, timestamp, operation, target, outcome and the
+hash of the record before it. Changing, removing or reordering records
+breaks the chain, and cutting records from the end no longer matches the
+head kept next to the log; 'audit-log verify' detects both.
+
+With --hash-paths, targets are stored as keyed hashes instead of paths.
+'audit-log find' still locates the records of a given path.
+
+Examples:
+  wipeOs audit-log enable               # Start auditing
+  wipeOs audit-log enable --hash-paths  # Keep paths out of the log
+  wipeOs audit-log verify               # Check the chain
+  wipeOs audit-log find ~/secret.txt    # Records of one path`,
+}
+
+var auditEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Record every operation in the audit log",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		hashPaths, _ := cmd.Flags().GetBool("hash-paths")
+		if err := audit.SaveSettings(audit.Config{Enabled: true, HashPaths: hashPaths}); err != nil {
+			fmt.Printf(ui.StyleError("Cannot save audit settings: %v\n"), err)
+			return
+		}
+		l, err := audit.OpenConfigured()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot open audit log: %v\n"), err)
+			return
+		}
+		fmt.Printf(ui.StyleSuccess("✓ Auditing enabled: %s\n"), l.Path())
+		if hashPaths {
+			fmt.Println(ui.StyleMuted("Targets are stored as keyed hashes"))
+		}
+	},
+}
+
+var auditDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop recording operations, keeping the existing log",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := audit.SaveSettings(audit.Config{}); err != nil {
+			fmt.Printf(ui.StyleError("Cannot save audit settings: %v\n"), err)
+			return
+		}
+		fmt.Println(ui.StyleSuccess("✓ Auditing disabled"))
+	},
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the audit log for modified or missing records",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := audit.Dir()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot access audit directory: %v\n"), err)
+			return
+		}
+
+		report, err := audit.Verify(dir)
+		if err != nil {
+			fmt.Printf(ui.StyleError("✗ %v\n"), err)
+			fmt.Printf(ui.StyleMuted("  %d record(s) verified before the failure\n"), report.Records)
+			os.Exit(1)
+		}
+
+		for _, r := range report.Recovered {
+			fmt.Printf(ui.StyleWarning("⚠️  Record #%d, written at %s, recovers from an incomplete record: %s\n"),
+				r.Seq, r.Time.Local().Format("2006-01-02 15:04:05"), r.Detail)
+		}
+		if report.Torn {
+			fmt.Println(ui.StyleWarning("⚠️  The log ends with a record left incomplete by a crash; the next operation recovers from it"))
+		}
+
+		if report.Last == nil {
+			fmt.Println(ui.StyleSuccess("✓ Audit log is empty"))
+			return
+		}
+		fmt.Printf(ui.StyleSuccess("✓ Audit log intact: %d record(s)\n"), report.Records)
+		fmt.Printf(ui.StyleMuted("  Latest: #%d at %s, %s\n"),
+			report.Last.Seq, report.Last.Time.Local().Format("2006-01-02 15:04:05"), report.Last.Hash)
+		fmt.Println(ui.StyleMuted("  Keep the latest hash elsewhere to detect a rewritten log later"))
+	},
+}
+
+var auditFindCmd = &cobra.Command{
+	Use:   "find <path>",
+	Short: "List the records of a path, hashed or not",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := audit.Dir()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot access audit directory: %v\n"), err)
+			return
+		}
+		records, err := audit.Find(dir, args[0])
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot read audit log: %v\n"), err)
+			return
+		}
+
+		if len(records) == 0 {
+			fmt.Printf(ui.StyleInfo("No records for %s\n"), args[0])
+			return
+		}
+		for _, r := range records {
+			fmt.Printf("#%-6d %s  %-16s %-8s %s\n",
+				r.Seq, r.Time.Local().Format("2006-01-02 15:04:05"), r.Operation, r.Outcome, r.Detail)
+		}
+	},
+}
+
+// auditor returns the audit log when auditing is enabled. It returns a
+// nil interface otherwise, so that options never carry a nil *audit.Log.
+// An enabled log that cannot be appended to fails the command rather than
+// letting it run unrecorded.
+func auditor() (shredder.Auditor, error) {
+	l, err := audit.OpenConfigured()
+	if err != nil {
+		return nil, fmt.Errorf("audit log unusable, check it with 'wipeOs audit-log verify' or run 'wipeOs audit-log disable': %w", err)
+	}
+	if l == nil {
+		return nil, nil
+	}
+	return l, nil
+}
+
+func init() {
+	rootCmd.AddCommand(auditLogCmd)
+	auditLogCmd.AddCommand(auditEnableCmd, auditDisableCmd, auditVerifyCmd, auditFindCmd)
+
+	auditEnableCmd.Flags().Bool("hash-paths", false, "Store keyed hashes of targets instead of their paths")
+}
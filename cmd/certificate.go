@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/certificate"
+	"github.com/joao-rrondon/wipeOs/internal/config"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "📜 Verify certificates of sanitization",
+	Long: ui.StyleHeader("Certificates of Sanitization") + `
+
+Every wipe, clean, device, forensic and apply run writes a certificate of
+sanitization to the certificates directory of the configuration directory,
+as JSON and as a printable HTML page. It records the host, operator,
+timestamps, method, verification and the outcome of every item, and is
+signed with an Ed25519 key kept next to it.
+
+Examples:
+  wipeOs cert verify ~/.config/wipeOs/certificates/<id>.json
+  wipeOs cert verify <id>.json --key certificate.pub   # On another machine
+  wipeOs cert key                                      # Show the signing key`,
+}
+
+var certVerifyCmd = &cobra.Command{
+	Use:   "verify <certificate.json>...",
+	Short: "Check the signature of certificates",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		trusted, err := trustedKey(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		for _, path := range args {
+			c, err := certificate.Load(path)
+			if err == nil {
+				err = c.Verify(trusted)
+			}
+			if err != nil {
+				fmt.Printf(ui.StyleError("✗ %s: %v\n"), path, err)
+				continue
+			}
+			summary := c.Summary
+			fmt.Printf(ui.StyleSuccess("✓ %s: valid, signed by %s\n"), path, certificate.Fingerprint(trusted))
+			fmt.Printf(ui.StyleMuted("  wipeOs %s on %s by %s at %s: %d sanitized, %d removed, %d failed, %d refused\n"),
+				c.Command, c.Host, c.Operator, c.Finished.Local().Format("2006-01-02 15:04"),
+				summary.Sanitized, summary.Removed, summary.Failed, summary.Refused)
+		}
+	},
+}
+
+var certKeyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Show the public key certificates are signed with",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := config.Dir()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot access configuration directory: %v\n"), err)
+			return
+		}
+		key, err := certificate.LoadKey(dir)
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot load signing key: %v\n"), err)
+			return
+		}
+		fmt.Printf(ui.StyleInfo("🔑 Fingerprint: %s\n"), certificate.Fingerprint(key.Public().(ed25519.PublicKey)))
+		fmt.Printf(ui.StyleMuted("Public key for auditors: %s\n"), filepath.Join(dir, certificate.PublicKeyFile))
+	},
+}
+
+// trustedKey returns the public key given with --key or, by default, the
+// local public key. No signing key is generated on a machine that only
+// verifies certificates.
+func trustedKey(cmd *cobra.Command) (ed25519.PublicKey, error) {
+	if path, _ := cmd.Flags().GetString("key"); path != "" {
+		return certificate.ReadPublicKey(path)
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("cannot access configuration directory: %w", err)
+	}
+	key, err := certificate.ReadPublicKey(filepath.Join(dir, certificate.PublicKeyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no local key, pass --key with the public key of the machine that signed the certificate")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read local public key: %w", err)
+	}
+	return key, nil
+}
+
+// addCertificateFlags registers the certificate flags on a command that
+// sanitizes data, with --hash-content for commands that wipe files
+func addCertificateFlags(cmd *cobra.Command, hashes bool) {
+	cmd.Flags().String("operator", "", "Operator named on the certificate (default: current user)")
+	cmd.Flags().Bool("no-certificate", false, "Do not write a certificate of sanitization")
+	if hashes {
+		cmd.Flags().Bool("hash-content", false, "Record the SHA-256 of every file on the certificate before overwriting it")
+	}
+}
+
+// newCertificate starts the certificate of a run of command
+func newCertificate(cmd *cobra.Command, command string, options shredder.WipeOptions) *certificate.Certificate {
+	operator, _ := cmd.Flags().GetString("operator")
+	return certificate.New(command, operator, options)
+}
+
+// issueCertificate signs and saves the certificate of a run that handled
+// anything, unless --no-certificate was given. Dry runs sanitize nothing
+// and get none. Problems are reported but never fail the certified run.
+func issueCertificate(cmd *cobra.Command, c *certificate.Certificate) {
+	skip, _ := cmd.Flags().GetBool("no-certificate")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if skip || dryRun || len(c.Items) == 0 {
+		return
+	}
+	c.Finish(time.Now())
+
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Printf(ui.StyleWarning("⚠️  No certificate written: %v\n"), err)
+		return
+	}
+	key, err := certificate.LoadKey(dir)
+	if err == nil {
+		err = c.Sign(key)
+	}
+	if err != nil {
+		fmt.Printf(ui.StyleWarning("⚠️  No certificate written, cannot sign: %v\n"), err)
+		return
+	}
+
+	certDir, err := config.SubDir("certificates")
+	if err != nil {
+		fmt.Printf(ui.StyleWarning("⚠️  No certificate written: %v\n"), err)
+		return
+	}
+	jsonPath, htmlPath, err := c.Save(certDir)
+	if err != nil {
+		fmt.Printf(ui.StyleWarning("⚠️  Certificate incomplete: %v\n"), err)
+		return
+	}
+	fmt.Printf(ui.StyleInfo("📜 Certificate of sanitization: %s\n"), jsonPath)
+	fmt.Printf(ui.StyleMuted("   Printable: %s\n"), htmlPath)
+}
+
+func init() {
+	rootCmd.AddCommand(certCmd)
+	certCmd.AddCommand(certVerifyCmd, certKeyCmd)
+
+	certVerifyCmd.Flags().String("key", "", "PEM public key to trust instead of the local one")
+}
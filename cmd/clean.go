@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/joao-rrondon/wipeOs/internal/certificate"
+	"github.com/joao-rrondon/wipeOs/internal/journal"
 	"github.com/joao-rrondon/wipeOs/internal/shredder"
 	"github.com/joao-rrondon/wipeOs/ui"
 	"github.com/spf13/cobra"
@@ -27,7 +30,8 @@ Available clean operations:
 Examples:
   wipeOs clean all                # Clean everything
   wipeOs clean browser temp       # Clean browser data and temp files
-  wipeOs clean logs --dry-run     # Preview log cleaning`,
+  wipeOs clean logs --dry-run     # Preview log cleaning
+  wipeOs clean temp --method dod  # Clean using DoD 5220.22-M`,
 	ValidArgs: []string{"all", "browser", "temp", "logs", "cache", "downloads"},
 	Args:      cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -35,38 +39,115 @@ Examples:
 		force, _ := cmd.Flags().GetBool("force")
 		passes, _ := cmd.Flags().GetInt("passes")
 
+		method, err := methodFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		verify, err := verifyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		noScrub, _ := cmd.Flags().GetBool("no-scrub")
+		followSymlinks, _ := cmd.Flags().GetBool("follow-symlinks")
+		hardLinks, err := hardLinksFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		directIO, _ := cmd.Flags().GetBool("direct")
+		slack, _ := cmd.Flags().GetBool("slack")
+		discard, err := discardFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		blockSize, err := blockSizeFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		hashContent, _ := cmd.Flags().GetBool("hash-content")
+		guard, err := policyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		auditLog, err := auditor()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
 		options := shredder.WipeOptions{
-			Recursive: true,
-			Passes:    passes,
-			Force:     force,
-			DryRun:    dryRun,
+			NoScrub:        noScrub,
+			FollowSymlinks: followSymlinks,
+			HardLinks:      hardLinks,
+			BlockSize:      blockSize,
+			DirectIO:       directIO,
+			Slack:          slack,
+			Discard:        discard,
+			Jobs:           jobs,
+			Progress:       newProgressSink(),
+			Recursive:      true,
+			Passes:         passes,
+			Force:          force,
+			DryRun:         dryRun,
+			Method:         method,
+			Auto:           autoFromFlags(cmd),
+			Verify:         verify,
+			Guard:          guard,
+			HashContent:    hashContent,
+			Audit:          auditLog,
 		}
 
 		s := shredder.New()
 
+		ctx, stop := interruptContext()
+		defer stop()
+
+		var j *journal.Journal
+		if !dryRun {
+			if j = newJournal(); j != nil {
+				options.Journal = j
+			}
+		}
+		defer finishJournal(ctx, j)
+
+		cert := newCertificate(cmd, "clean", options)
+		defer issueCertificate(cmd, cert)
+
 		for _, target := range args {
+			if ctx.Err() != nil {
+				break
+			}
+
 			switch target {
 			case "all":
 				fmt.Println(ui.StyleWarning("🧹 Performing comprehensive cleanup..."))
-				cleanBrowser(s, options)
-				cleanTemp(s, options)
-				cleanLogs(s, options)
-				cleanCache(s, options)
+				cleanBrowser(ctx, s, options, cert)
+				cleanTemp(ctx, s, options, cert)
+				cleanLogs(ctx, s, options)
+				cleanCache(ctx, s, options)
 
 			case "browser":
-				cleanBrowser(s, options)
+				cleanBrowser(ctx, s, options, cert)
 
 			case "temp":
-				cleanTemp(s, options)
+				cleanTemp(ctx, s, options, cert)
 
 			case "logs":
-				cleanLogs(s, options)
+				cleanLogs(ctx, s, options)
 
 			case "cache":
-				cleanCache(s, options)
+				cleanCache(ctx, s, options)
 
 			case "downloads":
-				cleanDownloads(s, options)
+				cleanDownloads(ctx, s, options)
 
 			default:
 				fmt.Printf(ui.StyleError("Unknown clean target: %s\n"), target)
@@ -78,33 +159,37 @@ Examples:
 	},
 }
 
-func cleanBrowser(s *shredder.Shredder, options shredder.WipeOptions) {
+func cleanBrowser(ctx context.Context, s *shredder.Shredder, options shredder.WipeOptions, cert *certificate.Certificate) {
 	fmt.Println(ui.StyleInfo("🌐 Cleaning browser data..."))
-	if err := s.WipeBrowserData(options); err != nil {
+	results, err := s.WipeBrowserData(ctx, options)
+	cert.AddWipeResults(results)
+	if err != nil {
 		fmt.Printf(ui.StyleError("Failed to clean browser data: %v\n"), err)
 	}
 }
 
-func cleanTemp(s *shredder.Shredder, options shredder.WipeOptions) {
+func cleanTemp(ctx context.Context, s *shredder.Shredder, options shredder.WipeOptions, cert *certificate.Certificate) {
 	fmt.Println(ui.StyleInfo("📂 Cleaning temporary files..."))
-	if err := s.WipeSystemTemp(options); err != nil {
+	results, err := s.WipeSystemTemp(ctx, options)
+	cert.AddWipeResults(results)
+	if err != nil {
 		fmt.Printf(ui.StyleError("Failed to clean temp files: %v\n"), err)
 	}
 }
 
-func cleanLogs(s *shredder.Shredder, options shredder.WipeOptions) {
+func cleanLogs(ctx context.Context, s *shredder.Shredder, options shredder.WipeOptions) {
 	fmt.Println(ui.StyleInfo("📝 Cleaning log files..."))
 	// Implementation would go here for log cleaning
 	fmt.Println(ui.StyleMuted("Log cleaning not yet implemented"))
 }
 
-func cleanCache(s *shredder.Shredder, options shredder.WipeOptions) {
+func cleanCache(ctx context.Context, s *shredder.Shredder, options shredder.WipeOptions) {
 	fmt.Println(ui.StyleInfo("💾 Cleaning cache directories..."))
 	// Implementation would go here for cache cleaning
 	fmt.Println(ui.StyleMuted("Cache cleaning not yet implemented"))
 }
 
-func cleanDownloads(s *shredder.Shredder, options shredder.WipeOptions) {
+func cleanDownloads(ctx context.Context, s *shredder.Shredder, options shredder.WipeOptions) {
 	fmt.Println(ui.StyleWarning("⬇️ Cleaning Downloads folder..."))
 	if !options.Force {
 		if !ui.ConfirmDangerous("clean your Downloads folder") {
@@ -121,4 +206,18 @@ func init() {
 	cleanCmd.Flags().Bool("dry-run", false, "Show what would be cleaned without actually doing it")
 	cleanCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
 	cleanCmd.Flags().IntP("passes", "p", 3, "Number of overwrite passes (1-35)")
+	cleanCmd.Flags().StringP("method", "m", "", "Wipe method name, custom pass list, or auto to choose per disk type (overrides --passes)")
+	cleanCmd.Flags().String("verify", "none", "Read back and check overwrite passes: none, last or all")
+	cleanCmd.Flags().Lookup("verify").NoOptDefVal = "last"
+	cleanCmd.Flags().Bool("no-scrub", false, "Skip truncating, renaming and resetting timestamps before unlink")
+	cleanCmd.Flags().Bool("follow-symlinks", false, "Wipe the targets of symbolic links instead of refusing them")
+	cleanCmd.Flags().String("hardlinks", "skip", "Files with other hard links: skip, unlink (keep data) or overwrite (destroys data for all links)")
+	cleanCmd.Flags().IntP("jobs", "j", 0, "Files overwritten in parallel (0 = one per CPU, spinning disks always use 1)")
+	cleanCmd.Flags().String("block-size", "1M", "Size of each write, a multiple of 4K (e.g. 64K, 1M, 8M)")
+	cleanCmd.Flags().Bool("direct", false, "Write with O_DIRECT, bypassing the page cache (Linux)")
+	cleanCmd.Flags().Bool("slack", false, "Also overwrite the slack after the end of each file up to its last filesystem block")
+	cleanCmd.Flags().String("discard", "none", "Release file blocks to the device (punch hole + FITRIM): none, after overwriting, or only instead of overwriting")
+	cleanCmd.Flags().Lookup("discard").NoOptDefVal = "after"
+	addPolicyFlags(cleanCmd)
+	addCertificateFlags(cleanCmd, true)
 } 
\ No newline at end of file
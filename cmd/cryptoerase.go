@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/joao-rrondon/wipeOs/internal/luks"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+var cryptoEraseCmd = &cobra.Command{
+	Use:   "crypto-erase <path>",
+	Short: "🔐 Destroy LUKS headers and keyslots for an instant crypto-erase",
+	Long: ui.StyleHeader("LUKS Crypto-Erase") + `
+
+This command sanitizes a LUKS1 or LUKS2 encrypted device in seconds by
+overwriting its binary headers, JSON metadata areas and every keyslot area,
+including the LUKS2 secondary header. Without them the volume key cannot be
+recovered with any passphrase or key file, so the encrypted data is lost
+for good even though it is not overwritten. The device is then scanned
+again to verify that no valid header remains.
+
+Close the mapping first (cryptsetup close); devices in use are refused.
+Header backups made with cryptsetup luksHeaderBackup can still unlock the
+data and must be destroyed separately.
+
+Examples:
+  wipeOs crypto-erase /dev/sdb2 --dry-run   # Show headers and areas
+  wipeOs crypto-erase /dev/sdb2             # Destroy the headers
+  wipeOs crypto-erase encrypted.img --force
+
+⚠️  WARNING: This operation is IRREVERSIBLE!`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		headers, size, err := detectLUKS(path)
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot read %s: %v\n"), path, err)
+			return
+		}
+		if len(headers) == 0 {
+			fmt.Printf(ui.StyleWarning("No LUKS header found on %s\n"), path)
+			return
+		}
+
+		fmt.Println(ui.StyleHeader(fmt.Sprintf("🔐 LUKS headers on %s", path)))
+		for _, h := range headers {
+			fmt.Printf("  %s\n", h)
+		}
+
+		areas := luks.Areas(headers, size)
+		if dryRun {
+			for _, a := range areas {
+				fmt.Printf(ui.StyleInfo("🔍 Would overwrite %s at 0x%x\n"), ui.FormatBytes(a.Length), a.Offset)
+			}
+			return
+		}
+
+		if !force && !ui.ConfirmDangerous(fmt.Sprintf("destroy the LUKS headers and keyslots of %s", path)) {
+			fmt.Println(ui.StyleInfo("Operation cancelled"))
+			return
+		}
+
+		file, _, err := shredder.OpenDevice(path, os.O_RDWR)
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot open %s: %v\n"), path, err)
+			return
+		}
+		defer file.Close()
+
+		result, err := luks.Erase(file, size)
+		if err != nil {
+			if errors.Is(err, luks.ErrHeaderRemains) {
+				fmt.Printf(ui.StyleError("✗ Verification failed: %v\n"), err)
+			} else {
+				fmt.Printf(ui.StyleError("✗ Crypto-erase failed: %v\n"), err)
+			}
+			return
+		}
+
+		fmt.Printf(ui.StyleSuccess("✓ Destroyed %d header(s) and their keyslots (%s overwritten in %d area(s))\n"), len(result.Headers), ui.FormatBytes(result.Bytes), len(result.Areas))
+		fmt.Println(ui.StyleSuccess("✓ Verified: no LUKS header remains, the encrypted data can no longer be unlocked"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cryptoEraseCmd)
+
+	cryptoEraseCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
+	cryptoEraseCmd.Flags().Bool("dry-run", false, "Show the headers and areas that would be overwritten")
+}
+
+// detectLUKS lists the LUKS headers of a device without opening it for
+// writing
+func detectLUKS(path string) ([]luks.Header, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, err
+	}
+	return luks.Detect(file, size), size, nil
+}
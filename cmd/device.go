@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+var deviceCmd = &cobra.Command{
+	Use:   "device <path>",
+	Short: "💽 Wipe a whole block device, partition or disk image",
+	Long: ui.StyleHeader("Block Device Wiping") + `
+
+This command overwrites an entire block device, a partition or a raw disk
+image in place with the selected wipe method, for decommissioning drives.
+Devices that are mounted, used as swap or held by LVM, dm-crypt or a loop
+device are refused. The device serial number, or the SHA-256 of an image
+after the wipe, is reported with the result.
+
+Examples:
+  wipeOs device /dev/sdb --dry-run                # Preview
+  wipeOs device /dev/sdb --method dod --verify    # Wipe a whole drive
+  wipeOs device /dev/sdb2                         # Wipe one partition
+  wipeOs device disk.img --offset 1M --length 64M # Wipe a byte range
+
+⚠️  WARNING: This operation is IRREVERSIBLE and destroys the partition
+table and every filesystem in the selected range!`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		directIO, _ := cmd.Flags().GetBool("direct")
+
+		if autoFromFlags(cmd) {
+			fmt.Println(ui.StyleError("--method auto only applies to files, pick a method for the device"))
+			return
+		}
+		method, err := methodFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		verify, err := verifyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		blockSize, err := blockSizeFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		offsetFlag, _ := cmd.Flags().GetString("offset")
+		offset, err := parseSize(offsetFlag)
+		if err != nil {
+			fmt.Printf(ui.StyleError("invalid --offset: %v\n"), err)
+			return
+		}
+		lengthFlag, _ := cmd.Flags().GetString("length")
+		length, err := parseSize(lengthFlag)
+		if err != nil {
+			fmt.Printf(ui.StyleError("invalid --length: %v\n"), err)
+			return
+		}
+
+		auditLog, err := auditor()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		options := shredder.WipeOptions{
+			BlockSize: blockSize,
+			DirectIO:  directIO,
+			Progress:  newProgressSink(),
+			Force:     force,
+			DryRun:    dryRun,
+			Method:    method,
+			Verify:    verify,
+			Audit:     auditLog,
+		}
+
+		if !dryRun && !force && !ui.ConfirmDangerous(fmt.Sprintf("overwrite %s", path)) {
+			fmt.Println(ui.StyleInfo("Operation cancelled"))
+			return
+		}
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		cert := newCertificate(cmd, "device", options)
+		fmt.Printf(ui.StyleInfo("💽 Wiping %s using %s...\n"), path, describeMethod(options))
+		result := shredder.New().WipeDevice(ctx, path, offset, length, options)
+		printDeviceResult(result, options)
+
+		cert.AddDevice(result)
+		issueCertificate(cmd, cert)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deviceCmd)
+
+	deviceCmd.Flags().IntP("passes", "p", 3, "Number of overwrite passes (1-35)")
+	deviceCmd.Flags().StringP("method", "m", "", "Wipe method name or custom pass list (overrides --passes)")
+	deviceCmd.Flags().String("verify", "none", "Read back and check overwrite passes: none, last or all")
+	deviceCmd.Flags().Lookup("verify").NoOptDefVal = "last"
+	deviceCmd.Flags().String("offset", "0", "Start of the range to overwrite, in bytes (K, M, G, T suffixes)")
+	deviceCmd.Flags().String("length", "0", "Length of the range to overwrite (0 = to the end of the device)")
+	deviceCmd.Flags().String("block-size", "1M", "Size of each write, a multiple of 4K (e.g. 64K, 1M, 8M)")
+	deviceCmd.Flags().Bool("direct", false, "Write with O_DIRECT, bypassing the page cache (Linux)")
+	deviceCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
+	deviceCmd.Flags().Bool("dry-run", false, "Show what would be wiped without actually doing it")
+	addCertificateFlags(deviceCmd, false)
+}
+
+// printDeviceResult reports the outcome of a device wipe
+func printDeviceResult(result shredder.DeviceResult, options shredder.WipeOptions) {
+	if !result.Success {
+		fmt.Printf(ui.StyleError("✗ %s: %v\n"), result.Path, result.Error)
+		if result.Passes > 0 {
+			fmt.Printf(ui.StyleWarning("  ⚠️ %d pass(es) completed before the failure\n"), result.Passes)
+		}
+		return
+	}
+
+	kind := "block device"
+	if result.Image {
+		kind = "disk image"
+	}
+	span := fmt.Sprintf("%s of %s from offset %d", ui.FormatBytes(result.Length), ui.FormatBytes(result.Size), result.Offset)
+
+	if options.DryRun {
+		fmt.Printf(ui.StyleInfo("🔍 Would overwrite %s (%s, %s, %d passes)\n"), result.Path, kind, span, result.Passes)
+	} else {
+		status := "wiped"
+		if result.Verification == shredder.VerifyPassed {
+			status = "wiped and verified"
+		}
+		fmt.Printf(ui.StyleSuccess("✓ %s %s\n"), result.Path, ui.StyleMuted(fmt.Sprintf("(%s, %s, %s, %s, %d passes)", kind, status, span, result.Method, result.Passes)))
+	}
+
+	if result.Serial != "" || result.Model != "" {
+		fmt.Printf(ui.StyleInfo("🏷️  Serial: %s  Model: %s\n"), valueOr(result.Serial, "unknown"), valueOr(result.Model, "unknown"))
+	}
+	if result.ImageHash != "" {
+		fmt.Printf(ui.StyleInfo("🔑 SHA-256 after wipe: %s\n"), result.ImageHash)
+	}
+}
+
+// valueOr returns value, or fallback when it is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -5,6 +5,7 @@ import (
 	"runtime"
 
 	"github.com/joao-rrondon/wipeOs/internal/forensic"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
 	"github.com/joao-rrondon/wipeOs/ui"
 	"github.com/spf13/cobra"
 )
@@ -36,7 +37,8 @@ Examples:
   wipeOs forensic --dry-run           # Preview operations
   wipeOs forensic --all               # Full cleanup
   wipeOs forensic --logs --registry   # Selective cleanup
-  wipeOs forensic --quick             # Quick essential cleanup`,
+  wipeOs forensic --quick             # Quick essential cleanup
+  wipeOs forensic --freespace --freespace-path /home --reserve 1G`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get flags
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
@@ -54,6 +56,14 @@ Examples:
 		swap, _ := cmd.Flags().GetBool("swap")
 		freespace, _ := cmd.Flags().GetBool("freespace")
 		passes, _ := cmd.Flags().GetInt("passes")
+		freespacePath, _ := cmd.Flags().GetString("freespace-path")
+		reserveFlag, _ := cmd.Flags().GetString("reserve")
+
+		reserve, err := parseSize(reserveFlag)
+		if err != nil {
+			fmt.Printf(ui.StyleError("invalid --reserve: %v\n"), err)
+			return
+		}
 
 		// Show warning for non-dry runs
 		if !dryRun {
@@ -73,6 +83,9 @@ Examples:
 			DryRun:  dryRun,
 			Verbose: verbose,
 			Passes:  passes,
+
+			FreeSpacePath:    freespacePath,
+			FreeSpaceReserve: reserve,
 		}
 
 		// Determine what to clean
@@ -131,8 +144,23 @@ Examples:
 		}
 
 		// Perform anti-forensic operations
+		ctx, stop := interruptContext()
+		defer stop()
+
+		auditLog, err := auditor()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		cert := newCertificate(cmd, "forensic", shredder.WipeOptions{Passes: passes})
 		antiForensic := forensic.New(dryRun, verbose)
-		results := antiForensic.PerformForensicCleanup(options)
+		antiForensic.SetAuditor(auditLog)
+		results := antiForensic.PerformForensicCleanup(ctx, options)
+
+		if ctx.Err() != nil {
+			fmt.Println(ui.StyleWarning("⏹️  Interrupted, remaining operations were skipped"))
+		}
 
 		// Display results
 		fmt.Println(ui.StyleHeader("📊 Anti-Forensic Operation Results:"))
@@ -150,6 +178,9 @@ Examples:
 
 		fmt.Println()
 		fmt.Printf(ui.StyleHeader("🎯 Summary: %d/%d operations completed successfully\n"), successCount, len(results))
+
+		cert.AddOperations(results)
+		issueCertificate(cmd, cert)
 		
 		if !dryRun && successCount > 0 {
 			fmt.Println()
@@ -182,4 +213,7 @@ func init() {
 	forensicCmd.Flags().Bool("dry-run", false, "Show what would be cleaned without doing it")
 	forensicCmd.Flags().BoolP("verbose", "v", false, "Show detailed operation progress")
 	forensicCmd.Flags().IntP("passes", "p", 3, "Number of overwrite passes for free space wiping")
+	forensicCmd.Flags().String("freespace-path", ".", "Directory on the filesystem whose free space is wiped")
+	forensicCmd.Flags().String("reserve", "256M", "Free space left untouched while wiping free space (K, M, G, T suffixes)")
+	addCertificateFlags(forensicCmd, false)
 } 
\ No newline at end of file
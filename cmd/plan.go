@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/joao-rrondon/wipeOs/internal/forensic"
+	"github.com/joao-rrondon/wipeOs/internal/plan"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan [files...]",
+	Short: "📋 Write the exact list of what a wipe would remove",
+	Long: ui.StyleHeader("Plan a Wipe") + `
+
+This command resolves files, clean targets and forensic operations into a
+manifest of concrete paths with their size, device, inode and modification
+time, plus totals. Nothing is changed. Review the manifest, then run
+'wipeOs apply' to execute exactly that plan: entries that changed since
+planning are refused, and anything created since is left alone.
+
+Examples:
+  wipeOs plan ~/old-project -r -o project.json      # Plan a directory wipe
+  wipeOs plan --clean temp,browser -o clean.json    # Plan clean targets
+  wipeOs plan --forensic logs,thumbnails            # Plan forensic cleanup
+  wipeOs apply project.json                         # Execute the plan`,
+	Run: func(cmd *cobra.Command, args []string) {
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		cleanTargets, _ := cmd.Flags().GetStringSlice("clean")
+		forensicOps, _ := cmd.Flags().GetStringSlice("forensic")
+		output, _ := cmd.Flags().GetString("output")
+
+		if len(args) == 0 && len(cleanTargets) == 0 && len(forensicOps) == 0 {
+			fmt.Println(ui.StyleError("Specify files, --clean targets or --forensic operations to plan"))
+			return
+		}
+
+		method, err := methodFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		verify, err := verifyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		guard, err := policyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		passes, _ := cmd.Flags().GetInt("passes")
+		options := shredder.WipeOptions{
+			Passes: passes,
+			Method: method,
+			Auto:   autoFromFlags(cmd),
+			Verify: verify,
+			Guard:  guard,
+		}
+
+		s := shredder.New()
+		var jobs []plan.Job
+		if len(args) > 0 {
+			jobs = append(jobs, plan.Job{Source: "wipe", Targets: expandTargets(args), Recursive: recursive})
+		}
+
+		for _, target := range cleanTargets {
+			switch target {
+			case "all":
+				jobs = append(jobs,
+					plan.Job{Source: "clean: browser", Targets: s.BrowserTargets(), Recursive: true},
+					plan.Job{Source: "clean: temp", Targets: s.TempTargets(), Recursive: true})
+			case "browser":
+				jobs = append(jobs, plan.Job{Source: "clean: browser", Targets: s.BrowserTargets(), Recursive: true})
+			case "temp":
+				jobs = append(jobs, plan.Job{Source: "clean: temp", Targets: s.TempTargets(), Recursive: true})
+			default:
+				fmt.Printf(ui.StyleError("Cannot plan clean target: %s\n"), target)
+				fmt.Println(ui.StyleInfo("Plannable targets: all, browser, temp"))
+				return
+			}
+		}
+
+		var actions []forensic.Trace
+		var reserve int64
+		if len(forensicOps) > 0 {
+			forensicOptions, err := forensicOptionsFromNames(forensicOps)
+			if err != nil {
+				fmt.Printf(ui.StyleError("%v\n"), err)
+				return
+			}
+			forensicOptions.FreeSpacePath, _ = cmd.Flags().GetString("freespace-path")
+			reserveFlag, _ := cmd.Flags().GetString("reserve")
+			if reserve, err = parseSize(reserveFlag); err != nil {
+				fmt.Printf(ui.StyleError("invalid --reserve: %v\n"), err)
+				return
+			}
+
+			var forensicJobs []plan.Job
+			forensicJobs, actions = plan.FromTraces(forensic.New(true, false).Traces(forensicOptions))
+			jobs = append(jobs, forensicJobs...)
+		}
+
+		m := plan.Build(s, options, jobs, actions)
+		m.FreeSpaceReserve = reserve
+		if err := m.Save(output); err != nil {
+			fmt.Printf(ui.StyleError("Cannot write plan: %v\n"), err)
+			return
+		}
+
+		for _, entry := range m.Entries {
+			if entry.Refused != "" {
+				fmt.Printf(ui.StyleWarning("⛔ %s: %s\n"), entry.Path, entry.Refused)
+			}
+		}
+		for _, action := range m.Actions {
+			fmt.Printf(ui.StyleInfo("⚙️  %s: %s %s\n"), action.Operation, action.Action, action.Target)
+		}
+
+		totals := m.Totals
+		fmt.Printf(ui.StyleHeader("\n📋 Plan written to %s\n"), output)
+		fmt.Printf(ui.StyleInfo("%d file(s) holding %s, %d director(ies), %d action(s), %d refused, using %s\n"),
+			totals.Files, ui.FormatBytes(totals.Bytes), totals.Dirs, totals.Actions, totals.Refused, describeMethod(options))
+		fmt.Printf(ui.StyleMuted("Execute with: wipeOs apply %s\n"), output)
+	},
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <manifest>",
+	Short: "▶️  Execute a plan written by 'wipeOs plan'",
+	Long: ui.StyleHeader("Apply a Plan") + `
+
+This command wipes exactly the entries of a manifest written by 'wipeOs
+plan', with the method recorded in it. Every entry is checked against the
+device, inode, size and modification time it had when planned; entries
+that changed or disappeared are refused, and entries that did not exist
+then are left alone. Forensic actions of the plan run afterwards.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := plan.Load(args[0])
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot open plan: %v\n"), err)
+			return
+		}
+		options, err := m.Options()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Plan %s is not usable: %v\n"), args[0], err)
+			return
+		}
+		guard, err := policyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		if options.Audit, err = auditor(); err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		totals := m.Totals
+		summary := fmt.Sprintf("apply a plan removing %d file(s) (%s) and %d director(ies) with %d action(s)",
+			totals.Files, ui.FormatBytes(totals.Bytes), totals.Dirs, totals.Actions)
+		force, _ := cmd.Flags().GetBool("force")
+		if !force && !ui.ConfirmDangerous(summary) {
+			fmt.Println(ui.StyleInfo("Operation cancelled"))
+			return
+		}
+
+		options.Force = true
+		options.Jobs, _ = cmd.Flags().GetInt("jobs")
+		options.Progress = newProgressSink()
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		j := newJournal()
+		if j != nil {
+			options.Journal = j
+		}
+		defer finishJournal(ctx, j)
+
+		options.HashContent, _ = cmd.Flags().GetBool("hash-content")
+		cert := newCertificate(cmd, "apply", options)
+		defer issueCertificate(cmd, cert)
+
+		fmt.Printf(ui.StyleInfo("▶️  Applying %s using %s...\n"), args[0], describeMethod(options))
+		results := plan.Apply(ctx, shredder.New(), m, guard, options)
+		cert.AddWipeResults(results)
+		printWipeResults(results, options)
+
+		if len(m.Actions) > 0 && ctx.Err() == nil {
+			verbose, _ := cmd.Flags().GetBool("verbose")
+			fmt.Println(ui.StyleHeader("\n🕵️  Forensic actions:"))
+			antiForensic := forensic.New(false, verbose)
+			antiForensic.SetAuditor(options.Audit)
+			actions := plan.RunActions(ctx, antiForensic, m, forensic.ForensicCleanOptions{Passes: options.Passes})
+			cert.AddOperations(actions)
+			for _, result := range actions {
+				if result.Success {
+					fmt.Printf(ui.StyleSuccess("✓ %s: %s\n"), result.Operation, result.Details)
+				} else {
+					fmt.Printf(ui.StyleError("✗ %s: %v\n"), result.Operation, result.Error)
+				}
+			}
+		}
+	},
+}
+
+// forensicOptionsFromNames selects forensic operations by the names of
+// the flags of the forensic command, plus all and quick
+func forensicOptionsFromNames(names []string) (forensic.ForensicCleanOptions, error) {
+	var options forensic.ForensicCleanOptions
+	for _, name := range names {
+		switch name {
+		case "all":
+			options.CleanLogs, options.CleanRegistry, options.CleanPrefetch = true, true, true
+			options.CleanThumbnails, options.CleanEventLogs, options.CleanMFT = true, true, true
+			options.CleanShadowCopies, options.CleanMemory, options.CleanSwap = true, true, true
+			options.WipeFreespace = true
+		case "quick":
+			options.CleanLogs, options.CleanRegistry = true, true
+			options.CleanThumbnails, options.CleanEventLogs = true, true
+		case "logs":
+			options.CleanLogs = true
+		case "registry":
+			options.CleanRegistry = true
+		case "prefetch":
+			options.CleanPrefetch = true
+		case "thumbnails":
+			options.CleanThumbnails = true
+		case "eventlogs":
+			options.CleanEventLogs = true
+		case "mft":
+			options.CleanMFT = true
+		case "shadows":
+			options.CleanShadowCopies = true
+		case "memory":
+			options.CleanMemory = true
+		case "swap":
+			options.CleanSwap = true
+		case "freespace":
+			options.WipeFreespace = true
+		default:
+			return options, fmt.Errorf("unknown forensic operation %q (all, quick, logs, registry, prefetch, thumbnails, eventlogs, mft, shadows, memory, swap, freespace)", name)
+		}
+	}
+	return options, nil
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+
+	planCmd.Flags().BoolP("recursive", "r", false, "Plan directories recursively")
+	planCmd.Flags().StringSlice("clean", nil, "Clean targets to plan: all, browser, temp")
+	planCmd.Flags().StringSlice("forensic", nil, "Forensic operations to plan, named like the flags of 'wipeOs forensic'")
+	planCmd.Flags().StringP("output", "o", "wipeos-plan.json", "File the manifest is written to")
+	planCmd.Flags().IntP("passes", "p", 3, "Number of overwrite passes (1-35)")
+	planCmd.Flags().StringP("method", "m", "", "Wipe method name, custom pass list, or auto to choose per disk type (overrides --passes)")
+	planCmd.Flags().String("verify", "none", "Read back and check overwrite passes: none, last or all")
+	planCmd.Flags().Lookup("verify").NoOptDefVal = "last"
+	planCmd.Flags().String("freespace-path", ".", "Directory on the filesystem whose free space is wiped")
+	planCmd.Flags().String("reserve", "256M", "Free space left untouched while wiping free space (K, M, G, T suffixes)")
+	addPolicyFlags(planCmd)
+
+	applyCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
+	applyCmd.Flags().IntP("jobs", "j", 0, "Files overwritten in parallel (0 = one per CPU, spinning disks always use 1)")
+	applyCmd.Flags().BoolP("verbose", "v", false, "Show detailed progress of forensic actions")
+	addPolicyFlags(applyCmd)
+	addCertificateFlags(applyCmd, true)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/joao-rrondon/wipeOs/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+// policyFromFlags loads the safety policy and applies the --max-files,
+// --max-size and --no-limits flags to its job limits
+func policyFromFlags(cmd *cobra.Command) (*policy.Policy, error) {
+	p, err := policy.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Flags().Changed("max-files") {
+		maxFiles, _ := cmd.Flags().GetInt("max-files")
+		if maxFiles < 0 {
+			return nil, fmt.Errorf("--max-files must not be negative, got %d", maxFiles)
+		}
+		p.MaxFiles = maxFiles
+	}
+	if cmd.Flags().Changed("max-size") {
+		value, _ := cmd.Flags().GetString("max-size")
+		maxBytes, err := parseSize(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --max-size: %v", err)
+		}
+		p.MaxBytes = maxBytes
+	}
+	if noLimits, _ := cmd.Flags().GetBool("no-limits"); noLimits {
+		p.MaxFiles, p.MaxBytes = 0, 0
+	}
+	return p, nil
+}
+
+// addPolicyFlags registers the flags that override the job limits
+func addPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("max-files", policy.DefaultMaxFiles, "Refuse jobs wiping more files than this (0 = no limit)")
+	cmd.Flags().String("max-size", "64G", "Refuse jobs wiping more bytes than this (0 = no limit)")
+	cmd.Flags().Bool("no-limits", false, "Lift the file count and size limits of the safety policy")
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"golang.org/x/term"
+)
+
+// progressRedrawInterval limits how often the live progress bar is redrawn
+const progressRedrawInterval = 100 * time.Millisecond
+
+// progressRenderer draws a live progress bar for wipe jobs on a terminal
+type progressRenderer struct {
+	out       io.Writer
+	started   time.Time
+	lastDraw  time.Time
+	total     int64
+	done      int64
+	files     int
+	filesDone int
+	current   string
+	pass      int
+	passes    int
+	drawn     bool
+}
+
+// newProgressSink returns a sink rendering a live progress bar, or nil
+// when stdout is not a terminal
+func newProgressSink() shredder.ProgressSink {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil
+	}
+	return &progressRenderer{out: os.Stdout}
+}
+
+// Progress implements shredder.ProgressSink
+func (r *progressRenderer) Progress(event shredder.ProgressEvent) {
+	switch event.Kind {
+	case shredder.EventJobStarted:
+		r.started = time.Now()
+		r.total = event.Total
+		r.files = event.Files
+		r.done = 0
+		r.filesDone = 0
+	case shredder.EventFileStarted:
+		r.current = event.Path
+		r.pass = 0
+		r.passes = event.Passes
+	case shredder.EventPassStarted:
+		r.current = event.Path
+		r.pass = event.Pass
+		r.passes = event.Passes
+	case shredder.EventBytesWritten:
+		r.done += event.Bytes
+	case shredder.EventFileFinished, shredder.EventError:
+		r.filesDone++
+	case shredder.EventJobFinished:
+		r.draw(true)
+		r.clear()
+		return
+	}
+	r.draw(false)
+}
+
+// draw redraws the progress line, throttled unless force is set
+func (r *progressRenderer) draw(force bool) {
+	now := time.Now()
+	if !force && now.Sub(r.lastDraw) < progressRedrawInterval {
+		return
+	}
+	r.lastDraw = now
+
+	label := fmt.Sprintf("%d/%d files", r.filesDone, r.files)
+	if r.current != "" && r.pass > 0 {
+		label += fmt.Sprintf(" · pass %d/%d %s", r.pass, r.passes, filepath.Base(r.current))
+	}
+
+	line := ui.TransferBar(r.done, r.total, now.Sub(r.started), label)
+	if line == "" {
+		line = ui.ProgressBar(r.filesDone, r.files, "files")
+	}
+	fmt.Fprintf(r.out, "\r\033[K%s", line)
+	r.drawn = true
+}
+
+// clear removes the progress line once the job is over
+func (r *progressRenderer) clear() {
+	if r.drawn {
+		fmt.Fprint(r.out, "\r\033[K")
+		r.drawn = false
+	}
+}
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/config"
+	"github.com/joao-rrondon/wipeOs/internal/policy"
+	"github.com/joao-rrondon/wipeOs/internal/quarantine"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+// sweepInterval is how often quarantine sweep --watch looks for due items
+const sweepInterval = time.Minute
+
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "🗃️  Inspect, restore or purge quarantined targets",
+	Long: ui.StyleHeader("Quarantine") + `
+
+'wipe --quarantine' moves targets into a staging directory on their own
+filesystem instead of wiping them. Until their delay has passed they can be
+restored; after that a sweep wipes them with the usual overwrite passes.
+Nothing is wiped behind your back: sweeps only run with 'quarantine sweep',
+or continuously with 'quarantine sweep --watch'.
+
+Examples:
+  wipeOs wipe ~/old-project -r --quarantine   # Stage for 24 hours
+  wipeOs quarantine list                      # Show quarantined items
+  wipeOs quarantine restore ~/old-project     # Put it back (by path or ID)
+  wipeOs quarantine purge 20240101-120000     # Wipe an item right away
+  wipeOs quarantine sweep --watch             # Wipe items as they fall due`,
+}
+
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List quarantined items",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := openQuarantine()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		items, err := store.Items()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot read quarantine: %v\n"), err)
+			return
+		}
+
+		if len(items) == 0 {
+			fmt.Println(ui.StyleSuccess("Quarantine is empty"))
+			return
+		}
+
+		now := time.Now()
+		fmt.Println(ui.StyleHeader("🗃️  Quarantined items:"))
+		for _, item := range items {
+			due := "purge due now"
+			if !item.Due(now) {
+				due = "purged after " + item.PurgeAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Printf("  %s  %s  %s\n",
+				ui.StyleInfo(item.ID),
+				item.Original,
+				ui.StyleMuted(fmt.Sprintf("(%s, %s)", ui.FormatBytes(item.Size), due)))
+		}
+	},
+}
+
+var quarantineRestoreCmd = &cobra.Command{
+	Use:   "restore <id|path>...",
+	Short: "Move quarantined items back to where they came from",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := openQuarantine()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		for _, ref := range args {
+			item, err := store.Find(ref)
+			if err == nil {
+				item, err = store.Restore(item.ID)
+			}
+			if err != nil {
+				fmt.Printf(ui.StyleError("✗ %s: %v\n"), ref, err)
+				continue
+			}
+			fmt.Printf(ui.StyleSuccess("↩️  Restored %s\n"), item.Original)
+		}
+	},
+}
+
+var quarantinePurgeCmd = &cobra.Command{
+	Use:   "purge [id|path...]",
+	Short: "Wipe quarantined items now (all of them without arguments)",
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := openQuarantine()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		options, err := quarantineOptions(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		var items []quarantine.Item
+		if len(args) == 0 {
+			if items, err = store.Items(); err != nil {
+				fmt.Printf(ui.StyleError("Cannot read quarantine: %v\n"), err)
+				return
+			}
+		}
+		for _, ref := range args {
+			item, err := store.Find(ref)
+			if err != nil {
+				fmt.Printf(ui.StyleError("✗ %s: %v\n"), ref, err)
+				continue
+			}
+			items = append(items, item)
+		}
+		if len(items) == 0 {
+			fmt.Println(ui.StyleSuccess("Nothing to purge"))
+			return
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !force && !options.DryRun && !ui.ConfirmDangerous(fmt.Sprintf("wipe %d quarantined item(s)", len(items))) {
+			fmt.Println(ui.StyleInfo("Operation cancelled"))
+			return
+		}
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		fmt.Printf(ui.StyleInfo("🧹 Purging %d quarantined item(s) using %s...\n"), len(items), describeMethod(options))
+		results, err := store.Purge(ctx, shredder.New(), items, options)
+		printWipeResults(results, options)
+		if err != nil {
+			fmt.Printf(ui.StyleError("Failed to update quarantine manifest: %v\n"), err)
+		}
+	},
+}
+
+var quarantineSweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "Wipe the quarantined items whose delay has passed",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		options, err := quarantineOptions(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		watch, _ := cmd.Flags().GetBool("watch")
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		s := shredder.New()
+		if !watch {
+			if sweepQuarantine(ctx, s, options) == 0 {
+				fmt.Println(ui.StyleSuccess("No quarantined items are due"))
+			}
+			return
+		}
+
+		fmt.Printf(ui.StyleInfo("👀 Sweeping the quarantine every %s, press Ctrl+C to stop\n"), sweepInterval)
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for {
+			sweepQuarantine(ctx, s, options)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// openQuarantine opens the quarantine in the configuration directory
+func openQuarantine() (*quarantine.Store, error) {
+	dir, err := config.SubDir("quarantine")
+	if err != nil {
+		return nil, fmt.Errorf("cannot access quarantine directory: %w", err)
+	}
+	return quarantine.Open(dir)
+}
+
+// quarantineTargets moves targets into quarantine for delay instead of
+// wiping them. Targets are vetted like they are by a wipe: protected
+// targets and directories without --recursive are refused, and the job as
+// a whole must stay within the limits, as purges no longer check them.
+func quarantineTargets(s *shredder.Shredder, targets []string, delay time.Duration, options shredder.WipeOptions) {
+	store, err := openQuarantine()
+	if err != nil {
+		fmt.Printf(ui.StyleError("%v\n"), err)
+		return
+	}
+
+	var accepted []string
+	for _, target := range targets {
+		if err := options.Guard.Check(target); err != nil {
+			fmt.Printf(ui.StyleWarning("⛔ %s: %v\n"), target, err)
+			continue
+		}
+		if info, err := os.Lstat(target); err == nil && info.IsDir() && !options.Recursive {
+			fmt.Printf(ui.StyleError("✗ %s: is a directory, use --recursive flag\n"), target)
+			continue
+		}
+		accepted = append(accepted, target)
+	}
+	if err := limitQuarantine(s, accepted, options); err != nil {
+		fmt.Printf(ui.StyleWarning("⛔ %v\n"), err)
+		return
+	}
+
+	staged := 0
+	for _, target := range accepted {
+		item, err := store.Add(target, delay)
+		if err != nil {
+			fmt.Printf(ui.StyleError("✗ %s: %v\n"), target, err)
+			continue
+		}
+		staged++
+		fmt.Printf(ui.StyleSuccess("🗃️  %s %s\n"), item.Original, ui.StyleMuted(fmt.Sprintf("(quarantined as %s)", item.ID)))
+	}
+
+	if staged > 0 {
+		fmt.Printf(ui.StyleInfo("\n%d target(s) quarantined until %s\n"), staged, time.Now().Add(delay).Format("2006-01-02 15:04"))
+		fmt.Println(ui.StyleMuted("Undo with: wipeOs quarantine restore <id|path>"))
+	}
+}
+
+// limitQuarantine checks the files a wipe of targets would overwrite
+// against the limits of the guard of options
+func limitQuarantine(s *shredder.Shredder, targets []string, options shredder.WipeOptions) error {
+	guard := options.Guard
+	options.Guard = nil
+
+	var files int
+	var bytes int64
+	for _, target := range s.Resolve(targets, options) {
+		if target.Dir || target.Err != nil {
+			continue
+		}
+		files++
+		if info, err := os.Lstat(target.Path); err == nil {
+			bytes += info.Size()
+		}
+	}
+	return guard.Limit(files, bytes)
+}
+
+// sweepQuarantine wipes the quarantined items that are due and returns how
+// many there were. Problems are reported but never stop the caller.
+func sweepQuarantine(ctx context.Context, s *shredder.Shredder, options shredder.WipeOptions) int {
+	store, err := openQuarantine()
+	if err != nil {
+		fmt.Printf(ui.StyleWarning("⚠️  Quarantine sweep skipped: %v\n"), err)
+		return 0
+	}
+
+	// Sweeps are never journaled: an interrupted one is simply retried
+	options.Journal = nil
+	due, results, err := store.Sweep(ctx, s, time.Now(), options)
+	if err != nil {
+		fmt.Printf(ui.StyleWarning("⚠️  Quarantine sweep incomplete: %v\n"), err)
+	}
+	if len(due) == 0 {
+		return 0
+	}
+
+	failed := 0
+	for _, result := range results {
+		if !result.Success && !result.Kept {
+			failed++
+			fmt.Printf(ui.StyleError("✗ %s: %v\n"), result.Path, result.Error)
+		}
+	}
+	fmt.Printf(ui.StyleInfo("🗃️  Purged %d quarantined item(s) past their delay, %d failure(s)\n"), len(due), failed)
+	return len(due)
+}
+
+// quarantineOptions builds the wipe options of purge and sweep from their
+// flags
+func quarantineOptions(cmd *cobra.Command) (shredder.WipeOptions, error) {
+	method, err := methodFromFlags(cmd)
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+	verify, err := verifyFromFlags(cmd)
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+	// The limits were accepted when the items were quarantined; the
+	// protected paths are vetted again
+	guard, err := policy.LoadConfig()
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+	guard.MaxFiles, guard.MaxBytes = 0, 0
+	auditLog, err := auditor()
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+
+	passes, _ := cmd.Flags().GetInt("passes")
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	return shredder.WipeOptions{
+		Recursive: true,
+		Passes:    passes,
+		Method:    method,
+		Auto:      autoFromFlags(cmd),
+		Verify:    verify,
+		Jobs:      jobs,
+		Force:     true,
+		DryRun:    dryRun,
+		Guard:     guard,
+		Audit:     auditLog,
+	}, nil
+}
+
+func init() {
+	rootCmd.AddCommand(quarantineCmd)
+	quarantineCmd.AddCommand(quarantineListCmd, quarantineRestoreCmd, quarantinePurgeCmd, quarantineSweepCmd)
+
+	for _, cmd := range []*cobra.Command{quarantinePurgeCmd, quarantineSweepCmd} {
+		cmd.Flags().IntP("passes", "p", 3, "Number of overwrite passes (1-35)")
+		cmd.Flags().StringP("method", "m", "", "Wipe method name, custom pass list, or auto to choose per disk type (overrides --passes)")
+		cmd.Flags().String("verify", "none", "Read back and check overwrite passes: none, last or all")
+		cmd.Flags().Lookup("verify").NoOptDefVal = "last"
+		cmd.Flags().IntP("jobs", "j", 0, "Files overwritten in parallel (0 = one per CPU, spinning disks always use 1)")
+		cmd.Flags().Bool("dry-run", false, "Show what would be wiped without actually doing it")
+	}
+	quarantinePurgeCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
+	quarantineSweepCmd.Flags().Bool("watch", false, "Keep running and wipe items as they fall due")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/policy"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// quarantined returns the items in the quarantine of the configuration
+// directory
+func quarantined(t *testing.T) int {
+	store, err := openQuarantine()
+	require.NoError(t, err)
+	items, err := store.Items()
+	require.NoError(t, err)
+	return len(items)
+}
+
+func TestQuarantineTargets_Limits(t *testing.T) {
+	t.Setenv("WIPEOS_CONFIG_DIR", t.TempDir())
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("data"), 0o600))
+	}
+
+	guard := policy.Default()
+	guard.MaxFiles = 1
+	options := shredder.WipeOptions{Recursive: true, Guard: guard}
+	quarantineTargets(shredder.New(), []string{dir}, time.Hour, options)
+	assert.Zero(t, quarantined(t))
+	assert.DirExists(t, dir)
+
+	guard.MaxFiles = 2
+	quarantineTargets(shredder.New(), []string{dir}, time.Hour, options)
+	assert.Equal(t, 1, quarantined(t))
+	assert.NoDirExists(t, dir)
+}
+
+func TestQuarantineTargets_DirectoryNeedsRecursive(t *testing.T) {
+	t.Setenv("WIPEOS_CONFIG_DIR", t.TempDir())
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0o600))
+
+	options := shredder.WipeOptions{Guard: policy.Default()}
+	quarantineTargets(shredder.New(), []string{dir}, time.Hour, options)
+	assert.Zero(t, quarantined(t))
+	assert.FileExists(t, file)
+
+	// Files are still quarantined without it
+	quarantineTargets(shredder.New(), []string{file}, time.Hour, options)
+	assert.Equal(t, 1, quarantined(t))
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+)
+
+// printWipeResults prints one line per wipe result followed by a summary
+func printWipeResults(results []shredder.WipeResult, options shredder.WipeOptions) {
+	fileCount := 0
+	successCount := 0
+	verifiedCount := 0
+	dirCount := 0
+	dirRemoved := 0
+	dirKept := 0
+	refusedCount := 0
+	unlinkedCount := 0
+	unconfirmed := 0
+	var policies []*shredder.AutoPolicy
+	seenPolicies := make(map[string]bool)
+
+	for _, result := range results {
+		if result.Policy != nil && !seenPolicies[result.Policy.String()] {
+			seenPolicies[result.Policy.String()] = true
+			policies = append(policies, result.Policy)
+		}
+
+		if result.Refused {
+			refusedCount++
+			fmt.Printf(ui.StyleWarning("⛔ %s: %v\n"), result.Path, result.Error)
+			continue
+		}
+
+		if result.IsDir {
+			dirCount++
+			switch {
+			case result.Success:
+				dirRemoved++
+				status := "directory scrubbed and removed"
+				if options.NoScrub {
+					status = "directory removed"
+				}
+				fmt.Printf(ui.StyleSuccess("✓ %s/ %s\n"), result.Path, ui.StyleMuted("("+status+")"))
+			case result.Kept:
+				dirKept++
+				fmt.Printf(ui.StyleWarning("📁 %s/ %s\n"), result.Path, ui.StyleMuted(fmt.Sprintf("(%v)", result.Error)))
+			default:
+				fmt.Printf(ui.StyleError("✗ %s/: %v\n"), result.Path, result.Error)
+			}
+			continue
+		}
+
+		fileCount++
+		if result.Success && result.UnlinkedOnly {
+			successCount++
+			unlinkedCount++
+			fmt.Printf(ui.StyleSuccess("✓ %s %s\n"), result.Path, ui.StyleMuted(fmt.Sprintf("(%s, unlinked without overwriting)", describeType(result))))
+		} else if result.Success {
+			successCount++
+			status := "wiped"
+			if result.Verification == shredder.VerifyPassed {
+				verifiedCount++
+				status = "wiped and verified"
+			}
+			if result.Discarded {
+				status += ", blocks discarded"
+				if result.Trimmed {
+					status += " and trimmed"
+				}
+			}
+			if result.SlackBytes > 0 {
+				status += fmt.Sprintf(", +%s slack", ui.FormatBytes(result.SlackBytes))
+			}
+			if result.PhysicalSize < result.Size {
+				status += fmt.Sprintf(", sparse: %s of %s allocated", ui.FormatBytes(result.PhysicalSize), ui.FormatBytes(result.Size))
+			}
+			fmt.Printf(ui.StyleSuccess("✓ %s %s\n"), result.Path, ui.StyleMuted(fmt.Sprintf("(%s, %s, %d passes)", status, result.Method, result.Passes)))
+			if result.DiscardError != nil {
+				fmt.Printf(ui.StyleWarning("  ⚠️ discard failed: %v\n"), result.DiscardError)
+			} else if result.Discarded && result.TrimError != nil {
+				fmt.Printf(ui.StyleWarning("  ⚠️ filesystem not trimmed: %v\n"), result.TrimError)
+			}
+			if result.Assurance != shredder.AssuranceInPlace && result.Assurance != shredder.AssuranceNone {
+				unconfirmed++
+				fmt.Printf(ui.StyleWarning("  ⚠️ in-place overwrite %s: %s\n"), result.Assurance, result.AssuranceNote)
+			}
+		} else {
+			fmt.Printf(ui.StyleError("✗ %s: %v\n"), result.Path, result.Error)
+		}
+
+		for _, step := range result.Scrub {
+			if step.Error != nil {
+				fmt.Printf(ui.StyleWarning("  ⚠️ %s step failed: %v\n"), step.Action, step.Error)
+			}
+		}
+	}
+
+	fmt.Printf(ui.StyleHeader("\n📊 Summary: %d/%d files wiped successfully\n"), successCount, fileCount)
+	if options.Verify != shredder.VerifyNone {
+		fmt.Printf(ui.StyleInfo("🔎 %d/%d files wiped and verified (--verify=%s)\n"), verifiedCount, successCount, options.Verify)
+	}
+	if dirCount > 0 {
+		fmt.Printf(ui.StyleInfo("📁 %d/%d directories removed, %d kept because they still hold entries that were not wiped\n"), dirRemoved, dirCount, dirKept)
+	}
+	for _, policy := range policies {
+		fmt.Printf(ui.StyleInfo("🧭 Auto method: %s\n"), policy)
+		if policy.Warning != "" {
+			fmt.Printf(ui.StyleWarning("  🔐 %s\n"), policy.Warning)
+			fmt.Println(ui.StyleMuted("  💡 To sanitize the whole volume, close it and run wipeOs crypto-erase on the device below it"))
+		}
+	}
+	if unconfirmed > 0 {
+		fmt.Printf(ui.StyleError("⚠️  %d file(s) could not be confirmed as overwritten in place: the original data may still be on disk\n"), unconfirmed)
+	}
+	if unlinkedCount > 0 {
+		fmt.Printf(ui.StyleInfo("🔗 %d entries unlinked without overwriting (special files or hard links)\n"), unlinkedCount)
+	}
+	if refusedCount > 0 {
+		fmt.Printf(ui.StyleWarning("⛔ %d entries refused and left untouched (see the reason given for each)\n"), refusedCount)
+	}
+}
+
+// describeMethod names the method a job runs with, for the line announcing
+// it
+func describeMethod(options shredder.WipeOptions) string {
+	if options.Auto {
+		return "automatic method selection per device"
+	}
+	method := options.Method
+	if method.IsZero() {
+		method = shredder.DefaultMethod(options.Passes)
+	}
+	return fmt.Sprintf("%s (%d passes)", method.Name, method.PassCount())
+}
+
+// describeType names the kind of entry of a result
+func describeType(result shredder.WipeResult) string {
+	if result.Type == shredder.TypeRegular && result.Links > 1 {
+		return fmt.Sprintf("hard link, %d names", result.Links)
+	}
+	return string(result.Type)
+}
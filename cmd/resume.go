@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/joao-rrondon/wipeOs/internal/config"
+	"github.com/joao-rrondon/wipeOs/internal/journal"
+	"github.com/joao-rrondon/wipeOs/internal/policy"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume [journal]",
+	Short: "⏯️  Resume an interrupted wipe job",
+	Long: ui.StyleHeader("Resume Interrupted Wipes") + `
+
+Every wipe keeps a journal of its targets and of the pass and offset
+reached on each file. When a job is interrupted (Ctrl+C, SIGTERM, crash),
+the journal is kept and this command finishes the job, continuing
+partially overwritten files from their last checkpoint.
+
+Examples:
+  wipeOs resume                         # List interrupted jobs
+  wipeOs resume 20240101-120000-ab12cd  # Resume a job by ID
+  wipeOs resume /path/to/journal.json   # Resume from a journal file`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := journalDir()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot access journal directory: %v\n"), err)
+			return
+		}
+
+		if len(args) == 0 {
+			listJournals(dir)
+			return
+		}
+
+		j, err := journal.Find(dir, args[0])
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot open journal %s: %v\n"), args[0], err)
+			return
+		}
+
+		options, err := j.Options()
+		if err != nil {
+			fmt.Printf(ui.StyleError("Journal %s is not usable: %v\n"), j.ID(), err)
+			return
+		}
+
+		leftovers := j.Leftovers()
+		targets := j.Remaining()
+		if len(targets) == 0 && len(leftovers) == 0 {
+			fmt.Println(ui.StyleSuccess("Nothing left to wipe, removing journal"))
+			if err := j.Remove(); err != nil {
+				fmt.Printf(ui.StyleWarning("Failed to remove journal %s: %v\n"), j.Path(), err)
+			}
+			return
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !force && !ui.ConfirmDangerous(fmt.Sprintf("resume wiping %d target(s)", len(targets)+len(leftovers))) {
+			fmt.Println(ui.StyleInfo("Operation cancelled"))
+			return
+		}
+
+		// The limits were accepted when the job started; the protected
+		// paths are vetted again
+		guard, err := policy.LoadConfig()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		guard.MaxFiles, guard.MaxBytes = 0, 0
+		if options.Audit, err = auditor(); err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		// Files renamed while being scrubbed were already overwritten and
+		// only need to be removed
+		removed, err := j.RemoveLeftovers()
+		for _, path := range removed {
+			fmt.Printf(ui.StyleSuccess("✓ %s (removed, left behind by the interrupted job)\n"), path)
+		}
+		if err != nil {
+			fmt.Printf(ui.StyleWarning("⚠️  Some files left behind were not removed: %v\n"), err)
+		}
+		if len(targets) == 0 {
+			finishJournal(context.Background(), j)
+			return
+		}
+
+		options.Force = true
+		options.Guard = guard
+		options.Jobs, _ = cmd.Flags().GetInt("jobs")
+		options.Progress = newProgressSink()
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		fmt.Printf(ui.StyleInfo("⏯️  Resuming %d target(s) using %s...\n"), len(targets), describeMethod(options))
+		results := shredder.New().WipeFiles(ctx, targets, options)
+		printWipeResults(results, options)
+		finishJournal(ctx, j)
+	},
+}
+
+// journalDir returns the directory holding wipe journals
+func journalDir() (string, error) {
+	return config.SubDir("journal")
+}
+
+// newJournal starts a journal for a wipe job. Failures are reported but
+// do not prevent the wipe from running.
+func newJournal() *journal.Journal {
+	dir, err := journalDir()
+	if err == nil {
+		var j *journal.Journal
+		if j, err = journal.Create(dir); err == nil {
+			return j
+		}
+	}
+	fmt.Printf(ui.StyleWarning("⚠️  Wipe journal unavailable, the job cannot be resumed if interrupted: %v\n"), err)
+	return nil
+}
+
+// finishJournal removes the journal of a completed job, or explains how
+// to resume an interrupted one
+func finishJournal(ctx context.Context, j *journal.Journal) {
+	if j == nil {
+		return
+	}
+
+	if ctx.Err() != nil || j.Interrupted() {
+		fmt.Println(ui.StyleWarning("\n⏹️  Wipe interrupted, partially overwritten files were left in place"))
+		fmt.Printf(ui.StyleInfo("Resume with: wipeOs resume %s\n"), j.ID())
+		return
+	}
+
+	if err := j.Remove(); err != nil {
+		fmt.Fprintf(os.Stderr, ui.StyleWarning("Failed to remove journal %s: %v\n"), j.Path(), err)
+	}
+}
+
+// listJournals prints the journals of interrupted jobs
+func listJournals(dir string) {
+	journals, err := journal.List(dir)
+	if err != nil {
+		fmt.Printf(ui.StyleError("Cannot list journals: %v\n"), err)
+		return
+	}
+
+	if len(journals) == 0 {
+		fmt.Println(ui.StyleSuccess("No interrupted wipe jobs"))
+		return
+	}
+
+	fmt.Println(ui.StyleHeader("⏯️  Interrupted wipe jobs:"))
+	for _, j := range journals {
+		job := j.Job()
+		pending := 0
+		for _, entry := range job.Entries {
+			if entry.Status != journal.StatusDone {
+				pending++
+			}
+		}
+		fmt.Printf("  %s  %s  %s\n",
+			ui.StyleInfo(job.ID),
+			ui.StyleMuted(job.Created.Local().Format("2006-01-02 15:04")),
+			ui.StyleMuted(fmt.Sprintf("%d target(s), %d file(s) unfinished, %s", len(job.Targets), pending, job.Method)))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+
+	resumeCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
+	resumeCmd.Flags().IntP("jobs", "j", 0, "Files overwritten in parallel (0 = one per CPU, spinning disks always use 1)")
+}
@@ -0,0 +1,15 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context cancelled on SIGINT or SIGTERM, so
+// that running wipes stop at a safe boundary instead of being killed
+// mid-write
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
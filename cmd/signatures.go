@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/internal/signatures"
+	"github.com/joao-rrondon/wipeOs/ui"
+	"github.com/spf13/cobra"
+)
+
+var signaturesCmd = &cobra.Command{
+	Use:   "signatures <path>",
+	Short: "🏷️  List or erase filesystem and partition table signatures",
+	Long: ui.StyleHeader("Signature Wiping") + `
+
+This command detects filesystem superblocks, MBR and GPT partition tables
+(primary and backup headers), RAID, LVM, LUKS and swap signatures on a
+block device or disk image, and erases them without overwriting the rest
+of the device, like wipefs. Only the magic bytes are zeroed, which is
+enough for the disk to look blank to every tool. Erasing refuses devices
+that are mounted or in use.
+
+Examples:
+  wipeOs signatures /dev/sdb                            # List signatures
+  wipeOs signatures /dev/sdb --erase all                # Erase every signature
+  wipeOs signatures /dev/sdb --erase gpt,PMBR           # Erase selected types
+  wipeOs signatures disk.img --erase all --backup sig.bak
+  wipeOs signatures disk.img --restore sig.bak          # Put them back`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := args[0]
+		erase, _ := cmd.Flags().GetStringSlice("erase")
+		backupPath, _ := cmd.Flags().GetString("backup")
+		restorePath, _ := cmd.Flags().GetString("restore")
+		force, _ := cmd.Flags().GetBool("force")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if restorePath != "" {
+			restoreSignatures(path, restorePath, force)
+			return
+		}
+
+		flag := os.O_RDONLY
+		if len(erase) > 0 && !dryRun {
+			flag = os.O_RDWR
+		}
+
+		var file *os.File
+		var image bool
+		var err error
+		if flag == os.O_RDONLY {
+			file, err = os.Open(path)
+		} else {
+			file, image, err = shredder.OpenDevice(path, flag)
+		}
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot open %s: %v\n"), path, err)
+			return
+		}
+		defer file.Close()
+
+		size, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("Cannot size %s: %v\n"), path, err)
+			return
+		}
+
+		found := signatures.Scan(file, size)
+		if len(found) == 0 {
+			fmt.Println(ui.StyleSuccess("✓ No known signatures found"))
+			return
+		}
+
+		fmt.Println(ui.StyleHeader(fmt.Sprintf("🏷️  %d signature(s) on %s", len(found), path)))
+		for _, s := range found {
+			fmt.Printf("  %-18s %-16s 0x%-10x %s\n", s.Type, s.Usage, s.Offset, ui.StyleMuted(fmt.Sprintf("% x", s.Magic)))
+		}
+		if len(erase) == 0 {
+			return
+		}
+
+		selected := found
+		if !(len(erase) == 1 && strings.EqualFold(erase[0], "all")) {
+			selected = signatures.Filter(found, erase)
+		}
+		if len(selected) == 0 {
+			fmt.Println(ui.StyleWarning("No signature matches --erase " + strings.Join(erase, ",")))
+			return
+		}
+
+		if dryRun {
+			for _, s := range selected {
+				fmt.Printf(ui.StyleInfo("🔍 Would erase %s\n"), s)
+			}
+			return
+		}
+		if !force && !ui.ConfirmDangerous(fmt.Sprintf("erase %d signature(s) on %s", len(selected), path)) {
+			fmt.Println(ui.StyleInfo("Operation cancelled"))
+			return
+		}
+
+		// The backup must be on disk before anything is erased
+		if backupPath != "" {
+			if err := saveBackup(backupPath, selected); err != nil {
+				fmt.Printf(ui.StyleError("Cannot write backup, nothing was erased: %v\n"), err)
+				return
+			}
+		}
+
+		err = signatures.Erase(file, selected)
+		if err == nil {
+			err = file.Sync()
+		}
+		if err != nil {
+			fmt.Printf(ui.StyleError("✗ %v\n"), err)
+			return
+		}
+
+		for _, s := range selected {
+			fmt.Printf(ui.StyleSuccess("✓ Erased %s\n"), s)
+		}
+		if backupPath != "" {
+			fmt.Printf(ui.StyleInfo("💾 Erased bytes saved to %s\n"), backupPath)
+		}
+		if !image {
+			if err := shredder.RereadPartitions(file); err != nil {
+				fmt.Printf(ui.StyleWarning("⚠️ The kernel did not reload the partition table: %v\n"), err)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(signaturesCmd)
+
+	signaturesCmd.Flags().StringSlice("erase", nil, "Erase signatures: all, or a list of types or usages (e.g. gpt,PMBR,ext4,raid)")
+	signaturesCmd.Flags().String("backup", "", "Save the erased bytes to this file before erasing")
+	signaturesCmd.Flags().String("restore", "", "Write back signatures saved with --backup")
+	signaturesCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
+	signaturesCmd.Flags().Bool("dry-run", false, "Show what would be erased without doing it")
+}
+
+// restoreSignatures writes back a backup made by --erase --backup
+func restoreSignatures(path, backupPath string, force bool) {
+	backup, err := os.Open(backupPath)
+	if err != nil {
+		fmt.Printf(ui.StyleError("Cannot open backup: %v\n"), err)
+		return
+	}
+	defer backup.Close()
+
+	if !force && !ui.ConfirmDangerous(fmt.Sprintf("restore signatures from %s onto %s", backupPath, path)) {
+		fmt.Println(ui.StyleInfo("Operation cancelled"))
+		return
+	}
+
+	file, _, err := shredder.OpenDevice(path, os.O_RDWR)
+	if err != nil {
+		fmt.Printf(ui.StyleError("Cannot open %s: %v\n"), path, err)
+		return
+	}
+	defer file.Close()
+
+	restored, err := signatures.Restore(file, backup)
+	if err == nil {
+		err = file.Sync()
+	}
+	for _, s := range restored {
+		fmt.Printf(ui.StyleSuccess("✓ Restored %s\n"), s)
+	}
+	if err != nil {
+		fmt.Printf(ui.StyleError("✗ %v\n"), err)
+	}
+}
+
+// saveBackup writes the bytes of found to a new file at path and syncs it
+func saveBackup(path string, found []signatures.Signature) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = signatures.WriteBackup(f, found)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/joao-rrondon/wipeOs/internal/journal"
+	"github.com/joao-rrondon/wipeOs/internal/quarantine"
 	"github.com/joao-rrondon/wipeOs/internal/shredder"
 	"github.com/joao-rrondon/wipeOs/ui"
 	"github.com/spf13/cobra"
@@ -24,6 +28,13 @@ Examples:
   wipeOs wipe /tmp/sensitive/ --recursive   # Wipe entire directory
   wipeOs wipe --browser-data                # Wipe browser cache/history
   wipeOs wipe --system-temp                 # Clean system temporary files
+  wipeOs wipe secret.txt --method gutmann   # Use a named wipe method
+  wipeOs wipe secret.txt --method 0x00,random,0xFF  # Custom pass sequence
+  wipeOs wipe secret.txt --method auto      # Pick passes and discard per disk type
+  wipeOs wipe old-project/ -r --quarantine  # Stage for 24h, restorable until then
+
+Wipe methods:
+` + methodHelp() + `
 
 ⚠️  WARNING: This operation is IRREVERSIBLE!`,
 	Args: func(cmd *cobra.Command, args []string) error {
@@ -43,18 +54,99 @@ Examples:
 		systemTemp, _ := cmd.Flags().GetBool("system-temp")
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
+		method, err := methodFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		verify, err := verifyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+
+		noScrub, _ := cmd.Flags().GetBool("no-scrub")
+		followSymlinks, _ := cmd.Flags().GetBool("follow-symlinks")
+		hardLinks, err := hardLinksFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		jobs, _ := cmd.Flags().GetInt("jobs")
+		directIO, _ := cmd.Flags().GetBool("direct")
+		slack, _ := cmd.Flags().GetBool("slack")
+		discard, err := discardFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		blockSize, err := blockSizeFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		guard, err := policyFromFlags(cmd)
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		auditLog, err := auditor()
+		if err != nil {
+			fmt.Printf(ui.StyleError("%v\n"), err)
+			return
+		}
+		hashContent, _ := cmd.Flags().GetBool("hash-content")
+		quarantined, _ := cmd.Flags().GetBool("quarantine")
+		delay, _ := cmd.Flags().GetDuration("quarantine-delay")
+		if quarantined && (browserData || systemTemp) {
+			fmt.Println(ui.StyleError("--quarantine only applies to the files given on the command line"))
+			return
+		}
+
 		options := shredder.WipeOptions{
-			Recursive: recursive,
-			Passes:    passes,
-			Force:     force,
-			DryRun:    dryRun,
+			NoScrub:        noScrub,
+			FollowSymlinks: followSymlinks,
+			HardLinks:      hardLinks,
+			BlockSize:      blockSize,
+			DirectIO:       directIO,
+			Slack:          slack,
+			Discard:        discard,
+			Jobs:           jobs,
+			Progress:       newProgressSink(),
+			Recursive:      recursive,
+			Passes:         passes,
+			Force:          force,
+			DryRun:         dryRun,
+			Method:         method,
+			Auto:           autoFromFlags(cmd),
+			Verify:         verify,
+			Guard:          guard,
+			HashContent:    hashContent,
+			Audit:          auditLog,
 		}
 
 		s := shredder.New()
 
+		ctx, stop := interruptContext()
+		defer stop()
+
+		var j *journal.Journal
+		if !dryRun && !quarantined {
+			if j = newJournal(); j != nil {
+				options.Journal = j
+			}
+		}
+		defer finishJournal(ctx, j)
+
+		cert := newCertificate(cmd, "wipe", options)
+		defer issueCertificate(cmd, cert)
+
 		if browserData {
 			fmt.Println(ui.StyleWarning("🌐 Wiping browser data..."))
-			if err := s.WipeBrowserData(options); err != nil {
+			results, err := s.WipeBrowserData(ctx, options)
+			cert.AddWipeResults(results)
+			if err != nil {
 				fmt.Printf(ui.StyleError("Failed to wipe browser data: %v\n"), err)
 				return
 			}
@@ -62,63 +154,186 @@ Examples:
 
 		if systemTemp {
 			fmt.Println(ui.StyleWarning("🗂️  Wiping system temporary files..."))
-			if err := s.WipeSystemTemp(options); err != nil {
+			results, err := s.WipeSystemTemp(ctx, options)
+			cert.AddWipeResults(results)
+			if err != nil {
 				fmt.Printf(ui.StyleError("Failed to wipe system temp: %v\n"), err)
 				return
 			}
 		}
 
 		if len(args) > 0 {
-			var targets []string
-			for _, arg := range args {
-				if strings.Contains(arg, "*") {
-					matches, err := filepath.Glob(arg)
-					if err != nil {
-						fmt.Printf(ui.StyleError("Invalid pattern '%s': %v\n"), arg, err)
-						continue
-					}
-					targets = append(targets, matches...)
-				} else {
-					targets = append(targets, arg)
-				}
-			}
+			targets := expandTargets(args)
 
 			if len(targets) == 0 {
 				fmt.Println(ui.StyleWarning("No files matched the specified patterns"))
 				return
 			}
 
+			if quarantined {
+				if dryRun {
+					fmt.Printf(ui.StyleInfo("🧪 Would quarantine %d target(s) for %s\n"), len(targets), delay)
+					return
+				}
+				quarantineTargets(s, targets, delay, options)
+				return
+			}
+
 			if !force && !ui.ConfirmDangerous(fmt.Sprintf("wipe %d file(s)", len(targets))) {
 				fmt.Println(ui.StyleInfo("Operation cancelled"))
 				return
 			}
 
-			fmt.Printf(ui.StyleInfo("🧹 Wiping %d file(s) with %d passes...\n"), len(targets), passes)
+			fmt.Printf(ui.StyleInfo("🧹 Wiping %d file(s) using %s...\n"), len(targets), describeMethod(options))
 			
-			results := s.WipeFiles(targets, options)
+			results := s.WipeFiles(ctx, targets, options)
+			cert.AddWipeResults(results)
 			
-			successCount := 0
-			for _, result := range results {
-				if result.Success {
-					successCount++
-					fmt.Printf(ui.StyleSuccess("✓ %s\n"), result.Path)
-				} else {
-					fmt.Printf(ui.StyleError("✗ %s: %v\n"), result.Path, result.Error)
-				}
-			}
-
-			fmt.Printf(ui.StyleHeader("\n📊 Summary: %d/%d files wiped successfully\n"), successCount, len(results))
+			printWipeResults(results, options)
 		}
 	},
 }
 
+// expandTargets expands the glob patterns among the targets given on the
+// command line
+func expandTargets(args []string) []string {
+	var targets []string
+	for _, arg := range args {
+		if strings.Contains(arg, "*") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				fmt.Printf(ui.StyleError("Invalid pattern '%s': %v\n"), arg, err)
+				continue
+			}
+			targets = append(targets, matches...)
+		} else {
+			targets = append(targets, arg)
+		}
+	}
+	return targets
+}
+
 func init() {
 	rootCmd.AddCommand(wipeCmd)
 
 	wipeCmd.Flags().BoolP("recursive", "r", false, "Wipe directories recursively")
 	wipeCmd.Flags().IntP("passes", "p", 3, "Number of overwrite passes (1-35)")
+	wipeCmd.Flags().StringP("method", "m", "", "Wipe method name, custom pass list, or auto to choose per disk type (overrides --passes)")
+	wipeCmd.Flags().String("verify", "none", "Read back and check overwrite passes: none, last or all")
+	wipeCmd.Flags().Lookup("verify").NoOptDefVal = "last"
+	wipeCmd.Flags().Bool("no-scrub", false, "Skip truncating, renaming and resetting timestamps before unlink")
+	wipeCmd.Flags().Bool("follow-symlinks", false, "Wipe the targets of symbolic links instead of refusing them")
+	wipeCmd.Flags().String("hardlinks", "skip", "Files with other hard links: skip, unlink (keep data) or overwrite (destroys data for all links)")
+	wipeCmd.Flags().IntP("jobs", "j", 0, "Files overwritten in parallel (0 = one per CPU, spinning disks always use 1)")
+	wipeCmd.Flags().String("block-size", "1M", "Size of each write, a multiple of 4K (e.g. 64K, 1M, 8M)")
+	wipeCmd.Flags().Bool("direct", false, "Write with O_DIRECT, bypassing the page cache (Linux)")
+	wipeCmd.Flags().Bool("slack", false, "Also overwrite the slack after the end of each file up to its last filesystem block")
+	wipeCmd.Flags().String("discard", "none", "Release file blocks to the device (punch hole + FITRIM): none, after overwriting, or only instead of overwriting")
+	wipeCmd.Flags().Lookup("discard").NoOptDefVal = "after"
+	addPolicyFlags(wipeCmd)
+	addCertificateFlags(wipeCmd, true)
 	wipeCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompts")
 	wipeCmd.Flags().Bool("browser-data", false, "Wipe browser cache, history, and temp files")
 	wipeCmd.Flags().Bool("system-temp", false, "Wipe system temporary files")
 	wipeCmd.Flags().Bool("dry-run", false, "Show what would be wiped without actually doing it")
-} 
\ No newline at end of file
+	wipeCmd.Flags().Bool("quarantine", false, "Move the targets into quarantine instead, to be wiped once --quarantine-delay has passed")
+	wipeCmd.Flags().Duration("quarantine-delay", quarantine.DefaultDelay, "How long quarantined targets can still be restored")
+}
+
+// methodFromFlags resolves the wipe method selected by --method, falling
+// back to the default rotation with --passes passes. With --method auto
+// the default rotation applies where the medium cannot be detected.
+func methodFromFlags(cmd *cobra.Command) (shredder.WipeMethod, error) {
+	spec, _ := cmd.Flags().GetString("method")
+	if spec == "" || autoFromFlags(cmd) {
+		passes, _ := cmd.Flags().GetInt("passes")
+		if passes < 1 || passes > 35 {
+			return shredder.WipeMethod{}, fmt.Errorf("--passes must be between 1 and 35, got %d", passes)
+		}
+		return shredder.DefaultMethod(passes), nil
+	}
+	return shredder.ParseMethod(spec)
+}
+
+// autoFromFlags reports whether --method auto was given
+func autoFromFlags(cmd *cobra.Command) bool {
+	spec, _ := cmd.Flags().GetString("method")
+	return strings.EqualFold(strings.TrimSpace(spec), "auto")
+}
+
+// methodHelp lists the built-in wipe methods for command help text
+func methodHelp() string {
+	var b strings.Builder
+	for _, m := range shredder.Methods() {
+		fmt.Fprintf(&b, "  %-12s %s\n", m.Name, m.Description)
+	}
+	b.WriteString("  auto         DoD 3 passes on spinning disks, 1 random pass + discard on SSDs\n")
+	b.WriteString("  custom       Comma separated passes, e.g. 0x00,random,0xFF")
+	return b.String()
+}
+
+// verifyFromFlags parses the --verify flag
+func verifyFromFlags(cmd *cobra.Command) (shredder.VerifyMode, error) {
+	value, _ := cmd.Flags().GetString("verify")
+	return shredder.ParseVerifyMode(value)
+}
+
+// discardFromFlags parses the --discard flag
+func discardFromFlags(cmd *cobra.Command) (shredder.DiscardMode, error) {
+	value, _ := cmd.Flags().GetString("discard")
+	return shredder.ParseDiscardMode(value)
+}
+
+// hardLinksFromFlags parses the --hardlinks flag
+func hardLinksFromFlags(cmd *cobra.Command) (shredder.HardLinkPolicy, error) {
+	value, _ := cmd.Flags().GetString("hardlinks")
+	return shredder.ParseHardLinkPolicy(value)
+}
+
+// blockSizeFromFlags parses the --block-size flag, which accepts a byte
+// count with an optional K, M, G or T suffix
+func blockSizeFromFlags(cmd *cobra.Command) (int, error) {
+	value, _ := cmd.Flags().GetString("block-size")
+	size, err := parseSize(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --block-size: %v", err)
+	}
+	if err := shredder.ValidateBlockSize(int(size)); err != nil {
+		return 0, fmt.Errorf("invalid --block-size: %w", err)
+	}
+	return int(size), nil
+}
+
+// parseSize parses a byte count with an optional K, M, G or T suffix
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(value), "B"))
+
+	multiplier := int64(1)
+	if n := len(value); n > 0 {
+		switch value[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			value = value[:n-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("size must not be negative")
+	}
+	if n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("size is too large")
+	}
+	return n * multiplier, nil
+}
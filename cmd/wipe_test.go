@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"4K", 4 << 10},
+		{"4KB", 4 << 10},
+		{" 256M ", 256 << 20},
+		{"1G", 1 << 30},
+		{"2T", 2 << 40},
+		{"8388607T", 8388607 << 40},
+	}
+	for _, tt := range tests {
+		size, err := parseSize(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, size, tt.value)
+	}
+}
+
+func TestParseSize_Invalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"K",
+		"1.5G",
+		"12X",
+		"-1",
+		"-4K",
+		// Would wrap around to exactly 1 TiB and to a negative size
+		"16777217T",
+		"8388608T",
+		"9223372036854775807K",
+	} {
+		_, err := parseSize(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,609 @@
+// Package audit keeps an opt-in, append-only log of every operation that
+// destroyed data. Each record carries the hash of the record before it, so
+// changing, removing or reordering records breaks the chain, and a head
+// file holding the latest hash reveals records cut from the end.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/config"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+)
+
+// Verification errors
+var (
+	// ErrBroken is returned when a record was changed, removed or
+	// inserted
+	ErrBroken = errors.New("audit log chain is broken")
+	// ErrTruncated is returned when records are missing from the end of
+	// the log
+	ErrTruncated = errors.New("audit log was truncated")
+)
+
+const (
+	// ConfigName is the name of the audit settings file in the
+	// configuration directory
+	ConfigName = "audit.json"
+	// DirName is the directory of the log below the configuration
+	// directory
+	DirName = "audit"
+	// LogName is the name of the log in the audit directory
+	LogName = "audit.log"
+	// headName holds the sequence number and hash of the latest record
+	headName = "audit.head"
+	// saltName holds the key hashed paths are derived with
+	saltName = "audit.salt"
+	// tailSize is how much of the end of the log is read to find the
+	// latest record
+	tailSize = 64 << 10
+)
+
+// Config selects whether and how operations are audited
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// HashPaths stores a keyed hash of every target instead of its path
+	HashPaths bool `json:"hash_paths,omitempty"`
+}
+
+// LoadConfig reads the audit settings from path. A missing file leaves
+// auditing disabled.
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid audit settings %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Save writes the audit settings to path
+func (c Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// LoadSettings reads the audit settings of the configuration directory
+func LoadSettings() (Config, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return Config{}, err
+	}
+	return LoadConfig(filepath.Join(dir, ConfigName))
+}
+
+// SaveSettings writes the audit settings of the configuration directory
+func SaveSettings(c Config) error {
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	return c.Save(filepath.Join(dir, ConfigName))
+}
+
+// Dir returns the directory of the audit log in the configuration
+// directory
+func Dir() (string, error) {
+	return config.SubDir(DirName)
+}
+
+// OpenConfigured opens the audit log of the configuration directory as its
+// settings select. It returns nil when auditing is disabled, and fails when
+// records can no longer be appended.
+func OpenConfigured() (*Log, error) {
+	c, err := LoadSettings()
+	if err != nil || !c.Enabled {
+		return nil, err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	l, err := Open(dir, c.HashPaths)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.Check(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record is one entry of the audit log
+type Record struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	// Prev is the hash of the previous record, empty for the first one
+	Prev      string `json:"prev"`
+	Operation string `json:"operation"`
+	Target    string `json:"target"`
+	// Hashed reports that Target is a keyed hash of the path
+	Hashed  bool   `json:"hashed,omitempty"`
+	Outcome string `json:"outcome"`
+	Detail  string `json:"detail,omitempty"`
+	// Hash covers every other field of the record
+	Hash string `json:"hash,omitempty"`
+}
+
+// digest returns the hash of the record without its Hash field
+func (r Record) digest() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// OperationRecover is the operation of the record appended after a record
+// left incomplete by a crash. It chains from the last complete record.
+const OperationRecover = "recover"
+
+// head is the persisted position of the latest record
+type head struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// Log is an audit log kept in a directory. It implements
+// shredder.Auditor.
+type Log struct {
+	mu   sync.Mutex
+	dir  string
+	salt []byte
+	// last is the latest record and end the size of the log once it was
+	// written, so that the log is only read again after another process
+	// appended to it
+	last *Record
+	end  int64
+
+	// headMu orders head updates, which happen once records are synced;
+	// headSeq is the latest sequence number written to the head
+	headMu  sync.Mutex
+	headSeq uint64
+}
+
+// Open returns the audit log kept in dir, creating the directory if
+// needed. With hashPaths, targets are stored as keyed hashes.
+func Open(dir string, hashPaths bool) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	l := &Log{dir: dir}
+	if hashPaths {
+		salt, err := loadSalt(filepath.Join(dir, saltName))
+		if err != nil {
+			return nil, err
+		}
+		l.salt = salt
+	}
+	return l, nil
+}
+
+// Check reports whether records can be appended to the log, which fails
+// once the latest record was altered
+func (l *Log) Check() error {
+	f, err := os.Open(l.Path())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, _, err = lastRecord(f)
+	return err
+}
+
+// Path returns the path of the log file
+func (l *Log) Path() string {
+	return filepath.Join(l.dir, LogName)
+}
+
+// HashTarget returns the target as it is stored for path: a keyed hash
+// when paths are hashed, otherwise the path itself
+func (l *Log) HashTarget(path string) string {
+	if l.salt == nil {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	mac := hmac.New(sha256.New, l.salt)
+	mac.Write([]byte(path))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Audit appends a record of event. A record left incomplete by a crash is
+// closed off first, followed by a recovery record. The record is synced
+// and the head moved to it after the log is unlocked, so that concurrent
+// callers only wait for each other's writes.
+func (l *Log) Audit(event shredder.AuditEvent) error {
+	f, err := os.OpenFile(l.Path(), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	record, err := l.append(f, event)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	l.headMu.Lock()
+	defer l.headMu.Unlock()
+	if record.Seq <= l.headSeq {
+		// A later record already moved the head
+		return nil
+	}
+	if err := writeHead(filepath.Join(l.dir, headName), head{Seq: record.Seq, Hash: record.Hash}); err != nil {
+		return err
+	}
+	l.headSeq = record.Seq
+	return nil
+}
+
+// append writes the record of event to f, chained to the latest record
+func (l *Log) append(f *os.File, event shredder.AuditEvent) (Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Other processes append to the same log
+	if err := lockFile(f); err != nil {
+		return Record{}, err
+	}
+	defer unlockFile(f)
+
+	info, err := f.Stat()
+	if err != nil {
+		return Record{}, err
+	}
+	var out []byte
+	if info.Size() != l.end || (l.last == nil && info.Size() > 0) {
+		last, torn, err := lastRecord(f)
+		if err != nil {
+			return Record{}, err
+		}
+		l.last = last
+		if torn > 0 {
+			recovery := Record{
+				Operation: OperationRecover,
+				Target:    LogName,
+				Outcome:   shredder.OutcomeDone,
+				Detail:    fmt.Sprintf("%d bytes of an incomplete record skipped", torn),
+			}
+			line, err := l.chain(&recovery)
+			if err != nil {
+				return Record{}, err
+			}
+			out = append(append(out, '\n'), line...)
+		}
+	}
+
+	record := Record{
+		Operation: event.Operation,
+		Target:    l.HashTarget(event.Target),
+		Hashed:    l.salt != nil,
+		Outcome:   event.Outcome,
+		Detail:    event.Detail,
+	}
+	line, err := l.chain(&record)
+	if err != nil {
+		return Record{}, err
+	}
+	out = append(out, line...)
+
+	if _, err := f.Write(out); err != nil {
+		// Whatever reached the log is read again next time
+		l.end = -1
+		return Record{}, err
+	}
+	l.end = info.Size() + int64(len(out))
+	return record, nil
+}
+
+// chain links r to the latest record, makes it the latest and returns its
+// line. The caller must hold l.mu.
+func (l *Log) chain(r *Record) ([]byte, error) {
+	r.Time = time.Now().UTC()
+	r.Seq = 1
+	if l.last != nil {
+		r.Seq = l.last.Seq + 1
+		r.Prev = l.last.Hash
+	}
+	var err error
+	if r.Hash, err = r.digest(); err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	l.last = r
+	return append(line, '\n'), nil
+}
+
+// Report summarizes a verified log
+type Report struct {
+	Records int
+	// Last is the latest record, nil for an empty log
+	Last *Record
+	// Recovered lists the recovery records, each following a record left
+	// incomplete by a crash
+	Recovered []Record
+	// Torn reports that the log ends with an incomplete record that no
+	// recovery record follows yet
+	Torn bool
+}
+
+// Verify checks the chain of the log kept in dir against its head. A head
+// lagging behind the log, as left by a crash right after a record was
+// written, is accepted as long as it matches its record.
+func Verify(dir string) (Report, error) {
+	var report Report
+
+	h, err := readHead(filepath.Join(dir, headName))
+	if err != nil {
+		return report, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, LogName))
+	if errors.Is(err, os.ErrNotExist) {
+		if h.Seq > 0 {
+			return report, fmt.Errorf("%w: log is missing, %d records expected", ErrTruncated, h.Seq)
+		}
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	defer f.Close()
+
+	var prev Record
+	headSeen := h.Seq == 0
+	torn := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, tailSize), tailSize)
+	for line := 1; scanner.Scan(); line++ {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// Only a crash leaves an incomplete record, and the next
+			// append follows it with a recovery record
+			if torn {
+				return report, fmt.Errorf("%w: line %d is not a record: %v", ErrBroken, line, err)
+			}
+			torn = true
+			continue
+		}
+		switch {
+		case torn && r.Operation != OperationRecover:
+			return report, fmt.Errorf("%w: line %d is not a record", ErrBroken, line-1)
+		case !torn && r.Operation == OperationRecover:
+			return report, fmt.Errorf("%w: recovery record %d follows a complete record", ErrBroken, r.Seq)
+		case torn:
+			report.Recovered = append(report.Recovered, r)
+		}
+		torn = false
+
+		digest, err := r.digest()
+		if err != nil {
+			return report, err
+		}
+		switch {
+		case r.Hash != digest:
+			return report, fmt.Errorf("%w: record %d was modified", ErrBroken, r.Seq)
+		case r.Seq != prev.Seq+1:
+			return report, fmt.Errorf("%w: record %d follows record %d", ErrBroken, r.Seq, prev.Seq)
+		case r.Prev != prev.Hash:
+			return report, fmt.Errorf("%w: record %d does not follow the record before it", ErrBroken, r.Seq)
+		}
+		if r.Seq == h.Seq {
+			if r.Hash != h.Hash {
+				return report, fmt.Errorf("%w: record %d does not match the head", ErrBroken, r.Seq)
+			}
+			headSeen = true
+		}
+
+		prev = r
+		report.Records++
+		report.Last = &r
+	}
+	if err := scanner.Err(); err != nil {
+		return report, err
+	}
+	report.Torn = torn
+
+	if h.Seq == 0 && report.Records > 0 {
+		return report, fmt.Errorf("%w: head is missing", ErrBroken)
+	}
+	if !headSeen {
+		return report, fmt.Errorf("%w: %d records left, %d expected", ErrTruncated, prev.Seq, h.Seq)
+	}
+	return report, nil
+}
+
+// Find returns the records of the log kept in dir whose target is path,
+// stored as a path or as a keyed hash
+func Find(dir, path string) ([]Record, error) {
+	f, err := os.Open(filepath.Join(dir, LogName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	targets := map[string]bool{path: true}
+	if abs, err := filepath.Abs(path); err == nil {
+		targets[abs] = true
+	}
+	if salt, err := os.ReadFile(filepath.Join(dir, saltName)); err == nil {
+		l := &Log{dir: dir}
+		if l.salt, err = hex.DecodeString(string(bytes.TrimSpace(salt))); err != nil {
+			return nil, fmt.Errorf("invalid path key: %w", err)
+		}
+		targets[l.HashTarget(path)] = true
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, tailSize), tailSize)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if targets[r.Target] {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// lastRecord returns the latest complete record of the log, or nil when
+// there is none, along with the size of an incomplete record after it. Only
+// a crash during an append leaves a log that does not end with a newline.
+func lastRecord(f *os.File) (*Record, int, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, 0, nil
+	}
+
+	n := min(size, tailSize)
+	buf := make([]byte, n)
+	if _, err := f.ReadAt(buf, size-n); err != nil && !errors.Is(err, io.EOF) {
+		return nil, 0, err
+	}
+
+	torn := 0
+	if buf[len(buf)-1] != '\n' {
+		i := bytes.LastIndexByte(buf, '\n')
+		if i < 0 && n < size {
+			return nil, 0, fmt.Errorf("%w: incomplete record is longer than %d bytes", ErrBroken, tailSize)
+		}
+		torn = len(buf) - (i + 1)
+		buf = buf[:i+1]
+	}
+
+	buf = bytes.TrimSuffix(buf, []byte("\n"))
+	if len(buf) == 0 {
+		return nil, torn, nil
+	}
+	if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+		buf = buf[i+1:]
+	} else if n < size {
+		return nil, 0, fmt.Errorf("%w: last record is longer than %d bytes", ErrBroken, tailSize)
+	}
+
+	r := &Record{}
+	if err := json.Unmarshal(buf, r); err != nil {
+		return nil, 0, fmt.Errorf("%w: last record is unreadable: %v", ErrBroken, err)
+	}
+	digest, err := r.digest()
+	if err != nil {
+		return nil, 0, err
+	}
+	if digest != r.Hash {
+		return nil, 0, fmt.Errorf("%w: record %d was modified", ErrBroken, r.Seq)
+	}
+	return r, torn, nil
+}
+
+// readHead reads the head file. A missing head belongs to an empty log.
+func readHead(path string) (head, error) {
+	var h head
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return h, fmt.Errorf("%w: unreadable head: %v", ErrBroken, err)
+	}
+	return h, nil
+}
+
+// writeHead atomically replaces the head file. Processes appending at once
+// may leave a head lagging behind the log, which Verify accepts.
+func writeHead(path string, h head) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), headName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// loadSalt returns the key of hashed paths, creating it on first use
+func loadSalt(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return hex.DecodeString(string(bytes.TrimSpace(data)))
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return loadSalt(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.WriteString(hex.EncodeToString(salt)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return salt, f.Close()
+}
@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRecords(t *testing.T, l *Log, targets ...string) {
+	t.Helper()
+	for _, target := range targets {
+		require.NoError(t, l.Audit(shredder.AuditEvent{Operation: shredder.AuditWipeFile, Target: target, Outcome: shredder.OutcomeDone}))
+	}
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	report, err := Verify(dir)
+	require.NoError(t, err)
+	assert.Zero(t, report.Records)
+
+	l, err := Open(dir, false)
+	require.NoError(t, err)
+	writeRecords(t, l, "/data/a", "/data/b", "/data/c")
+
+	report, err = Verify(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Records)
+	assert.Equal(t, uint64(3), report.Last.Seq)
+	assert.Equal(t, "/data/c", report.Last.Target)
+
+	// Reopening continues the chain
+	l, err = Open(dir, false)
+	require.NoError(t, err)
+	writeRecords(t, l, "/data/d")
+	report, err = Verify(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Records)
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines []string) []string
+		want   error
+	}{
+		{"modified", func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], "/data/b", "/data/x", 1)
+			return lines
+		}, ErrBroken},
+		{"removed", func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}, ErrBroken},
+		{"reordered", func(lines []string) []string {
+			lines[0], lines[1] = lines[1], lines[0]
+			return lines
+		}, ErrBroken},
+		{"truncated", func(lines []string) []string {
+			return lines[:2]
+		}, ErrTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l, err := Open(dir, false)
+			require.NoError(t, err)
+			writeRecords(t, l, "/data/a", "/data/b", "/data/c")
+
+			path := filepath.Join(dir, LogName)
+			lines := tt.tamper(readLines(t, path))
+			require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "")), 0o600))
+
+			_, err = Verify(dir)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestAudit_RecoversTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, false)
+	require.NoError(t, err)
+	writeRecords(t, l, "/data/a", "/data/b")
+
+	// A crash in the middle of an append
+	f, err := os.OpenFile(l.Path(), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"seq":3,"time":"2024-`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	report, err := Verify(dir)
+	require.NoError(t, err)
+	assert.True(t, report.Torn)
+
+	reopened, err := Open(dir, false)
+	require.NoError(t, err)
+	require.NoError(t, reopened.Check())
+	writeRecords(t, reopened, "/data/c")
+
+	report, err = Verify(dir)
+	require.NoError(t, err)
+	assert.False(t, report.Torn)
+	require.Len(t, report.Recovered, 1)
+	assert.Equal(t, uint64(3), report.Recovered[0].Seq)
+	assert.Equal(t, uint64(4), report.Last.Seq)
+	assert.Equal(t, "/data/c", report.Last.Target)
+
+	// A recovery record cannot hide a record that was altered
+	lines := readLines(t, l.Path())
+	lines[1] = "garbage\n"
+	require.NoError(t, os.WriteFile(l.Path(), []byte(strings.Join(lines, "")), 0o600))
+	_, err = Verify(dir)
+	assert.ErrorIs(t, err, ErrBroken)
+}
+
+func TestCheck_AlteredLastRecord(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, false)
+	require.NoError(t, err)
+	writeRecords(t, l, "/data/a")
+	require.NoError(t, os.WriteFile(l.Path(), []byte("garbage\n"), 0o600))
+
+	assert.ErrorIs(t, l.Check(), ErrBroken)
+	assert.ErrorIs(t, l.Audit(shredder.AuditEvent{Operation: shredder.AuditWipeFile, Target: "/data/b"}), ErrBroken)
+
+	// A well-formed record whose content no longer matches its hash
+	dir = t.TempDir()
+	l, err = Open(dir, false)
+	require.NoError(t, err)
+	writeRecords(t, l, "/data/a")
+	data, err := os.ReadFile(l.Path())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(l.Path(), []byte(strings.Replace(string(data), "/data/a", "/data/x", 1)), 0o600))
+	assert.ErrorIs(t, l.Check(), ErrBroken)
+}
+
+func TestAudit_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, true)
+	require.NoError(t, err)
+	other, err := Open(dir, true)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Two logs stand for two processes sharing the file
+			log := l
+			if i%2 == 1 {
+				log = other
+			}
+			assert.NoError(t, log.Audit(shredder.AuditEvent{Operation: shredder.AuditWipeFile, Target: fmt.Sprintf("/data/%d", i)}))
+		}(i)
+	}
+	wg.Wait()
+
+	report, err := Verify(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 16, report.Records)
+}
+
+func TestOpen_HashPaths(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, true)
+	require.NoError(t, err)
+	writeRecords(t, l, "/data/secret.txt")
+
+	data, err := os.ReadFile(l.Path())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	report, err := Verify(dir)
+	require.NoError(t, err)
+	assert.True(t, report.Last.Hashed)
+
+	// The key is kept, so the same path always hashes the same
+	reopened, err := Open(dir, true)
+	require.NoError(t, err)
+	assert.Equal(t, report.Last.Target, reopened.HashTarget("/data/secret.txt"))
+	assert.NotEqual(t, report.Last.Target, reopened.HashTarget("/data/other.txt"))
+
+	found, err := Find(dir, "/data/secret.txt")
+	require.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestShredderRecordsWipes(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(filepath.Join(dir, "audit"), false)
+	require.NoError(t, err)
+
+	target := filepath.Join(dir, "files")
+	require.NoError(t, os.MkdirAll(target, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(target, "a.txt"), []byte("data"), 0o644))
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(target, "link")))
+
+	shredder.New().WipeFiles(context.Background(), []string{target}, shredder.WipeOptions{Recursive: true, Passes: 1, Force: true, Audit: l})
+
+	lines := readLines(t, l.Path())
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `"operation":"wipe-file"`)
+	assert.Contains(t, lines[1], `"outcome":"refused"`)
+	assert.Contains(t, lines[2], `"outcome":"kept"`)
+
+	_, err = Verify(filepath.Join(dir, "audit"))
+	assert.NoError(t, err)
+}
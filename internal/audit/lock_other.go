@@ -0,0 +1,16 @@
+//go:build !linux
+
+package audit
+
+import "os"
+
+// lockFile is a no-op on platforms without flock: records are only
+// serialized within one process
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without flock
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,366 @@
+// Package certificate produces certificates of sanitization, the record
+// NIST SP 800-88 asks for once media have been sanitized. A certificate
+// lists every item a job handled with the method and outcome, is signed
+// with an Ed25519 key kept in the WipeOs configuration directory, and is
+// rendered as JSON and as a printable HTML page.
+package certificate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/forensic"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+)
+
+// Version is the format version of certificates written by this package
+const Version = 1
+
+// Algorithm names the signature scheme of certificates
+const Algorithm = "ed25519"
+
+// Verification errors
+var (
+	// ErrUnsigned is returned when a certificate carries no signature
+	ErrUnsigned = errors.New("certificate is not signed")
+	// ErrSignature is returned when the signature does not match the
+	// content, which has been altered since signing
+	ErrSignature = errors.New("signature does not match the certificate")
+	// ErrUntrusted is returned when a certificate was signed with another
+	// key than the trusted one
+	ErrUntrusted = errors.New("certificate was signed with an untrusted key")
+	// ErrVersion is returned for certificates of an unknown format
+	ErrVersion = errors.New("unsupported certificate version")
+)
+
+// MethodAuto is the method of a certificate whose items were each wiped
+// with the method chosen for their device
+const MethodAuto = "auto"
+
+// Kinds of certified items
+const (
+	KindFile      = "file"
+	KindDirectory = "directory"
+	KindDevice    = "device"
+	KindImage     = "image"
+	KindOperation = "operation"
+)
+
+// Outcomes of certified items
+const (
+	// StatusSanitized means the data was overwritten, or the operation
+	// completed
+	StatusSanitized = "sanitized"
+	// StatusRemoved means the entry was removed without being overwritten,
+	// as for directories and special files
+	StatusRemoved = "removed"
+	StatusFailed  = "failed"
+	StatusRefused = "refused"
+	// StatusKept means a directory was left in place because it still
+	// holds entries that were not wiped
+	StatusKept = "kept"
+)
+
+// Item is one file, directory, device or operation handled by a job
+type Item struct {
+	Path         string `json:"path"`
+	Kind         string `json:"kind"`
+	Status       string `json:"status"`
+	Size         int64  `json:"size,omitempty"`
+	Method       string `json:"method,omitempty"`
+	Passes       int    `json:"passes,omitempty"`
+	Verification string `json:"verification,omitempty"`
+	// ContentHash is the hex SHA-256 of a file before it was overwritten
+	ContentHash string `json:"content_sha256,omitempty"`
+	// Serial and Model identify a wiped block device
+	Serial string `json:"serial,omitempty"`
+	Model  string `json:"model,omitempty"`
+	// ImageHash is the hex SHA-256 of a disk image after it was wiped
+	ImageHash string `json:"image_sha256,omitempty"`
+	Details   string `json:"details,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Summary counts the items of a certificate by outcome
+type Summary struct {
+	Items     int `json:"items"`
+	Sanitized int `json:"sanitized"`
+	Removed   int `json:"removed"`
+	Failed    int `json:"failed"`
+	Refused   int `json:"refused"`
+	Kept      int `json:"kept"`
+	// Bytes is the total size of the sanitized items
+	Bytes int64 `json:"bytes"`
+}
+
+// Signature is the detached signature of a certificate. Value signs the
+// JSON encoding of the certificate without its signature.
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+	Value     string `json:"value"`
+}
+
+// Certificate records the outcome of one job
+type Certificate struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	// Command is the WipeOs command that ran the job
+	Command  string    `json:"command"`
+	Host     string    `json:"host"`
+	Operator string    `json:"operator"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Method   string    `json:"method"`
+	// Passes lists the patterns of the overwrite passes in order. It is
+	// empty when the method was chosen per device, as each item then names
+	// its own.
+	Passes       []string   `json:"passes,omitempty"`
+	Verification string     `json:"verification"`
+	Items        []Item     `json:"items"`
+	Summary      Summary    `json:"summary"`
+	Signature    *Signature `json:"signature,omitempty"`
+}
+
+// New starts the certificate of a job run by command with options. The
+// operator is the current user unless one is given.
+func New(command, operator string, options shredder.WipeOptions) *Certificate {
+	method := options.Method
+	if method.IsZero() {
+		method = shredder.DefaultMethod(options.Passes)
+	}
+	if operator == "" {
+		if u, err := user.Current(); err == nil {
+			operator = u.Username
+		}
+	}
+	host, _ := os.Hostname()
+
+	c := &Certificate{
+		Version:      Version,
+		ID:           newID(),
+		Command:      command,
+		Host:         host,
+		Operator:     operator,
+		Started:      time.Now().UTC(),
+		Method:       method.Name,
+		Verification: options.Verify.String(),
+	}
+	if options.Auto {
+		c.Method = MethodAuto
+		return c
+	}
+	for _, p := range method.Passes {
+		c.Passes = append(c.Passes, p.String())
+	}
+	return c
+}
+
+// AddWipeResults records the results of a file wipe
+func (c *Certificate) AddWipeResults(results []shredder.WipeResult) {
+	for _, result := range results {
+		item := Item{
+			Path:         result.Path,
+			Kind:         KindFile,
+			Size:         result.Size,
+			Method:       result.Method,
+			Passes:       result.Passes,
+			Verification: string(result.Verification),
+			ContentHash:  result.ContentHash,
+		}
+		if result.IsDir {
+			item.Kind = KindDirectory
+		}
+		if result.Discarded {
+			item.Details = "blocks discarded"
+		}
+
+		switch {
+		case result.Refused:
+			item.Status = StatusRefused
+		case result.Kept:
+			item.Status = StatusKept
+		case !result.Success:
+			item.Status = StatusFailed
+		case result.IsDir || result.UnlinkedOnly:
+			item.Status = StatusRemoved
+		default:
+			item.Status = StatusSanitized
+		}
+		if result.Error != nil {
+			item.Error = result.Error.Error()
+		}
+		c.Items = append(c.Items, item)
+	}
+}
+
+// AddDevice records the result of a device or disk image wipe
+func (c *Certificate) AddDevice(result shredder.DeviceResult) {
+	item := Item{
+		Path:         result.Path,
+		Kind:         KindDevice,
+		Status:       StatusSanitized,
+		Size:         result.Length,
+		Method:       result.Method,
+		Passes:       result.Passes,
+		Verification: string(result.Verification),
+		Serial:       result.Serial,
+		Model:        result.Model,
+		ImageHash:    result.ImageHash,
+		Details:      fmt.Sprintf("%d bytes from offset %d of %d", result.Length, result.Offset, result.Size),
+	}
+	if result.Image {
+		item.Kind = KindImage
+	}
+	if !result.Success {
+		item.Status = StatusFailed
+	}
+	if result.Error != nil {
+		item.Error = result.Error.Error()
+	}
+	c.Items = append(c.Items, item)
+}
+
+// AddOperations records the results of forensic operations
+func (c *Certificate) AddOperations(results []forensic.CleanResult) {
+	for _, result := range results {
+		item := Item{Path: result.Operation, Kind: KindOperation, Status: StatusSanitized, Details: result.Details}
+		if !result.Success {
+			item.Status = StatusFailed
+		}
+		if result.Error != nil {
+			item.Error = result.Error.Error()
+		}
+		c.Items = append(c.Items, item)
+	}
+}
+
+// Finish closes the certificate at now and computes its summary
+func (c *Certificate) Finish(now time.Time) {
+	c.Finished = now.UTC()
+	c.Summary = Summary{Items: len(c.Items)}
+	for _, item := range c.Items {
+		switch item.Status {
+		case StatusSanitized:
+			c.Summary.Sanitized++
+			c.Summary.Bytes += item.Size
+		case StatusRemoved:
+			c.Summary.Removed++
+		case StatusFailed:
+			c.Summary.Failed++
+		case StatusRefused:
+			c.Summary.Refused++
+		case StatusKept:
+			c.Summary.Kept++
+		}
+	}
+}
+
+// Sign signs the certificate with key, replacing any previous signature
+func (c *Certificate) Sign(key ed25519.PrivateKey) error {
+	payload, err := c.payload()
+	if err != nil {
+		return err
+	}
+	c.Signature = &Signature{
+		Algorithm: Algorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	}
+	return nil
+}
+
+// Verify checks that the certificate is unchanged since it was signed by
+// trusted
+func (c *Certificate) Verify(trusted ed25519.PublicKey) error {
+	if c.Signature == nil {
+		return ErrUnsigned
+	}
+	if c.Signature.Algorithm != Algorithm {
+		return fmt.Errorf("%w: unknown algorithm %q", ErrSignature, c.Signature.Algorithm)
+	}
+	signer, err := base64.StdEncoding.DecodeString(c.Signature.PublicKey)
+	if err != nil || len(signer) != ed25519.PublicKeySize {
+		return fmt.Errorf("%w: invalid public key", ErrSignature)
+	}
+	value, err := base64.StdEncoding.DecodeString(c.Signature.Value)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature encoding", ErrSignature)
+	}
+
+	payload, err := c.payload()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(signer, payload, value) {
+		return ErrSignature
+	}
+	if !trusted.Equal(ed25519.PublicKey(signer)) {
+		return fmt.Errorf("%w: signed by %s, expected %s", ErrUntrusted, Fingerprint(signer), Fingerprint(trusted))
+	}
+	return nil
+}
+
+// Save writes the certificate to dir as <id>.json and <id>.html and
+// returns both paths
+func (c *Certificate) Save(dir string) (string, string, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	jsonPath := filepath.Join(dir, c.ID+".json")
+	if err := os.WriteFile(jsonPath, data, 0o600); err != nil {
+		return "", "", err
+	}
+
+	htmlPath := filepath.Join(dir, c.ID+".html")
+	f, err := os.OpenFile(htmlPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return jsonPath, "", err
+	}
+	if err := c.WriteHTML(f); err != nil {
+		f.Close()
+		return jsonPath, "", err
+	}
+	return jsonPath, htmlPath, f.Close()
+}
+
+// Load reads a certificate written by Save
+func Load(path string) (*Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Certificate{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrVersion, c.Version)
+	}
+	return c, nil
+}
+
+// payload returns the signed encoding of the certificate, which leaves out
+// the signature itself
+func (c *Certificate) payload() ([]byte, error) {
+	unsigned := *c
+	unsigned.Signature = nil
+	return json.Marshal(unsigned)
+}
+
+// newID returns a sortable, unique certificate identifier
+func newID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,155 @@
+package certificate
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/forensic"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCertificate() *Certificate {
+	c := New("wipe", "auditor", shredder.WipeOptions{Passes: 3})
+	c.AddWipeResults([]shredder.WipeResult{
+		{Path: "/data/a.txt", Success: true, Size: 100, Method: "standard", Passes: 3, ContentHash: "abc"},
+		{Path: "/data/b.txt", Error: errors.New("permission denied")},
+		{Path: "/data/link", Error: shredder.ErrSymlink, Refused: true},
+		{Path: "/data", Success: true, IsDir: true},
+	})
+	c.AddOperations([]forensic.CleanResult{{Operation: "Log Files", Success: true, Details: "Cleaned"}})
+	c.Finish(time.Now())
+	return c
+}
+
+func TestFinish(t *testing.T) {
+	c := testCertificate()
+
+	assert.Equal(t, "auditor", c.Operator)
+	assert.Equal(t, []string{"random", "0x00", "0xFF"}, c.Passes)
+	assert.Equal(t, Summary{Items: 5, Sanitized: 2, Removed: 1, Failed: 1, Refused: 1, Bytes: 100}, c.Summary)
+	assert.Equal(t, KindDirectory, c.Items[3].Kind)
+	assert.Equal(t, KindOperation, c.Items[4].Kind)
+}
+
+func TestNew_Auto(t *testing.T) {
+	c := New("wipe", "auditor", shredder.WipeOptions{Passes: 3, Auto: true})
+	c.AddWipeResults([]shredder.WipeResult{
+		{Path: "/ssd/a.txt", Success: true, Method: "random", Passes: 1, Discarded: true},
+		{Path: "/hdd/b.txt", Success: true, Method: "dod", Passes: 3},
+	})
+
+	// The passes depend on the device of each item, none is claimed for all
+	assert.Equal(t, MethodAuto, c.Method)
+	assert.Empty(t, c.Passes)
+	assert.Equal(t, 1, c.Items[0].Passes)
+	assert.Equal(t, "blocks discarded", c.Items[0].Details)
+	assert.Equal(t, 3, c.Items[1].Passes)
+
+	var page strings.Builder
+	require.NoError(t, c.WriteHTML(&page))
+	assert.Contains(t, page.String(), "chosen per device")
+	assert.NotContains(t, page.String(), "0xFF")
+}
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	key, err := LoadKey(dir)
+	require.NoError(t, err)
+	trusted := key.Public().(ed25519.PublicKey)
+
+	c := testCertificate()
+	assert.ErrorIs(t, c.Verify(trusted), ErrUnsigned)
+	require.NoError(t, c.Sign(key))
+
+	jsonPath, htmlPath, err := c.Save(dir)
+	require.NoError(t, err)
+	loaded, err := Load(jsonPath)
+	require.NoError(t, err)
+	assert.NoError(t, loaded.Verify(trusted))
+
+	// The public key written next to the signing key is trusted the same
+	public, err := ReadPublicKey(filepath.Join(dir, PublicKeyFile))
+	require.NoError(t, err)
+	assert.NoError(t, loaded.Verify(public))
+
+	page, err := os.ReadFile(htmlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "/data/a.txt")
+	assert.Contains(t, string(page), Fingerprint(trusted))
+
+	// Altering any item breaks the signature
+	loaded.Items[1].Status = StatusSanitized
+	assert.ErrorIs(t, loaded.Verify(trusted), ErrSignature)
+}
+
+func TestVerify_UntrustedKey(t *testing.T) {
+	_, other, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	key, err := LoadKey(t.TempDir())
+	require.NoError(t, err)
+
+	c := testCertificate()
+	require.NoError(t, c.Sign(other))
+	assert.ErrorIs(t, c.Verify(key.Public().(ed25519.PublicKey)), ErrUntrusted)
+}
+
+func TestLoadKey_Reused(t *testing.T) {
+	dir := t.TempDir()
+	first, err := LoadKey(dir)
+	require.NoError(t, err)
+	second, err := LoadKey(dir)
+	require.NoError(t, err)
+	assert.True(t, first.Equal(second))
+
+	info, err := os.Stat(filepath.Join(dir, KeyFile))
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestLoadKey_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	keys := make([]ed25519.PrivateKey, 8)
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i := range keys {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			keys[i], errs[i] = LoadKey(dir)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range keys {
+		require.NoError(t, errs[i])
+		assert.True(t, keys[0].Equal(keys[i]))
+	}
+	// No temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestLoadKey_RestoresPublicKey(t *testing.T) {
+	dir := t.TempDir()
+	key, err := LoadKey(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(dir, PublicKeyFile)))
+
+	_, err = LoadKey(dir)
+	require.NoError(t, err)
+	public, err := ReadPublicKey(filepath.Join(dir, PublicKeyFile))
+	require.NoError(t, err)
+	assert.True(t, public.Equal(key.Public()))
+}
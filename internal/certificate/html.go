@@ -0,0 +1,67 @@
+package certificate
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"html/template"
+	"io"
+)
+
+// page is the printable rendering of a certificate
+var page = template.Must(template.New("certificate").Funcs(template.FuncMap{
+	"fingerprint": func(s *Signature) string {
+		key, err := base64.StdEncoding.DecodeString(s.PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return "invalid key"
+		}
+		return Fingerprint(key)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Certificate of Sanitization {{.ID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #111; }
+h1 { font-size: 1.5em; border-bottom: 2px solid #111; padding-bottom: .3em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; font-size: .9em; }
+th, td { border: 1px solid #999; padding: .3em .5em; text-align: left; vertical-align: top; }
+th { background: #eee; }
+td.hash { font-family: monospace; word-break: break-all; }
+.failed, .refused { color: #a00; }
+.signature { font-family: monospace; word-break: break-all; font-size: .8em; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Certificate of Sanitization</h1>
+<table>
+<tr><th>Certificate</th><td>{{.ID}}</td></tr>
+<tr><th>Command</th><td>wipeOs {{.Command}}</td></tr>
+<tr><th>Host</th><td>{{.Host}}</td></tr>
+<tr><th>Operator</th><td>{{.Operator}}</td></tr>
+<tr><th>Started</th><td>{{.Started.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Finished</th><td>{{.Finished.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Method</th><td>{{if .Passes}}{{.Method}} ({{len .Passes}} passes{{range $i, $p := .Passes}}{{if $i}},{{else}}:{{end}} {{$p}}{{end}}){{else}}{{.Method}} (chosen per device, see each item){{end}}</td></tr>
+<tr><th>Verification</th><td>{{.Verification}}</td></tr>
+<tr><th>Outcome</th><td>{{.Summary.Sanitized}} sanitized, {{.Summary.Removed}} removed, {{.Summary.Failed}} failed, {{.Summary.Refused}} refused, {{.Summary.Kept}} kept, {{.Summary.Bytes}} bytes sanitized</td></tr>
+</table>
+<table>
+<tr><th>Item</th><th>Kind</th><th>Status</th><th>Size</th><th>Method</th><th>Passes</th><th>Verification</th><th>Details</th></tr>
+{{range .Items}}<tr class="{{.Status}}">
+<td>{{.Path}}</td><td>{{.Kind}}</td><td>{{.Status}}</td><td>{{.Size}}</td><td>{{.Method}}</td><td>{{.Passes}}</td><td>{{.Verification}}</td>
+<td>{{.Details}}{{if .Serial}} Serial: {{.Serial}}{{end}}{{if .Model}} Model: {{.Model}}{{end}}{{if .ContentHash}}<div class="hash">SHA-256 before: {{.ContentHash}}</div>{{end}}{{if .ImageHash}}<div class="hash">SHA-256 after: {{.ImageHash}}</div>{{end}}{{if .Error}} {{.Error}}{{end}}</td>
+</tr>
+{{end}}</table>
+{{with .Signature}}<p>Signed with {{.Algorithm}} key {{fingerprint .}}. Check with <code>wipeOs cert verify</code> on the JSON certificate.</p>
+<p class="signature">{{.Value}}</p>
+{{else}}<p>This certificate is not signed.</p>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML renders the certificate as a printable HTML page. The page is
+// for reading; only the JSON certificate can be verified.
+func (c *Certificate) WriteHTML(w io.Writer) error {
+	return page.Execute(w, c)
+}
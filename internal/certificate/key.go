@@ -0,0 +1,164 @@
+package certificate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joao-rrondon/wipeOs/internal/fsutil"
+)
+
+const (
+	// KeyFile holds the PEM encoded signing key in the key directory
+	KeyFile = "certificate.key"
+	// PublicKeyFile holds the matching PEM encoded public key, to be
+	// handed to auditors
+	PublicKeyFile = "certificate.pub"
+)
+
+// LoadKey returns the signing key kept in dir, generating it on first use.
+// A missing public key is written again from it.
+func LoadKey(dir string) (ed25519.PrivateKey, error) {
+	path := filepath.Join(dir, KeyFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return generateKey(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("%s: no PEM private key", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	if err := ensurePublicKey(dir, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ReadPublicKey reads a PEM encoded Ed25519 public key, as written next to
+// the signing key
+func ReadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("%s: no PEM public key", path)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+	}
+	return key, nil
+}
+
+// Fingerprint returns a short hex digest identifying a public key
+func Fingerprint(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// generateKey creates a signing key in dir along with its public key. The
+// private key is written to a synced temporary file and linked into place,
+// so that it appears complete or not at all and a concurrent run never
+// replaces it.
+func generateKey(dir string) (ed25519.PrivateKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := writeTemp(dir, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Link(tmp, filepath.Join(dir, KeyFile))
+	os.Remove(tmp)
+	if errors.Is(err, os.ErrExist) {
+		return LoadKey(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := fsutil.SyncDir(dir); err != nil {
+		return nil, err
+	}
+
+	if err := ensurePublicKey(dir, private); err != nil {
+		return nil, err
+	}
+	return private, nil
+}
+
+// ensurePublicKey writes the public key of key to dir unless it is there
+func ensurePublicKey(dir string, key ed25519.PrivateKey) error {
+	path := filepath.Join(dir, PublicKeyFile)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return err
+	}
+
+	tmp, err := writeTemp(dir, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return fsutil.SyncDir(dir)
+}
+
+// writeTemp writes data to a new temporary file in dir, syncs it and
+// returns its path
+func writeTemp(dir string, data []byte, perm os.FileMode) (string, error) {
+	f, err := os.CreateTemp(dir, ".certificate-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// appName is the directory name used below the user configuration directory
+const appName = "wipeOs"
+
+// Dir returns the WipeOs configuration directory, creating it if needed.
+// The WIPEOS_CONFIG_DIR environment variable overrides the default
+// location below os.UserConfigDir.
+func Dir() (string, error) {
+	dir := os.Getenv("WIPEOS_CONFIG_DIR")
+	if dir == "" {
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, appName)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// SubDir returns a directory below the configuration directory, creating it
+// if needed
+func SubDir(elem ...string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	dir = filepath.Join(append([]string{dir}, elem...)...)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
@@ -1,6 +1,7 @@
 package forensic
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -8,6 +9,8 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
 	"github.com/rs/zerolog"
 )
 
@@ -16,6 +19,10 @@ type AntiForensic struct {
 	logger  zerolog.Logger
 	dryRun  bool
 	verbose bool
+	// fs is the filesystem traces are removed from
+	fs shredder.FS
+	// auditor, if set, records every trace removed
+	auditor shredder.Auditor
 }
 
 // ForensicCleanOptions contains configuration for forensic cleaning
@@ -33,6 +40,12 @@ type ForensicCleanOptions struct {
 	CleanThumbnails   bool
 	WipeFreespace     bool
 	Passes            int
+	// FreeSpacePath selects the filesystem whose free space is wiped. The
+	// current directory is used when it is empty.
+	FreeSpacePath string
+	// FreeSpaceReserve is the number of bytes left free while wiping, so
+	// the system never fully runs out of space
+	FreeSpaceReserve int64
 }
 
 // CleanResult represents the result of a cleaning operation
@@ -43,6 +56,25 @@ type CleanResult struct {
 	Error     error
 }
 
+// Trace is a concrete item a forensic operation removes: a file or
+// directory, or a step such as clearing an event log
+type Trace struct {
+	Operation string `json:"operation"`
+	// Path is set for files and directories
+	Path string `json:"path,omitempty"`
+	// Action and Target describe any other step
+	Action string `json:"action,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// Actions of the traces that are not files
+const (
+	ActionClearEventLog      = "clear-event-log"
+	ActionDeleteRegistryKey  = "delete-registry-key"
+	ActionDeleteShadowCopies = "delete-shadow-copies"
+	ActionWipeFreeSpace      = "wipe-free-space"
+)
+
 // New creates a new AntiForensic instance
 func New(dryRun, verbose bool) *AntiForensic {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
@@ -50,92 +82,321 @@ func New(dryRun, verbose bool) *AntiForensic {
 		logger:  logger,
 		dryRun:  dryRun,
 		verbose: verbose,
+		fs:      shredder.OSFS{},
 	}
 }
 
-// PerformForensicCleanup performs comprehensive anti-forensic cleanup
-func (af *AntiForensic) PerformForensicCleanup(options ForensicCleanOptions) []CleanResult {
+// SetAuditor makes every later operation append a record to a, including
+// the free space wipes run through the shredder
+func (af *AntiForensic) SetAuditor(a shredder.Auditor) {
+	af.auditor = a
+}
+
+// PerformForensicCleanup performs comprehensive anti-forensic cleanup.
+// When ctx is cancelled the current operation stops at the next file and
+// the remaining operations are skipped.
+func (af *AntiForensic) PerformForensicCleanup(ctx context.Context, options ForensicCleanOptions) []CleanResult {
 	var results []CleanResult
 
 	af.log("🔍 Starting comprehensive anti-forensic cleanup...")
 
-	// 1. Clean System Logs
-	if options.CleanLogs {
-		results = append(results, af.cleanSystemLogs())
+	steps := af.operations(ctx, options)
+
+	for _, step := range steps {
+		if !step.enabled {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			af.log("⏹️ Anti-forensic cleanup interrupted")
+			return results
+		}
+		results = append(results, step.run())
+	}
+
+	af.log("✅ Anti-forensic cleanup completed")
+	return results
+}
+
+// Traces resolves the selected operations into the concrete files,
+// directories and steps they would remove, without changing anything
+func (af *AntiForensic) Traces(options ForensicCleanOptions) []Trace {
+	var traces []Trace
+	for _, step := range af.operations(context.Background(), options) {
+		if step.enabled && step.traces != nil {
+			traces = append(traces, step.traces()...)
+		}
+	}
+	return traces
+}
+
+// operation is one step of a forensic cleanup
+type operation struct {
+	enabled bool
+	run     func() CleanResult
+	// traces lists what the step removes; nil for steps that change
+	// nothing
+	traces func() []Trace
+}
+
+// operations returns the steps of a cleanup in the order they run
+func (af *AntiForensic) operations(ctx context.Context, options ForensicCleanOptions) []operation {
+	return []operation{
+		// 1. Clean System Logs
+		{options.CleanLogs, func() CleanResult { return af.cleanSystemLogs(ctx) }, af.logTraces},
+		// 2. Clean Windows Registry traces
+		{options.CleanRegistry && runtime.GOOS == "windows", af.cleanRegistryTraces, af.registryTraces},
+		// 3. Clean Prefetch files
+		{options.CleanPrefetch && runtime.GOOS == "windows", func() CleanResult { return af.cleanPrefetchFiles(ctx) }, af.prefetchTraces},
+		// 4. Clean thumbnails and recent files
+		{options.CleanThumbnails, func() CleanResult { return af.cleanThumbnailsAndRecent(ctx) }, af.thumbnailTraces},
+		// 5. Clean event logs
+		{options.CleanEventLogs && runtime.GOOS == "windows", af.cleanEventLogs, af.eventLogTraces},
+		// 6. Clean MFT records
+		{options.CleanMFT && runtime.GOOS == "windows", af.cleanMFTRecords, nil},
+		// 7. Clean shadow copies
+		{options.CleanShadowCopies && runtime.GOOS == "windows", af.cleanShadowCopies, af.shadowCopyTraces},
+		// 8. Clean memory dump files
+		{options.CleanMemory, func() CleanResult { return af.cleanMemoryDumps(ctx) }, af.memoryDumpTraces},
+		// 9. Clean swap/page files
+		{options.CleanSwap, af.cleanSwapFiles, af.swapTraces},
+		// 10. Wipe free space (last operation)
+		{options.WipeFreespace, func() CleanResult { return af.wipeFreeSpace(ctx, options) }, func() []Trace {
+			return []Trace{{Operation: "Free Space Wipe", Action: ActionWipeFreeSpace, Target: freeSpacePath(options)}}
+		}},
+	}
+}
+
+// source is a directory whose entries matching pattern an operation
+// removes
+type source struct {
+	dir     string
+	pattern string
+}
+
+// logSources returns the directories holding the logs of this system
+func logSources() []source {
+	var dirs []string
+	switch runtime.GOOS {
+	case "windows":
+		dirs = []string{
+			`C:\Windows\System32\winevt\Logs`,
+			`C:\Windows\System32\LogFiles`,
+			`C:\Windows\Logs`,
+		}
+	case "linux", "darwin":
+		dirs = []string{
+			"/var/log",
+			"/tmp",
+			"/var/tmp",
+		}
+	}
+
+	sources := make([]source, len(dirs))
+	for i, dir := range dirs {
+		sources[i] = source{dir, "*.log"}
+	}
+	return sources
+}
+
+// prefetchSource is where Windows keeps its Prefetch files
+var prefetchSource = source{`C:\Windows\Prefetch`, "*.pf"}
+
+// thumbnailSources returns the directories holding thumbnails and recent
+// items
+func thumbnailSources() []source {
+	userProfile := os.Getenv("USERPROFILE")
+	if userProfile == "" {
+		userProfile = os.Getenv("HOME")
 	}
 
-	// 2. Clean Windows Registry traces
-	if options.CleanRegistry && runtime.GOOS == "windows" {
-		results = append(results, af.cleanRegistryTraces())
+	return []source{
+		{filepath.Join(userProfile, "AppData", "Local", "Microsoft", "Windows", "Explorer"), "*"},
+		{filepath.Join(userProfile, "AppData", "Roaming", "Microsoft", "Windows", "Recent"), "*"},
+		{filepath.Join(userProfile, "AppData", "Local", "Temp"), "*"},
 	}
+}
+
+// dumpSources are the locations of memory dumps
+var dumpSources = []source{
+	{`C:\Windows\MEMORY.DMP`, "*.dmp"},
+	{`C:\Windows\Minidump`, "*.dmp"},
+	{`C:\crashdumps`, "*.dmp"},
+}
+
+// swapFiles are the page, swap and hibernation files of Windows
+var swapFiles = []string{
+	`C:\pagefile.sys`,
+	`C:\swapfile.sys`,
+	`C:\hiberfil.sys`,
+}
+
+// eventLogs are the Windows event logs that are cleared
+var eventLogs = []string{
+	"Application",
+	"System",
+	"Security",
+	"Setup",
+	"Microsoft-Windows-Windows Defender/Operational",
+	"Microsoft-Windows-Windows Firewall With Advanced Security/Firewall",
+	"Microsoft-Windows-TaskScheduler/Operational",
+}
+
+// registryKeys are the Windows Registry keys holding usage traces
+var registryKeys = []string{
+	// Recent Documents
+	`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\RecentDocs`,
+	// Run History
+	`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\RunMRU`,
+	// Typed URLs
+	`HKEY_CURRENT_USER\Software\Microsoft\Internet Explorer\TypedURLs`,
+	// Windows Search
+	`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\WordWheelQuery`,
+	// File Extensions
+	`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\FileExts`,
+}
 
-	// 3. Clean Prefetch files
-	if options.CleanPrefetch && runtime.GOOS == "windows" {
-		results = append(results, af.cleanPrefetchFiles())
+func (af *AntiForensic) logTraces() []Trace {
+	var traces []Trace
+	if runtime.GOOS == "windows" {
+		traces = actionTraces("System Logs", ActionClearEventLog, eventLogs)
 	}
+	return append(traces, af.sourceTraces("System Logs", logSources())...)
+}
+
+func (af *AntiForensic) registryTraces() []Trace {
+	return actionTraces("Registry Traces", ActionDeleteRegistryKey, registryKeys)
+}
 
-	// 4. Clean thumbnails and recent files
-	if options.CleanThumbnails {
-		results = append(results, af.cleanThumbnailsAndRecent())
+func (af *AntiForensic) prefetchTraces() []Trace {
+	return af.sourceTraces("Prefetch Files", []source{prefetchSource})
+}
+
+func (af *AntiForensic) thumbnailTraces() []Trace {
+	return af.sourceTraces("Thumbnails & Recent", thumbnailSources())
+}
+
+func (af *AntiForensic) eventLogTraces() []Trace {
+	return actionTraces("Event Logs", ActionClearEventLog, eventLogs)
+}
+
+func (af *AntiForensic) shadowCopyTraces() []Trace {
+	return []Trace{{Operation: "Shadow Copies", Action: ActionDeleteShadowCopies, Target: "all"}}
+}
+
+func (af *AntiForensic) memoryDumpTraces() []Trace {
+	return af.sourceTraces("Memory Dumps", dumpSources)
+}
+
+func (af *AntiForensic) swapTraces() []Trace {
+	var traces []Trace
+	for _, file := range swapFiles {
+		if _, err := af.fs.Lstat(file); err == nil {
+			traces = append(traces, Trace{Operation: "Swap Files", Path: file})
+		}
 	}
+	return traces
+}
 
-	// 5. Clean event logs
-	if options.CleanEventLogs && runtime.GOOS == "windows" {
-		results = append(results, af.cleanEventLogs())
+// sourceTraces lists the entries of the sources as traces of operation
+func (af *AntiForensic) sourceTraces(operation string, sources []source) []Trace {
+	var traces []Trace
+	for _, src := range sources {
+		matches, err := af.matches(src.dir, src.pattern)
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			traces = append(traces, Trace{Operation: operation, Path: match})
+		}
 	}
+	return traces
+}
 
-	// 6. Clean MFT records
-	if options.CleanMFT && runtime.GOOS == "windows" {
-		results = append(results, af.cleanMFTRecords())
+// actionTraces returns one trace per target of action
+func actionTraces(operation, action string, targets []string) []Trace {
+	traces := make([]Trace, len(targets))
+	for i, target := range targets {
+		traces[i] = Trace{Operation: operation, Action: action, Target: target}
 	}
+	return traces
+}
 
-	// 7. Clean shadow copies
-	if options.CleanShadowCopies && runtime.GOOS == "windows" {
-		results = append(results, af.cleanShadowCopies())
+// preview describes what an operation would remove in a dry run
+func (af *AntiForensic) preview(operation string, traces []Trace) CleanResult {
+	var paths, actions []string
+	var size int64
+	for _, trace := range traces {
+		if trace.Path == "" {
+			actions = append(actions, trace.Target)
+			continue
+		}
+		paths = append(paths, trace.Path)
+		if info, err := af.fs.Lstat(trace.Path); err == nil {
+			size += info.Size()
+		}
 	}
 
-	// 8. Clean memory dump files
-	if options.CleanMemory {
-		results = append(results, af.cleanMemoryDumps())
+	var details []string
+	if len(paths) > 0 {
+		details = append(details, fmt.Sprintf("Would remove %d entries (%s): %s", len(paths), ui.FormatBytes(size), abbreviate(paths)))
+	}
+	if len(actions) > 0 {
+		details = append(details, fmt.Sprintf("Would clear %d items: %s", len(actions), abbreviate(actions)))
 	}
+	if len(details) == 0 {
+		details = append(details, "Nothing to clean")
+	}
+	return CleanResult{Operation: operation, Success: true, Details: strings.Join(details, "; ")}
+}
 
-	// 9. Clean swap/page files
-	if options.CleanSwap {
-		results = append(results, af.cleanSwapFiles())
+// abbreviate joins the first few items of a list
+func abbreviate(items []string) string {
+	const shown = 3
+	if len(items) <= shown {
+		return strings.Join(items, ", ")
 	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:shown], ", "), len(items)-shown)
+}
 
-	// 10. Wipe free space (last operation)
-	if options.WipeFreespace {
-		results = append(results, af.wipeFreeSpace(options.Passes))
+// freeSpacePath returns the directory whose filesystem free space wiping
+// covers
+func freeSpacePath(options ForensicCleanOptions) string {
+	if options.FreeSpacePath == "" {
+		return "."
 	}
+	return options.FreeSpacePath
+}
 
-	af.log("✅ Anti-forensic cleanup completed")
-	return results
+// RunTrace performs a trace that is not a file or directory, as listed by
+// Traces
+func (af *AntiForensic) RunTrace(ctx context.Context, trace Trace, options ForensicCleanOptions) error {
+	switch trace.Action {
+	case ActionClearEventLog:
+		return af.clearEventLog(trace.Target)
+	case ActionDeleteRegistryKey:
+		return af.deleteRegistryKey(trace.Target)
+	case ActionDeleteShadowCopies:
+		return af.deleteShadowCopies()
+	case ActionWipeFreeSpace:
+		options.FreeSpacePath = trace.Target
+		if result := af.wipeFreeSpace(ctx, options); !result.Success {
+			return result.Error
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown forensic action %q", trace.Action)
+	}
 }
 
 // cleanSystemLogs removes system and application logs
-func (af *AntiForensic) cleanSystemLogs() CleanResult {
+func (af *AntiForensic) cleanSystemLogs(ctx context.Context) CleanResult {
 	af.log("🗂️ Cleaning system logs...")
 	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "System Logs",
-			Success:   true,
-			Details:   "Would clean: Application logs, System logs, Security logs",
-		}
+		return af.preview("System Logs", af.logTraces())
 	}
 
-	var logPaths []string
-	
-	switch runtime.GOOS {
-	case "windows":
-		// Windows Event Logs
-		logPaths = []string{
-			`C:\Windows\System32\winevt\Logs`,
-			`C:\Windows\System32\LogFiles`,
-			`C:\Windows\Logs`,
-		}
-		
+	if runtime.GOOS == "windows" {
 		// Try to clear Windows Event Logs via wevtutil
 		if err := af.clearWindowsEventLogs(); err != nil {
 			return CleanResult{
@@ -144,19 +405,12 @@ func (af *AntiForensic) cleanSystemLogs() CleanResult {
 				Error:     err,
 			}
 		}
-		
-	case "linux", "darwin":
-		logPaths = []string{
-			"/var/log",
-			"/tmp",
-			"/var/tmp",
-		}
 	}
 
 	// Clean log files
 	cleaned := 0
-	for _, logPath := range logPaths {
-		if err := af.cleanDirectory(logPath, "*.log"); err == nil {
+	for _, src := range logSources() {
+		if err := af.cleanDirectory(ctx, src.dir, src.pattern); err == nil {
 			cleaned++
 		}
 	}
@@ -170,19 +424,8 @@ func (af *AntiForensic) cleanSystemLogs() CleanResult {
 
 // clearWindowsEventLogs clears Windows Event Logs using wevtutil
 func (af *AntiForensic) clearWindowsEventLogs() error {
-	eventLogs := []string{
-		"Application",
-		"System", 
-		"Security",
-		"Setup",
-		"Microsoft-Windows-Windows Defender/Operational",
-		"Microsoft-Windows-Windows Firewall With Advanced Security/Firewall",
-		"Microsoft-Windows-TaskScheduler/Operational",
-	}
-
 	for _, logName := range eventLogs {
-		cmd := exec.Command("wevtutil.exe", "cl", logName)
-		if err := cmd.Run(); err != nil {
+		if err := af.clearEventLog(logName); err != nil {
 			af.log(fmt.Sprintf("⚠️ Failed to clear event log: %s", logName))
 		} else {
 			af.log(fmt.Sprintf("✓ Cleared event log: %s", logName))
@@ -192,29 +435,19 @@ func (af *AntiForensic) clearWindowsEventLogs() error {
 	return nil
 }
 
+// clearEventLog clears a single Windows Event Log using wevtutil
+func (af *AntiForensic) clearEventLog(name string) error {
+	err := exec.Command("wevtutil.exe", "cl", name).Run()
+	af.audit(ActionClearEventLog, name, err)
+	return err
+}
+
 // cleanRegistryTraces removes Windows Registry traces
 func (af *AntiForensic) cleanRegistryTraces() CleanResult {
 	af.log("📋 Cleaning Windows Registry traces...")
 	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "Registry Traces",
-			Success:   true,
-			Details:   "Would clean: Recent docs, Run history, Search history, Typed URLs",
-		}
-	}
-
-	registryKeys := []string{
-		// Recent Documents
-		`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\RecentDocs`,
-		// Run History  
-		`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\RunMRU`,
-		// Typed URLs
-		`HKEY_CURRENT_USER\Software\Microsoft\Internet Explorer\TypedURLs`,
-		// Windows Search
-		`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\WordWheelQuery`,
-		// File Extensions
-		`HKEY_CURRENT_USER\Software\Microsoft\Windows\CurrentVersion\Explorer\FileExts`,
+		return af.preview("Registry Traces", af.registryTraces())
 	}
 
 	cleaned := 0
@@ -232,20 +465,14 @@ func (af *AntiForensic) cleanRegistryTraces() CleanResult {
 }
 
 // cleanPrefetchFiles removes Windows Prefetch files
-func (af *AntiForensic) cleanPrefetchFiles() CleanResult {
+func (af *AntiForensic) cleanPrefetchFiles(ctx context.Context) CleanResult {
 	af.log("⚡ Cleaning Prefetch files...")
 	
-	prefetchPath := `C:\Windows\Prefetch`
-	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "Prefetch Files",
-			Success:   true,
-			Details:   fmt.Sprintf("Would clean all .pf files in %s", prefetchPath),
-		}
+		return af.preview("Prefetch Files", af.prefetchTraces())
 	}
 
-	if err := af.cleanDirectory(prefetchPath, "*.pf"); err != nil {
+	if err := af.cleanDirectory(ctx, prefetchSource.dir, prefetchSource.pattern); err != nil {
 		return CleanResult{
 			Operation: "Prefetch Files",
 			Success:   false,
@@ -261,31 +488,16 @@ func (af *AntiForensic) cleanPrefetchFiles() CleanResult {
 }
 
 // cleanThumbnailsAndRecent removes thumbnails and recent files
-func (af *AntiForensic) cleanThumbnailsAndRecent() CleanResult {
+func (af *AntiForensic) cleanThumbnailsAndRecent(ctx context.Context) CleanResult {
 	af.log("🖼️ Cleaning thumbnails and recent files...")
 	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "Thumbnails & Recent",
-			Success:   true,
-			Details:   "Would clean: Thumbnails cache, Recent items, Jump lists",
-		}
-	}
-
-	userProfile := os.Getenv("USERPROFILE")
-	if userProfile == "" {
-		userProfile = os.Getenv("HOME")
-	}
-
-	cleanPaths := []string{
-		filepath.Join(userProfile, "AppData", "Local", "Microsoft", "Windows", "Explorer"),
-		filepath.Join(userProfile, "AppData", "Roaming", "Microsoft", "Windows", "Recent"),
-		filepath.Join(userProfile, "AppData", "Local", "Temp"),
+		return af.preview("Thumbnails & Recent", af.thumbnailTraces())
 	}
 
 	cleaned := 0
-	for _, path := range cleanPaths {
-		if err := af.cleanDirectory(path, "*"); err == nil {
+	for _, src := range thumbnailSources() {
+		if err := af.cleanDirectory(ctx, src.dir, src.pattern); err == nil {
 			cleaned++
 		}
 	}
@@ -302,11 +514,7 @@ func (af *AntiForensic) cleanEventLogs() CleanResult {
 	af.log("📋 Cleaning Windows Event Logs...")
 	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "Event Logs",
-			Success:   true,
-			Details:   "Would clear all Windows Event Logs",
-		}
+		return af.preview("Event Logs", af.eventLogTraces())
 	}
 
 	if err := af.clearWindowsEventLogs(); err != nil {
@@ -328,14 +536,6 @@ func (af *AntiForensic) cleanEventLogs() CleanResult {
 func (af *AntiForensic) cleanMFTRecords() CleanResult {
 	af.log("🗃️ Cleaning MFT records...")
 	
-	if af.dryRun {
-		return CleanResult{
-			Operation: "MFT Records",
-			Success:   true,
-			Details:   "Would clean Master File Table records",
-		}
-	}
-
 	// This is a complex operation that would require admin privileges
 	// For now, we'll just indicate what would be done
 	return CleanResult{
@@ -350,16 +550,10 @@ func (af *AntiForensic) cleanShadowCopies() CleanResult {
 	af.log("👥 Cleaning Shadow Copies...")
 	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "Shadow Copies",
-			Success:   true,
-			Details:   "Would delete all Volume Shadow Copies",
-		}
+		return af.preview("Shadow Copies", af.shadowCopyTraces())
 	}
 
-	// Delete all shadow copies using vssadmin
-	cmd := exec.Command("vssadmin.exe", "delete", "shadows", "/all", "/quiet")
-	if err := cmd.Run(); err != nil {
+	if err := af.deleteShadowCopies(); err != nil {
 		return CleanResult{
 			Operation: "Shadow Copies",
 			Success:   false,
@@ -374,27 +568,24 @@ func (af *AntiForensic) cleanShadowCopies() CleanResult {
 	}
 }
 
+// deleteShadowCopies deletes all shadow copies using vssadmin
+func (af *AntiForensic) deleteShadowCopies() error {
+	err := exec.Command("vssadmin.exe", "delete", "shadows", "/all", "/quiet").Run()
+	af.audit(ActionDeleteShadowCopies, "all", err)
+	return err
+}
+
 // cleanMemoryDumps removes memory dump files
-func (af *AntiForensic) cleanMemoryDumps() CleanResult {
+func (af *AntiForensic) cleanMemoryDumps(ctx context.Context) CleanResult {
 	af.log("🧠 Cleaning memory dump files...")
 	
-	dumpPaths := []string{
-		`C:\Windows\MEMORY.DMP`,
-		`C:\Windows\Minidump`,
-		`C:\crashdumps`,
-	}
-	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "Memory Dumps",
-			Success:   true,
-			Details:   "Would clean memory dump files",
-		}
+		return af.preview("Memory Dumps", af.memoryDumpTraces())
 	}
 
 	cleaned := 0
-	for _, path := range dumpPaths {
-		if err := af.cleanDirectory(path, "*.dmp"); err == nil {
+	for _, src := range dumpSources {
+		if err := af.cleanDirectory(ctx, src.dir, src.pattern); err == nil {
 			cleaned++
 		}
 	}
@@ -411,24 +602,18 @@ func (af *AntiForensic) cleanSwapFiles() CleanResult {
 	af.log("💾 Cleaning swap/page files...")
 	
 	if af.dryRun {
-		return CleanResult{
-			Operation: "Swap Files",
-			Success:   true,
-			Details:   "Would clean pagefile.sys and swapfile.sys",
-		}
-	}
-
-	swapFiles := []string{
-		`C:\pagefile.sys`,
-		`C:\swapfile.sys`,
-		`C:\hiberfil.sys`,
+		return af.preview("Swap Files", af.swapTraces())
 	}
 
 	cleaned := 0
 	for _, file := range swapFiles {
-		if err := os.Remove(file); err == nil {
+		err := os.Remove(file)
+		if err == nil {
 			cleaned++
 		}
+		if !os.IsNotExist(err) {
+			af.audit(shredder.AuditUnlink, file, err)
+		}
 	}
 
 	return CleanResult{
@@ -438,50 +623,101 @@ func (af *AntiForensic) cleanSwapFiles() CleanResult {
 	}
 }
 
-// wipeFreeSpace performs secure overwriting of free disk space
-func (af *AntiForensic) wipeFreeSpace(passes int) CleanResult {
+// wipeFreeSpace securely overwrites the free space of the filesystem
+// holding options.FreeSpacePath
+func (af *AntiForensic) wipeFreeSpace(ctx context.Context, options ForensicCleanOptions) CleanResult {
 	af.log("🗂️ Wiping free disk space...")
-	
+
+	path := freeSpacePath(options)
+	result := shredder.New().WipeFreeSpace(ctx, path, options.FreeSpaceReserve, shredder.WipeOptions{
+		Passes: options.Passes,
+		DryRun: af.dryRun,
+		Audit:  af.auditor,
+	})
+	if result.Error != nil {
+		return CleanResult{
+			Operation: "Free Space Wipe",
+			Success:   false,
+			Error:     result.Error,
+		}
+	}
+
 	if af.dryRun {
 		return CleanResult{
 			Operation: "Free Space Wipe",
 			Success:   true,
-			Details:   fmt.Sprintf("Would wipe free space with %d passes", passes),
+			Details:   fmt.Sprintf("Would wipe %s of free space on %s with %d passes", ui.FormatBytes(result.Bytes), path, result.Passes),
 		}
 	}
 
-	// This is a complex operation that would fill the disk with random data
-	// then delete the temporary files
 	return CleanResult{
-		Operation: "Free Space Wipe", 
+		Operation: "Free Space Wipe",
 		Success:   true,
-		Details:   "Free space wiping completed (simplified implementation)",
+		Details: fmt.Sprintf("Covered %s of free space on %s with %d passes, %d small files",
+			ui.FormatBytes(result.Bytes), path, result.Passes, result.SmallFiles),
 	}
 }
 
 // Helper functions
 
-func (af *AntiForensic) cleanDirectory(dirPath, pattern string) error {
-	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-		return nil // Directory doesn't exist, nothing to clean
-	}
-
-	matches, err := filepath.Glob(filepath.Join(dirPath, pattern))
+// cleanDirectory removes the entries of dirPath matching pattern
+func (af *AntiForensic) cleanDirectory(ctx context.Context, dirPath, pattern string) error {
+	matches, err := af.matches(dirPath, pattern)
 	if err != nil {
 		return err
 	}
 
+	var failed int
+	var firstErr error
 	for _, match := range matches {
-		if err := os.RemoveAll(match); err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := af.fs.RemoveAll(match)
+		af.audit(shredder.AuditUnlink, match, err)
+		if err != nil {
 			af.log(fmt.Sprintf("⚠️ Failed to remove: %s", match))
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
 		} else {
 			af.log(fmt.Sprintf("✓ Removed: %s", match))
 		}
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d entries from %s: %w", failed, dirPath, firstErr)
+	}
 	return nil
 }
 
+// matches returns the entries of dirPath matching pattern. A missing
+// directory holds nothing.
+func (af *AntiForensic) matches(dirPath, pattern string) ([]string, error) {
+	if _, err := af.fs.Stat(dirPath); os.IsNotExist(err) {
+		return nil, nil // Directory doesn't exist, nothing to clean
+	}
+
+	entries, err := af.fs.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		matched, err := filepath.Match(pattern, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, filepath.Join(dirPath, entry.Name()))
+		}
+	}
+	return matches, nil
+}
+
 func (af *AntiForensic) deleteRegistryKey(keyPath string) error {
 	// On Windows, use reg.exe to delete registry keys
 	if runtime.GOOS != "windows" {
@@ -497,7 +733,9 @@ func (af *AntiForensic) deleteRegistryKey(keyPath string) error {
 	subkey := parts[1]
 
 	cmd := exec.Command("reg.exe", "delete", hive+`\`+subkey, "/f")
-	if err := cmd.Run(); err != nil {
+	err := cmd.Run()
+	af.audit(ActionDeleteRegistryKey, keyPath, err)
+	if err != nil {
 		return err
 	}
 
@@ -505,6 +743,22 @@ func (af *AntiForensic) deleteRegistryKey(keyPath string) error {
 	return nil
 }
 
+// audit appends an operation to the audit log, if one is set. Dry runs
+// remove nothing and are not recorded.
+func (af *AntiForensic) audit(operation, target string, err error) {
+	if af.auditor == nil || af.dryRun {
+		return
+	}
+	event := shredder.AuditEvent{Operation: operation, Target: target, Outcome: shredder.OutcomeDone}
+	if err != nil {
+		event.Outcome = shredder.OutcomeFailed
+		event.Detail = err.Error()
+	}
+	if aerr := af.auditor.Audit(event); aerr != nil {
+		af.logger.Error().Err(aerr).Str("target", target).Msg("failed to append to audit log")
+	}
+}
+
 func (af *AntiForensic) log(message string) {
 	if af.verbose {
 		fmt.Println(message)
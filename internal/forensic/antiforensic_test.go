@@ -0,0 +1,64 @@
+package forensic
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanDirectory(t *testing.T) {
+	mem := shredder.NewMemFS()
+	dir := filepath.Join(string(filepath.Separator), "logs")
+	for _, name := range []string{"app.log", "locked.log", "notes.txt"} {
+		require.NoError(t, mem.WriteFile(filepath.Join(dir, name), []byte(name)))
+	}
+	mem.Inject(shredder.Fault{Op: shredder.FaultRemove, Path: filepath.Join(dir, "locked.log"), Err: syscall.EACCES})
+
+	af := New(false, false)
+	af.fs = mem
+
+	err := af.cleanDirectory(context.Background(), dir, "*.log")
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.False(t, mem.Exists(filepath.Join(dir, "app.log")))
+	assert.True(t, mem.Exists(filepath.Join(dir, "locked.log")))
+	assert.True(t, mem.Exists(filepath.Join(dir, "notes.txt")))
+
+	// A missing directory has nothing to clean
+	assert.NoError(t, af.cleanDirectory(context.Background(), filepath.Join(dir, "missing"), "*"))
+}
+
+func TestThumbnailTracesAndPreview(t *testing.T) {
+	mem := shredder.NewMemFS()
+	home := filepath.Join(string(filepath.Separator), "home", "user")
+	t.Setenv("USERPROFILE", home)
+	recent := filepath.Join(home, "AppData", "Roaming", "Microsoft", "Windows", "Recent")
+	require.NoError(t, mem.WriteFile(filepath.Join(recent, "a.lnk"), []byte("12345")))
+	require.NoError(t, mem.WriteFile(filepath.Join(recent, "b.lnk"), []byte("123")))
+
+	af := New(true, false)
+	af.fs = mem
+
+	traces := af.Traces(ForensicCleanOptions{CleanThumbnails: true})
+	require.Len(t, traces, 2)
+	assert.Equal(t, Trace{Operation: "Thumbnails & Recent", Path: filepath.Join(recent, "a.lnk")}, traces[0])
+
+	results := af.PerformForensicCleanup(context.Background(), ForensicCleanOptions{CleanThumbnails: true})
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Details, "Would remove 2 entries (8 B)")
+	assert.True(t, mem.Exists(filepath.Join(recent, "a.lnk")))
+}
+
+func TestTracesOfActions(t *testing.T) {
+	traces := New(true, false).Traces(ForensicCleanOptions{WipeFreespace: true, FreeSpacePath: "/data"})
+
+	require.Len(t, traces, 1)
+	assert.Equal(t, ActionWipeFreeSpace, traces[0].Action)
+	assert.Equal(t, "/data", traces[0].Target)
+	assert.Empty(t, traces[0].Path)
+}
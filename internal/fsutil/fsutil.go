@@ -0,0 +1,48 @@
+// Package fsutil makes changes to the filesystem durable, for the state
+// files that must survive a crash in the middle of a job.
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// WriteSynced replaces path with data through a synced temporary file, so
+// that a crash leaves either the old or the new contents
+func WriteSynced(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return SyncDir(filepath.Dir(path))
+}
+
+// SyncDir makes the names created, renamed or removed in dir durable.
+// Windows cannot sync directories and commits them on its own.
+func SyncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,99 @@
+package interactive
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/joao-rrondon/wipeOs/ui"
+)
+
+// wipeProgressMsg carries a progress event from a background wipe
+type wipeProgressMsg struct {
+	event shredder.ProgressEvent
+}
+
+// wipeDoneMsg is sent when a background wipe has finished
+type wipeDoneMsg struct {
+	results []shredder.WipeResult
+}
+
+// wipeJob tracks the progress of a wipe running in the background
+type wipeJob struct {
+	events    chan tea.Msg
+	cancel    context.CancelFunc
+	started   time.Time
+	total     int64
+	done      int64
+	files     int
+	filesDone int
+	current   string
+	pass      int
+	passes    int
+}
+
+// startWipe runs a wipe in the background and returns the command that
+// feeds its progress events into the update loop
+func (m *Model) startWipe(files []string, options shredder.WipeOptions) tea.Cmd {
+	events := make(chan tea.Msg, 64)
+	options.Progress = shredder.ProgressFunc(func(event shredder.ProgressEvent) {
+		events <- wipeProgressMsg{event: event}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := m.shredder
+	go func() {
+		defer cancel()
+		results := s.WipeFiles(ctx, files, options)
+		events <- wipeDoneMsg{results: results}
+		close(events)
+	}()
+
+	m.job = &wipeJob{events: events, cancel: cancel, started: time.Now()}
+	return waitForWipe(events)
+}
+
+// waitForWipe waits for the next message of a background wipe
+func waitForWipe(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// apply updates the job state with a progress event
+func (j *wipeJob) apply(event shredder.ProgressEvent) {
+	switch event.Kind {
+	case shredder.EventJobStarted:
+		j.started = time.Now()
+		j.total = event.Total
+		j.files = event.Files
+	case shredder.EventPassStarted:
+		j.current = event.Path
+		j.pass = event.Pass
+		j.passes = event.Passes
+	case shredder.EventBytesWritten:
+		j.done += event.Bytes
+	case shredder.EventFileFinished, shredder.EventError:
+		j.filesDone++
+	}
+}
+
+// view renders the progress line of the job
+func (j *wipeJob) view() string {
+	label := fmt.Sprintf("%d/%d files", j.filesDone, j.files)
+	if j.pass > 0 {
+		label += fmt.Sprintf(" · pass %d/%d %s", j.pass, j.passes, filepath.Base(j.current))
+	}
+
+	if bar := ui.TransferBar(j.done, j.total, time.Since(j.started), label); bar != "" {
+		return bar
+	}
+	return ui.ProgressBar(j.filesDone, j.files, "files")
+}
@@ -1,13 +1,18 @@
 package interactive
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/joao-rrondon/wipeOs/internal/audit"
 	"github.com/joao-rrondon/wipeOs/internal/forensic"
+	"github.com/joao-rrondon/wipeOs/internal/plan"
+	"github.com/joao-rrondon/wipeOs/internal/policy"
 	"github.com/joao-rrondon/wipeOs/internal/shredder"
 	"github.com/joao-rrondon/wipeOs/ui"
 )
@@ -19,6 +24,10 @@ type Model struct {
 	shredder     *shredder.Shredder
 	historyIndex int
 	quitting     bool
+	// job is the wipe currently running in the background, if any
+	job *wipeJob
+	// pending is a command started by the last processed input
+	pending tea.Cmd
 }
 
 type sessionEndMsg struct{}
@@ -49,24 +58,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
+			// Stop a running wipe at a safe boundary before quitting
+			if m.job != nil {
+				m.job.cancel()
+				m.appendOutput(ui.StyleWarning("⏹️  Cancelling wipe, stopping at a safe boundary..."))
+				return m, nil
+			}
 			m.quitting = true
 			return m, tea.Quit
 
 		case tea.KeyEnter:
 			input := strings.TrimSpace(m.textInput.Value())
+			var pending tea.Cmd
 			if input != "" {
 				m.history = append(m.history, input)
 				m.historyIndex = len(m.history)
 				
 				// Process command
 				output := m.processCommand(input)
-				m.output = append(m.output, ui.StyleInfo("> "+input))
-				m.output = append(m.output, output...)
-				
-				// Keep output manageable
-				if len(m.output) > 50 {
-					m.output = m.output[len(m.output)-50:]
-				}
+				m.appendOutput(ui.StyleInfo("> " + input))
+				m.appendOutput(output...)
+				pending, m.pending = m.pending, nil
 				
 				if input == "exit" || input == "quit" {
 					m.quitting = true
@@ -74,6 +86,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			m.textInput.SetValue("")
+			m.textInput, cmd = m.textInput.Update(msg)
+			return m, tea.Batch(cmd, pending)
 
 		case tea.KeyUp:
 			if len(m.history) > 0 && m.historyIndex > 0 {
@@ -96,6 +110,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case sessionEndMsg:
 		m.quitting = true
 		return m, tea.Quit
+
+	case wipeProgressMsg:
+		if m.job == nil {
+			return m, nil
+		}
+		m.job.apply(msg.event)
+		return m, waitForWipe(m.job.events)
+
+	case wipeDoneMsg:
+		m.job = nil
+		m.appendOutput(formatWipeResults(msg.results)...)
+		return m, nil
 	}
 
 	m.textInput, cmd = m.textInput.Update(msg)
@@ -135,6 +161,12 @@ func (m Model) View() string {
 		s.WriteString("\n\n")
 	}
 
+	// Live progress of a running wipe
+	if m.job != nil {
+		s.WriteString(m.job.view())
+		s.WriteString("\n\n")
+	}
+
 	// Input prompt
 	promptStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#FF6B6B")).
@@ -154,6 +186,14 @@ func (m Model) View() string {
 	return s.String()
 }
 
+// appendOutput adds lines to the output history, keeping it manageable
+func (m *Model) appendOutput(lines ...string) {
+	m.output = append(m.output, lines...)
+	if len(m.output) > 50 {
+		m.output = m.output[len(m.output)-50:]
+	}
+}
+
 func (m *Model) processCommand(input string) []string {
 	parts := strings.Fields(input)
 	if len(parts) == 0 {
@@ -212,6 +252,7 @@ func (m *Model) showHelp() []string {
 		"",
 		ui.StyleHeader(ui.Icon().Info + " Examples:"),
 		ui.StyleMuted("  wipe test.txt --dry-run"),
+		ui.StyleMuted("  wipe test.txt --method dod"),
 		ui.StyleMuted("  clean browser"),
 		ui.StyleMuted("  forensic --quick --dry-run"),
 		ui.StyleMuted("  forensic --all --dry-run"),
@@ -234,7 +275,7 @@ func (m *Model) showStatus() []string {
 func (m *Model) handleWipe(args []string) []string {
 	if len(args) == 0 {
 		return []string{
-			ui.StyleError("Usage: wipe <file> [--dry-run] [--force] [--passes N]"),
+			ui.StyleError("Usage: wipe <file> [--dry-run] [--force] [--passes N] [--method NAME] [--verify]"),
 			ui.StyleInfo("Example: wipe test.txt --dry-run"),
 		}
 	}
@@ -244,8 +285,11 @@ func (m *Model) handleWipe(args []string) []string {
 	dryRun := false
 	force := false
 	passes := 3
+	methodSpec := ""
+	verify := shredder.VerifyNone
 
-	for i, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		switch arg {
 		case "--dry-run":
 			dryRun = true
@@ -253,9 +297,22 @@ func (m *Model) handleWipe(args []string) []string {
 			force = true
 		case "--passes":
 			if i+1 < len(args) {
-				// Simple passes parsing (could be improved)
-				passes = 3 // Default for now
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 1 || n > 35 {
+					return []string{ui.StyleError(fmt.Sprintf("Invalid --passes value: %s (expected 1-35)", args[i]))}
+				}
+				passes = n
+			}
+		case "--method":
+			if i+1 < len(args) {
+				i++
+				methodSpec = args[i]
 			}
+		case "--verify":
+			verify = shredder.VerifyLast
+		case "--verify=last", "--verify=all", "--verify=none":
+			verify, _ = shredder.ParseVerifyMode(strings.TrimPrefix(arg, "--verify="))
 		default:
 			if !strings.HasPrefix(arg, "--") {
 				files = append(files, arg)
@@ -267,40 +324,147 @@ func (m *Model) handleWipe(args []string) []string {
 		return []string{ui.StyleError("No files specified to wipe")}
 	}
 
+	if m.job != nil && !dryRun {
+		return []string{ui.StyleWarning("A wipe is already in progress, wait for it to finish")}
+	}
+
+	method := shredder.DefaultMethod(passes)
+	if methodSpec != "" {
+		parsed, err := shredder.ParseMethod(methodSpec)
+		if err != nil {
+			return []string{ui.StyleError(err.Error())}
+		}
+		method = parsed
+	}
+	auditLog, err := loadAuditor()
+	if err != nil {
+		return []string{ui.StyleError(err.Error())}
+	}
+
 	options := shredder.WipeOptions{
 		Recursive: false,
 		Passes:    passes,
 		Force:     force,
 		DryRun:    dryRun,
+		Method:    method,
+		Verify:    verify,
+		Guard:     loadGuard(),
+		Audit:     auditLog,
 	}
 
 	if dryRun {
-		return []string{
-			ui.StyleWarning("🧪 DRY RUN MODE - No files will be deleted"),
-			ui.StyleInfo(fmt.Sprintf("Would wipe %d file(s) with %d passes", len(files), passes)),
-			ui.StyleMuted("Files: " + strings.Join(files, ", ")),
-		}
+		return m.previewPlan([]plan.Job{{Source: "wipe", Targets: files}}, options)
 	}
 
-	results := m.shredder.WipeFiles(files, options)
-	
+	m.pending = m.startWipe(files, options)
+
+	return []string{
+		ui.StyleInfo(fmt.Sprintf("🧹 Wiping %d file(s) using %s (%d passes)...", len(files), method.Name, method.PassCount())),
+	}
+}
+
+// formatWipeResults renders the results of a finished wipe job
+func formatWipeResults(results []shredder.WipeResult) []string {
 	output := []string{}
 	successCount := 0
+	files := 0
 	
 	for _, result := range results {
-		if result.Success {
+		if !result.IsDir {
+			files++
+		}
+		if result.Refused {
+			output = append(output, ui.StyleWarning(fmt.Sprintf("⛔ Refused: %s - %v", result.Path, result.Error)))
+			continue
+		}
+		if result.IsDir {
+			if result.Success {
+				output = append(output, ui.StyleSuccess(fmt.Sprintf("✓ Removed directory: %s", result.Path)))
+			} else if result.Kept {
+				output = append(output, ui.StyleWarning(fmt.Sprintf("📁 Kept directory: %s - %v", result.Path, result.Error)))
+			} else {
+				output = append(output, ui.StyleError(fmt.Sprintf("✗ Failed: %s - %v", result.Path, result.Error)))
+			}
+			continue
+		}
+		if result.Success && result.UnlinkedOnly {
 			successCount++
-			output = append(output, ui.StyleSuccess(fmt.Sprintf("✓ Wiped: %s", result.Path)))
+			output = append(output, ui.StyleSuccess(fmt.Sprintf("✓ Unlinked: %s (%s, not overwritten)", result.Path, result.Type)))
+		} else if result.Success {
+			successCount++
+			label := "Wiped"
+			if result.Verification == shredder.VerifyPassed {
+				label = "Wiped and verified"
+			}
+			output = append(output, ui.StyleSuccess(fmt.Sprintf("✓ %s: %s (%s, %d passes)", label, result.Path, result.Method, result.Passes)))
+			if result.Assurance != shredder.AssuranceInPlace && result.Assurance != shredder.AssuranceNone {
+				output = append(output, ui.StyleWarning(fmt.Sprintf("  ⚠️ In-place overwrite %s: %s", result.Assurance, result.AssuranceNote)))
+			}
 		} else {
 			output = append(output, ui.StyleError(fmt.Sprintf("✗ Failed: %s - %v", result.Path, result.Error)))
 		}
 	}
 	
-	output = append(output, ui.StyleHeader(fmt.Sprintf("📊 Summary: %d/%d files wiped successfully", successCount, len(results))))
+	output = append(output, ui.StyleHeader(fmt.Sprintf("📊 Summary: %d/%d files wiped successfully", successCount, files)))
 	
 	return output
 }
 
+// loadGuard returns the safety policy of the configuration directory. An
+// unreadable policy file falls back to the built-in policy rather than to
+// none.
+func loadGuard() *policy.Policy {
+	p, err := policy.LoadConfig()
+	if err != nil {
+		return policy.Default()
+	}
+	return p
+}
+
+// loadAuditor returns the audit log when auditing is enabled, and nil when
+// it is disabled. An enabled log that cannot be appended to is an error, so
+// that nothing runs unrecorded.
+func loadAuditor() (shredder.Auditor, error) {
+	l, err := audit.OpenConfigured()
+	if err != nil {
+		return nil, fmt.Errorf("audit log unusable, check it with 'audit-log verify' outside this session: %w", err)
+	}
+	if l == nil {
+		return nil, nil
+	}
+	return l, nil
+}
+
+// previewEntries is how many planned entries a dry run lists
+const previewEntries = 10
+
+// previewPlan resolves jobs into the entries a run with options would
+// remove, like 'wipeOs plan' does, and lists the first of them
+func (m *Model) previewPlan(jobs []plan.Job, options shredder.WipeOptions) []string {
+	p := plan.Build(m.shredder, options, jobs, nil)
+	output := []string{
+		ui.StyleWarning("🧪 DRY RUN MODE - No files will be deleted"),
+		ui.StyleInfo(fmt.Sprintf("Would wipe %d file(s) holding %s and %d director(ies) using %s",
+			p.Totals.Files, ui.FormatBytes(p.Totals.Bytes), p.Totals.Dirs, p.Method)),
+	}
+
+	for i, entry := range p.Entries {
+		if i == previewEntries {
+			output = append(output, ui.StyleMuted(fmt.Sprintf("• ... and %d more", len(p.Entries)-i)))
+			break
+		}
+		if entry.Refused != "" {
+			output = append(output, ui.StyleWarning(fmt.Sprintf("⛔ %s: %s", entry.Path, entry.Refused)))
+			continue
+		}
+		output = append(output, ui.StyleMuted("• "+entry.Path))
+	}
+	if p.Totals.Refused > 0 {
+		output = append(output, ui.StyleWarning(fmt.Sprintf("%d target(s) would be refused", p.Totals.Refused)))
+	}
+	return output
+}
+
 func (m *Model) handleClean(args []string) []string {
 	if len(args) == 0 {
 		return []string{
@@ -318,26 +482,29 @@ func (m *Model) handleClean(args []string) []string {
 			dryRun = true
 		}
 	}
+	auditLog, err := loadAuditor()
+	if err != nil {
+		return []string{ui.StyleError(err.Error())}
+	}
 
 	options := shredder.WipeOptions{
 		Recursive: true,
 		Passes:    3,
 		Force:     true,
 		DryRun:    dryRun,
+		Guard:     loadGuard(),
+		Audit:     auditLog,
 	}
+	browserJob := plan.Job{Source: "clean: browser", Targets: m.shredder.BrowserTargets(), Recursive: true}
+	tempJob := plan.Job{Source: "clean: temp", Targets: m.shredder.TempTargets(), Recursive: true}
 
 	switch target {
 	case "browser":
 		if dryRun {
-			return []string{
-				ui.StyleWarning("🧪 DRY RUN: Would clean browser data"),
-				ui.StyleMuted("• Cache files"),
-				ui.StyleMuted("• History databases"),
-				ui.StyleMuted("• Temporary files"),
-			}
+			return m.previewPlan([]plan.Job{browserJob}, options)
 		}
 		
-		err := m.shredder.WipeBrowserData(options)
+		_, err := m.shredder.WipeBrowserData(context.Background(), options)
 		if err != nil {
 			return []string{ui.StyleError(fmt.Sprintf("Failed to clean browser data: %v", err))}
 		}
@@ -345,14 +512,10 @@ func (m *Model) handleClean(args []string) []string {
 
 	case "temp":
 		if dryRun {
-			return []string{
-				ui.StyleWarning("🧪 DRY RUN: Would clean temporary files"),
-				ui.StyleMuted("• System temp directories"),
-				ui.StyleMuted("• User cache folders"),
-			}
+			return m.previewPlan([]plan.Job{tempJob}, options)
 		}
 		
-		err := m.shredder.WipeSystemTemp(options)
+		_, err := m.shredder.WipeSystemTemp(context.Background(), options)
 		if err != nil {
 			return []string{ui.StyleError(fmt.Sprintf("Failed to clean temp files: %v", err))}
 		}
@@ -360,23 +523,18 @@ func (m *Model) handleClean(args []string) []string {
 
 	case "all":
 		if dryRun {
-			return []string{
-				ui.StyleWarning("🧪 DRY RUN: Would perform complete cleanup"),
-				ui.StyleMuted("• Browser data"),
-				ui.StyleMuted("• Temporary files"),
-				ui.StyleMuted("• Cache directories"),
-			}
+			return m.previewPlan([]plan.Job{browserJob, tempJob}, options)
 		}
 		
 		output := []string{ui.StyleWarning("🧹 Performing complete cleanup...")}
 		
-		if err := m.shredder.WipeBrowserData(options); err != nil {
+		if _, err := m.shredder.WipeBrowserData(context.Background(), options); err != nil {
 			output = append(output, ui.StyleError("Browser: Failed"))
 		} else {
 			output = append(output, ui.StyleSuccess("Browser: ✓"))
 		}
 		
-		if err := m.shredder.WipeSystemTemp(options); err != nil {
+		if _, err := m.shredder.WipeSystemTemp(context.Background(), options); err != nil {
 			output = append(output, ui.StyleError("Temp files: Failed"))
 		} else {
 			output = append(output, ui.StyleSuccess("Temp files: ✓"))
@@ -524,9 +682,15 @@ func (m *Model) handleForensic(args []string) []string {
 		}
 	}
 
+	auditLog, err := loadAuditor()
+	if err != nil {
+		return []string{ui.StyleError(err.Error())}
+	}
+
 	// Perform anti-forensic operations
 	antiForensic := forensic.New(dryRun, verbose)
-	results := antiForensic.PerformForensicCleanup(options)
+	antiForensic.SetAuditor(auditLog)
+	results := antiForensic.PerformForensicCleanup(context.Background(), options)
 
 	// Format results for display
 	output := []string{
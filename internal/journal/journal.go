@@ -0,0 +1,531 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/fsutil"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+)
+
+// Status is the processing state of a journal entry
+type Status string
+
+const (
+	// StatusInProgress means overwriting started but did not finish
+	StatusInProgress Status = "in-progress"
+	// StatusDone means the file was wiped and removed
+	StatusDone Status = "done"
+	// StatusFailed means wiping the file failed
+	StatusFailed Status = "failed"
+)
+
+const (
+	// fileExt is the extension of journal files
+	fileExt = ".json"
+	// logExt is the extension of the entry log kept next to a journal
+	// file
+	logExt = ".log"
+)
+
+// Entry is the journal record of a single file
+type Entry struct {
+	Path   string `json:"path"`
+	Status Status `json:"status"`
+	Size   int64  `json:"size"`
+	Pass   int    `json:"pass"`
+	Offset int64  `json:"offset"`
+	Seed   string `json:"seed,omitempty"`
+	// Scrubbed is the name the file was renamed to while being scrubbed
+	Scrubbed string `json:"scrubbed,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Job is the persisted description of a wipe job
+type Job struct {
+	ID             string            `json:"id"`
+	Created        time.Time         `json:"created"`
+	Updated        time.Time         `json:"updated"`
+	Targets        []string          `json:"targets"`
+	Recursive      bool              `json:"recursive"`
+	Method         string            `json:"method"`
+	Passes         []string          `json:"passes"`
+	Verify         string            `json:"verify"`
+	NoScrub        bool              `json:"no_scrub"`
+	FollowSymlinks bool              `json:"follow_symlinks,omitempty"`
+	HardLinks      string            `json:"hard_links,omitempty"`
+	BlockSize      int               `json:"block_size,omitempty"`
+	DirectIO       bool              `json:"direct_io,omitempty"`
+	Slack          bool              `json:"slack,omitempty"`
+	Discard        string            `json:"discard,omitempty"`
+	Auto           bool              `json:"auto,omitempty"`
+	Entries        map[string]*Entry `json:"entries"`
+}
+
+// Journal is an on-disk wipe journal. It implements shredder.Journal.
+//
+// The job is written to the journal file by Begin. Checkpoints and
+// outcomes of files are appended to an entry log next to it, one entry per
+// line, and replayed over the job when the journal is opened, so that
+// recording one stays cheap however many files the job holds.
+type Journal struct {
+	mu   sync.Mutex
+	path string
+	job  Job
+}
+
+// Create starts a new journal in dir
+func Create(dir string) (*Journal, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	j := &Journal{
+		path: filepath.Join(dir, id+fileExt),
+		job: Job{
+			ID:      id,
+			Created: now,
+			Updated: now,
+			Entries: make(map[string]*Entry),
+		},
+	}
+	return j, nil
+}
+
+// Open loads an existing journal file
+func Open(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &Journal{path: path}
+	if err := json.Unmarshal(data, &j.job); err != nil {
+		return nil, fmt.Errorf("invalid journal %s: %w", path, err)
+	}
+	if j.job.Entries == nil {
+		j.job.Entries = make(map[string]*Entry)
+	}
+	if err := j.replay(); err != nil {
+		return nil, fmt.Errorf("invalid journal log %s: %w", j.logPath(), err)
+	}
+	return j, nil
+}
+
+// replay applies the entry log to the job. A line cut short by a crash is
+// skipped, along with the entry appended right after it onto the same
+// line.
+func (j *Journal) replay() error {
+	f, err := os.Open(j.logPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		entry := &Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil || entry.Path == "" {
+			continue
+		}
+		j.job.Entries[entry.Path] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if info, err := f.Stat(); err == nil && info.ModTime().After(j.job.Updated) {
+		j.job.Updated = info.ModTime().UTC()
+	}
+	return nil
+}
+
+// Find opens a journal by ID or path. IDs are looked up in dir.
+func Find(dir, ref string) (*Journal, error) {
+	if _, err := os.Stat(ref); err == nil {
+		return Open(ref)
+	}
+	return Open(filepath.Join(dir, strings.TrimSuffix(ref, fileExt)+fileExt))
+}
+
+// List returns the journals found in dir, oldest first
+func List(dir string) ([]*Journal, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+fileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	var journals []*Journal
+	for _, match := range matches {
+		j, err := Open(match)
+		if err != nil {
+			continue
+		}
+		journals = append(journals, j)
+	}
+
+	sort.Slice(journals, func(a, b int) bool {
+		return journals[a].job.Created.Before(journals[b].job.Created)
+	})
+	return journals, nil
+}
+
+// ID returns the journal identifier
+func (j *Journal) ID() string {
+	return j.job.ID
+}
+
+// Path returns the location of the journal file
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Job returns a snapshot of the journal contents
+func (j *Journal) Job() Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job := j.job
+	job.Targets = append([]string(nil), j.job.Targets...)
+	job.Entries = make(map[string]*Entry, len(j.job.Entries))
+	for path, entry := range j.job.Entries {
+		e := *entry
+		job.Entries[path] = &e
+	}
+	return job
+}
+
+// Options rebuilds the wipe options the job was started with
+func (j *Journal) Options() (shredder.WipeOptions, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	method, err := shredder.ParseMethod(strings.Join(j.job.Passes, ","))
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+	method.Name = j.job.Method
+
+	verify, err := shredder.ParseVerifyMode(j.job.Verify)
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+
+	hardLinks, err := shredder.ParseHardLinkPolicy(j.job.HardLinks)
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+
+	discard, err := shredder.ParseDiscardMode(j.job.Discard)
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+
+	return shredder.WipeOptions{
+		Recursive:      j.job.Recursive,
+		Passes:         method.PassCount(),
+		Method:         method,
+		Verify:         verify,
+		NoScrub:        j.job.NoScrub,
+		FollowSymlinks: j.job.FollowSymlinks,
+		HardLinks:      hardLinks,
+		BlockSize:      j.job.BlockSize,
+		DirectIO:       j.job.DirectIO,
+		Slack:          j.job.Slack,
+		Discard:        discard,
+		Auto:           j.job.Auto,
+		Journal:        j,
+	}, nil
+}
+
+// Remaining returns the targets that still need to be wiped
+func (j *Journal) Remaining() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var remaining []string
+	for _, target := range j.job.Targets {
+		if entry, ok := j.job.Entries[target]; ok && entry.Status == StatusDone {
+			continue
+		}
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			continue
+		}
+		remaining = append(remaining, target)
+	}
+	return remaining
+}
+
+// Leftovers returns the files an interrupted job left under the name they
+// were given while being scrubbed. Their data was already overwritten and
+// only their removal is left.
+func (j *Journal) Leftovers() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var leftovers []string
+	for _, entry := range j.leftoverEntries() {
+		if _, err := os.Lstat(entry.Scrubbed); err == nil {
+			leftovers = append(leftovers, entry.Scrubbed)
+		}
+	}
+	sort.Strings(leftovers)
+	return leftovers
+}
+
+// RemoveLeftovers removes the files returned by Leftovers and marks their
+// entries done, along with those whose scrubbed file is already gone. It
+// returns the files removed.
+func (j *Journal) RemoveLeftovers() ([]string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var removed []string
+	var errs []error
+	for _, entry := range j.leftoverEntries() {
+		info, err := os.Lstat(entry.Scrubbed)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			errs = append(errs, err)
+			continue
+		case !info.Mode().IsRegular():
+			errs = append(errs, fmt.Errorf("%s is no longer the file left behind, not removing it", entry.Scrubbed))
+			continue
+		default:
+			if err := os.Remove(entry.Scrubbed); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			removed = append(removed, entry.Scrubbed)
+		}
+
+		entry.Status = StatusDone
+		entry.Seed = ""
+		entry.Error = ""
+		if err := j.appendEntry(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sort.Strings(removed)
+	return removed, errors.Join(errs...)
+}
+
+// leftoverEntries returns the unfinished entries renamed while being
+// scrubbed whose original name is gone. The caller must hold j.mu.
+func (j *Journal) leftoverEntries() []*Entry {
+	var entries []*Entry
+	for _, entry := range j.job.Entries {
+		if entry.Status == StatusDone || entry.Scrubbed == "" {
+			continue
+		}
+		if _, err := os.Lstat(entry.Path); !os.IsNotExist(err) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Interrupted reports whether any file was left partially processed
+func (j *Journal) Interrupted() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for _, entry := range j.job.Entries {
+		if entry.Status == StatusInProgress {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove deletes the journal file and its entry log
+func (j *Journal) Remove() error {
+	for _, path := range []string{j.logPath(), j.path} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Begin implements shredder.Journal
+func (j *Journal) Begin(targets []string, options shredder.WipeOptions) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	known := make(map[string]bool, len(j.job.Targets))
+	for _, target := range j.job.Targets {
+		known[target] = true
+	}
+	for _, target := range targets {
+		if !known[target] {
+			j.job.Targets = append(j.job.Targets, target)
+			known[target] = true
+		}
+	}
+
+	method := options.Method
+	if method.IsZero() {
+		method = shredder.DefaultMethod(options.Passes)
+	}
+	j.job.Method = method.Name
+	j.job.Passes = make([]string, len(method.Passes))
+	for i, p := range method.Passes {
+		j.job.Passes[i] = p.String()
+	}
+	j.job.Recursive = j.job.Recursive || options.Recursive
+	j.job.Verify = options.Verify.String()
+	j.job.NoScrub = options.NoScrub
+	j.job.FollowSymlinks = options.FollowSymlinks
+	j.job.HardLinks = options.HardLinks.String()
+	j.job.BlockSize = options.BlockSize
+	j.job.DirectIO = options.DirectIO
+	j.job.Slack = options.Slack
+	j.job.Discard = options.Discard.String()
+	j.job.Auto = options.Auto
+
+	return j.save()
+}
+
+// Checkpoint implements shredder.Journal
+func (j *Journal) Checkpoint(path string) (shredder.Checkpoint, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, ok := j.job.Entries[path]
+	if !ok || entry.Status != StatusInProgress {
+		return shredder.Checkpoint{}, false
+	}
+
+	cp := shredder.Checkpoint{Size: entry.Size, Pass: entry.Pass, Offset: entry.Offset, Scrubbed: entry.Scrubbed}
+	if entry.Seed != "" {
+		seed, err := hex.DecodeString(entry.Seed)
+		if err != nil {
+			return shredder.Checkpoint{}, false
+		}
+		cp.Seed = seed
+	}
+	return cp, true
+}
+
+// Record implements shredder.Journal
+func (j *Journal) Record(path string, cp shredder.Checkpoint) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := j.entry(path)
+	entry.Status = StatusInProgress
+	entry.Size = cp.Size
+	entry.Pass = cp.Pass
+	entry.Offset = cp.Offset
+	entry.Seed = hex.EncodeToString(cp.Seed)
+	entry.Scrubbed = cp.Scrubbed
+	entry.Error = ""
+	return j.appendEntry(entry)
+}
+
+// Finish implements shredder.Journal
+func (j *Journal) Finish(path string, err error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// Keep the last checkpoint so the file can be resumed
+		return nil
+	}
+
+	entry := j.entry(path)
+	if err != nil {
+		entry.Status = StatusFailed
+		entry.Error = err.Error()
+	} else {
+		entry.Status = StatusDone
+		entry.Seed = ""
+		entry.Error = ""
+	}
+	return j.appendEntry(entry)
+}
+
+// entry returns the entry for path, creating it if needed. The caller
+// must hold j.mu.
+func (j *Journal) entry(path string) *Entry {
+	entry, ok := j.job.Entries[path]
+	if !ok {
+		entry = &Entry{Path: path}
+		j.job.Entries[path] = entry
+	}
+	return entry
+}
+
+// appendEntry appends the state of entry to the entry log. The log is not
+// synced: a checkpoint lost in a crash only makes a resume start from an
+// earlier one. The caller must hold j.mu.
+func (j *Journal) appendEntry(entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.logPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// save atomically writes the journal to disk and folds the entry log into
+// it. The caller must hold j.mu.
+func (j *Journal) save() error {
+	j.job.Updated = time.Now().UTC()
+
+	data, err := json.MarshalIndent(j.job, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsutil.WriteSynced(j.path, data, 0o600); err != nil {
+		return err
+	}
+
+	// Entries replayed twice after a crash right here end in the same
+	// state, so the log can simply be dropped
+	if err := os.Remove(j.logPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// logPath returns the location of the entry log
+func (j *Journal) logPath() string {
+	return strings.TrimSuffix(j.path, fileExt) + logExt
+}
+
+// newID returns a sortable, unique journal identifier
+func newID() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix), nil
+}
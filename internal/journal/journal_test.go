@@ -0,0 +1,143 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	targets := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")}
+	for _, target := range targets {
+		require.NoError(t, os.WriteFile(target, []byte("data"), 0o644))
+	}
+
+	j, err := Create(dir)
+	require.NoError(t, err)
+
+	method, err := shredder.ParseMethod("dod")
+	require.NoError(t, err)
+	options := shredder.WipeOptions{Method: method, Verify: shredder.VerifyAll, Recursive: true}
+	require.NoError(t, j.Begin(targets, options))
+
+	seed := []byte("0123456789abcdef0123456789abcdef")
+	require.NoError(t, j.Record(targets[0], shredder.Checkpoint{Size: 4, Pass: 2, Offset: 3, Seed: seed}))
+	require.NoError(t, j.Finish(targets[0], context.Canceled))
+	require.NoError(t, j.Finish(targets[1], nil))
+	require.NoError(t, j.Finish(targets[2], errors.New("permission denied")))
+
+	reopened, err := Find(dir, j.ID())
+	require.NoError(t, err)
+
+	cp, ok := reopened.Checkpoint(targets[0])
+	require.True(t, ok)
+	assert.Equal(t, shredder.Checkpoint{Size: 4, Pass: 2, Offset: 3, Seed: seed}, cp)
+	assert.True(t, reopened.Interrupted())
+
+	// Done targets are not resumed, failed ones are retried
+	assert.Equal(t, []string{targets[0], targets[2]}, reopened.Remaining())
+
+	restored, err := reopened.Options()
+	require.NoError(t, err)
+	assert.Equal(t, "dod", restored.Method.Name)
+	assert.Equal(t, method.Passes, restored.Method.Passes)
+	assert.Equal(t, shredder.VerifyAll, restored.Verify)
+	assert.True(t, restored.Recursive)
+
+	journals, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, journals, 1)
+
+	require.NoError(t, reopened.Remove())
+	_, err = os.Stat(reopened.Path())
+	assert.True(t, os.IsNotExist(err))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, len(targets))
+}
+
+func TestJournal_EntryLog(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "a")
+
+	j, err := Create(dir)
+	require.NoError(t, err)
+	require.NoError(t, j.Begin([]string{target}, shredder.WipeOptions{Passes: 3}))
+	before, err := os.ReadFile(j.Path())
+	require.NoError(t, err)
+
+	// Checkpoints are appended to the log, leaving the job file alone
+	for pass := 1; pass <= 3; pass++ {
+		require.NoError(t, j.Record(target, shredder.Checkpoint{Size: 4, Pass: pass}))
+	}
+	after, err := os.ReadFile(j.Path())
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// A line cut short by a crash is ignored
+	f, err := os.OpenFile(j.logPath(), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"path":"` + target + `","status":"do`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := Open(j.Path())
+	require.NoError(t, err)
+	cp, ok := reopened.Checkpoint(target)
+	require.True(t, ok)
+	assert.Equal(t, 3, cp.Pass)
+
+	// Beginning again folds the log into the job file
+	require.NoError(t, reopened.Begin([]string{target}, shredder.WipeOptions{Passes: 3}))
+	_, err = os.Stat(reopened.logPath())
+	assert.True(t, os.IsNotExist(err))
+	again, err := Open(j.Path())
+	require.NoError(t, err)
+	cp, ok = again.Checkpoint(target)
+	require.True(t, ok)
+	assert.Equal(t, 3, cp.Pass)
+}
+
+func TestJournal_RemoveLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "secret.txt")
+	leftover := filepath.Join(dir, "x")
+	require.NoError(t, os.WriteFile(leftover, nil, 0o644))
+
+	j, err := Create(dir)
+	require.NoError(t, err)
+	require.NoError(t, j.Begin([]string{target}, shredder.WipeOptions{Passes: 3}))
+	// Interrupted after renaming the overwritten file
+	require.NoError(t, j.Record(target, shredder.Checkpoint{Size: 4, Pass: 3, Scrubbed: leftover}))
+
+	reopened, err := Open(j.Path())
+	require.NoError(t, err)
+	assert.Empty(t, reopened.Remaining())
+	assert.Equal(t, []string{leftover}, reopened.Leftovers())
+	assert.True(t, reopened.Interrupted())
+
+	removed, err := reopened.RemoveLeftovers()
+	require.NoError(t, err)
+	assert.Equal(t, []string{leftover}, removed)
+	_, err = os.Lstat(leftover)
+	assert.True(t, os.IsNotExist(err))
+	assert.Empty(t, reopened.Leftovers())
+	assert.False(t, reopened.Interrupted())
+
+	// Not when the original name is still there
+	require.NoError(t, os.WriteFile(target, nil, 0o644))
+	require.NoError(t, os.WriteFile(leftover, nil, 0o644))
+	require.NoError(t, reopened.Record(target, shredder.Checkpoint{Size: 4, Pass: 3, Scrubbed: leftover}))
+	assert.Empty(t, reopened.Leftovers())
+	removed, err = reopened.RemoveLeftovers()
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	assert.FileExists(t, leftover)
+}
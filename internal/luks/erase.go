@@ -0,0 +1,74 @@
+package luks
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNoHeader is returned when a device carries no LUKS header
+var ErrNoHeader = errors.New("no LUKS header found")
+
+// ErrHeaderRemains is returned when a header is still detected after the
+// erase
+var ErrHeaderRemains = errors.New("LUKS header still present after erase")
+
+// Device is the storage being erased; *os.File satisfies it
+type Device interface {
+	io.ReaderAt
+	io.WriterAt
+	Sync() error
+}
+
+// Result reports a crypto-erase
+type Result struct {
+	// Headers lists the headers found before the erase
+	Headers []Header
+	// Areas lists the ranges overwritten
+	Areas []Area
+	// Bytes is the total number of bytes overwritten
+	Bytes int64
+}
+
+// Erase destroys every LUKS header, JSON metadata area and keyslot area
+// on a device of size bytes by overwriting them with random data, then
+// checks that no header can be found any more. The encrypted payload is
+// not touched: without the keyslots it can no longer be decrypted.
+func Erase(dev Device, size int64) (Result, error) {
+	result := Result{Headers: Detect(dev, size)}
+	if len(result.Headers) == 0 {
+		return result, ErrNoHeader
+	}
+
+	result.Areas = Areas(result.Headers, size)
+	buf := make([]byte, 1<<20)
+	for _, area := range result.Areas {
+		for offset := area.Offset; offset < area.Offset+area.Length; {
+			chunk := buf[:min(int64(len(buf)), area.Offset+area.Length-offset)]
+			if _, err := rand.Read(chunk); err != nil {
+				return result, err
+			}
+			n, err := dev.WriteAt(chunk, offset)
+			result.Bytes += int64(n)
+			if err != nil {
+				return result, fmt.Errorf("failed to overwrite 0x%x: %w", offset, err)
+			}
+			offset += int64(n)
+		}
+	}
+
+	if err := dev.Sync(); err != nil {
+		return result, err
+	}
+	return result, Verify(dev, size)
+}
+
+// Verify fails with ErrHeaderRemains if a LUKS header is found on the
+// device
+func Verify(r io.ReaderAt, size int64) error {
+	if remaining := Detect(r, size); len(remaining) > 0 {
+		return fmt.Errorf("%w: %s", ErrHeaderRemains, remaining[0])
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+// Package luks detects LUKS1 and LUKS2 headers and destroys them together
+// with their keyslots. Without the keyslots the volume key cannot be
+// recovered, so the encrypted data becomes unreadable in seconds, whatever
+// the size of the device.
+package luks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// sectorSize is the unit of LUKS1 offsets
+	sectorSize = 512
+	// luks1HeaderSize is the size of the LUKS1 binary header
+	luks1HeaderSize = 592
+	// luks1Keyslots is the fixed number of LUKS1 keyslots
+	luks1Keyslots = 8
+	// luks1SlotActive marks an enabled LUKS1 keyslot
+	luks1SlotActive = 0x00AC71F3
+	// luks2BinarySize is the size of the LUKS2 binary header, which is
+	// followed by the JSON metadata area
+	luks2BinarySize = 4096
+	// maxHeaderSize bounds the LUKS2 header size read from a header
+	maxHeaderSize = 4 << 20
+	// maxMetadataEnd bounds the areas taken from a header, so that a
+	// corrupted one cannot make the erase run over the data. LUKS2 keyslot
+	// areas never extend past 128 MiB.
+	maxMetadataEnd = 128 << 20
+)
+
+var (
+	primaryMagic   = []byte("LUKS\xba\xbe")
+	secondaryMagic = []byte("SKUL\xba\xbe")
+)
+
+// secondaryOffsets lists where a LUKS2 secondary header may start: right
+// after a primary header of one of the allowed sizes
+var secondaryOffsets = []int64{0x4000, 0x8000, 0x10000, 0x20000, 0x40000, 0x80000, 0x100000, 0x200000, 0x400000}
+
+// Keyslot is a key material area of a header
+type Keyslot struct {
+	ID     string
+	Active bool
+	Offset int64
+	Size   int64
+}
+
+// Header is a LUKS header found on a device
+type Header struct {
+	Version int
+	// Offset is where the header starts; Secondary is set for the LUKS2
+	// backup copy
+	Offset    int64
+	Secondary bool
+	UUID      string
+	Cipher    string
+	// Size is the size of the header, including the LUKS2 JSON area
+	Size int64
+	// DataOffset is where the encrypted payload starts, 0 if unknown
+	DataOffset int64
+	Keyslots   []Keyslot
+	// KeyslotsEnd is the end of the keyslots area declared by a LUKS2
+	// header, 0 for LUKS1
+	KeyslotsEnd int64
+}
+
+// Area is a byte range to destroy
+type Area struct {
+	Offset int64
+	Length int64
+}
+
+// Detect returns every LUKS header on a device of size bytes: the primary
+// header at offset 0 and any LUKS2 secondary header
+func Detect(r io.ReaderAt, size int64) []Header {
+	var headers []Header
+	if h, ok := parseHeader(r, size, 0); ok {
+		headers = append(headers, h)
+	}
+	for _, offset := range secondaryOffsets {
+		if h, ok := parseHeader(r, size, offset); ok && h.Secondary {
+			headers = append(headers, h)
+		}
+	}
+	return headers
+}
+
+// parseHeader reads the header at offset. ok is false when no header with
+// a known magic and version is found there.
+func parseHeader(r io.ReaderAt, size, offset int64) (Header, bool) {
+	if offset+luks1HeaderSize > size {
+		return Header{}, false
+	}
+	buf := make([]byte, luks1HeaderSize)
+	if _, err := r.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+		return Header{}, false
+	}
+
+	secondary := bytes.Equal(buf[:6], secondaryMagic)
+	if !bytes.Equal(buf[:6], primaryMagic) && !secondary {
+		return Header{}, false
+	}
+
+	switch binary.BigEndian.Uint16(buf[6:]) {
+	case 1:
+		if secondary {
+			return Header{}, false
+		}
+		return parseLUKS1(buf, offset), true
+	case 2:
+		return parseLUKS2(r, size, buf, offset, secondary), true
+	default:
+		return Header{}, false
+	}
+}
+
+// parseLUKS1 decodes the fixed LUKS1 header
+func parseLUKS1(buf []byte, offset int64) Header {
+	h := Header{
+		Version:    1,
+		Offset:     offset,
+		UUID:       cString(buf[168:208]),
+		Cipher:     cString(buf[8:40]) + "-" + cString(buf[40:72]),
+		Size:       luks1HeaderSize,
+		DataOffset: int64(binary.BigEndian.Uint32(buf[104:])) * sectorSize,
+	}
+
+	keyBytes := int64(binary.BigEndian.Uint32(buf[108:]))
+	for i := 0; i < luks1Keyslots; i++ {
+		slot := buf[208+48*i:]
+		stripes := int64(binary.BigEndian.Uint32(slot[44:]))
+		material := keyBytes * stripes
+		h.Keyslots = append(h.Keyslots, Keyslot{
+			ID:     strconv.Itoa(i),
+			Active: binary.BigEndian.Uint32(slot) == luks1SlotActive,
+			Offset: int64(binary.BigEndian.Uint32(slot[40:])) * sectorSize,
+			Size:   (material + sectorSize - 1) / sectorSize * sectorSize,
+		})
+	}
+	return h
+}
+
+// luks2Metadata is the part of the LUKS2 JSON area needed to find the
+// keyslots. Numbers are encoded as strings.
+type luks2Metadata struct {
+	Keyslots map[string]struct {
+		Area struct {
+			Offset string `json:"offset"`
+			Size   string `json:"size"`
+		} `json:"area"`
+	} `json:"keyslots"`
+	Segments map[string]struct {
+		Offset     string `json:"offset"`
+		Encryption string `json:"encryption"`
+	} `json:"segments"`
+	Config struct {
+		KeyslotsSize string `json:"keyslots_size"`
+	} `json:"config"`
+}
+
+// parseLUKS2 decodes the LUKS2 binary header and its JSON metadata. A
+// header with unreadable metadata is still reported, with its keyslots
+// unknown.
+func parseLUKS2(r io.ReaderAt, size int64, buf []byte, offset int64, secondary bool) Header {
+	h := Header{
+		Version:   2,
+		Offset:    offset,
+		Secondary: secondary,
+		UUID:      cString(buf[168:208]),
+		Size:      int64(binary.BigEndian.Uint64(buf[8:])),
+	}
+	if h.Size < luks2BinarySize || h.Size > maxHeaderSize || offset+h.Size > size {
+		h.Size = luks2BinarySize
+		return h
+	}
+
+	area := make([]byte, h.Size-luks2BinarySize)
+	if _, err := r.ReadAt(area, offset+luks2BinarySize); err != nil && !errors.Is(err, io.EOF) {
+		return h
+	}
+	if end := bytes.IndexByte(area, 0); end >= 0 {
+		area = area[:end]
+	}
+
+	var meta luks2Metadata
+	if err := json.Unmarshal(area, &meta); err != nil {
+		return h
+	}
+
+	ids := make([]string, 0, len(meta.Keyslots))
+	for id := range meta.Keyslots {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		slot := meta.Keyslots[id]
+		h.Keyslots = append(h.Keyslots, Keyslot{ID: id, Active: true, Offset: parseSize(slot.Area.Offset), Size: parseSize(slot.Area.Size)})
+	}
+
+	for _, segment := range meta.Segments {
+		if h.DataOffset == 0 || parseSize(segment.Offset) < h.DataOffset {
+			h.DataOffset = parseSize(segment.Offset)
+			h.Cipher = segment.Encryption
+		}
+	}
+
+	// The keyslots area follows both copies of the header
+	if keyslots := parseSize(meta.Config.KeyslotsSize); keyslots > 0 {
+		h.KeyslotsEnd = 2*h.Size + keyslots
+	}
+	return h
+}
+
+// Areas returns the merged byte ranges holding the headers, their JSON
+// metadata and every keyslot of a device of size bytes: the whole area
+// before the payload when its offset is known. Ranges are clipped to the
+// device and never reach into the payload.
+func Areas(headers []Header, size int64) []Area {
+	var areas []Area
+	limit := size
+	for _, h := range headers {
+		if h.DataOffset > 0 && h.DataOffset < limit {
+			limit = h.DataOffset
+		}
+	}
+
+	add := func(offset, length int64) {
+		if length <= 0 || offset >= limit {
+			return
+		}
+		areas = append(areas, Area{Offset: offset, Length: min(length, limit-offset)})
+	}
+
+	// Everything before the payload is header, metadata, keyslots or the
+	// padding between them, which may hold key material of old keyslots
+	if limit < size && limit <= maxMetadataEnd {
+		add(0, limit)
+	}
+
+	for _, h := range headers {
+		add(h.Offset, h.Size)
+		if h.Version == 2 && !h.Secondary {
+			// The secondary copy lives right after the primary header
+			add(h.Offset+h.Size, h.Size)
+		}
+		if h.KeyslotsEnd > 0 {
+			add(2*h.Size, min(h.KeyslotsEnd, maxMetadataEnd)-2*h.Size)
+		}
+		for _, slot := range h.Keyslots {
+			// Disabled LUKS1 keyslots may still hold old key material
+			if slot.Offset > 0 && slot.Offset+slot.Size <= maxMetadataEnd {
+				add(slot.Offset, slot.Size)
+			}
+		}
+	}
+
+	return mergeAreas(areas)
+}
+
+// mergeAreas sorts areas and joins overlapping or adjacent ones
+func mergeAreas(areas []Area) []Area {
+	sort.Slice(areas, func(i, j int) bool { return areas[i].Offset < areas[j].Offset })
+
+	var merged []Area
+	for _, a := range areas {
+		if n := len(merged); n > 0 && a.Offset <= merged[n-1].Offset+merged[n-1].Length {
+			end := max(merged[n-1].Offset+merged[n-1].Length, a.Offset+a.Length)
+			merged[n-1].Length = end - merged[n-1].Offset
+			continue
+		}
+		merged = append(merged, a)
+	}
+	return merged
+}
+
+// String describes the header for listings
+func (h Header) String() string {
+	kind := "primary"
+	if h.Secondary {
+		kind = "secondary"
+	}
+
+	active := 0
+	for _, slot := range h.Keyslots {
+		if slot.Active {
+			active++
+		}
+	}
+
+	desc := fmt.Sprintf("LUKS%d %s header at 0x%x, %d active keyslot(s)", h.Version, kind, h.Offset, active)
+	if h.UUID != "" {
+		desc += ", UUID " + h.UUID
+	}
+	if h.Cipher != "" {
+		desc += ", " + h.Cipher
+	}
+	return desc
+}
+
+// cString returns a NUL-padded header field as a string
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// parseSize parses a LUKS2 JSON number, returning 0 when it is invalid
+func parseSize(value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,195 @@
+package luks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// image is an in-memory device
+type image []byte
+
+func (img image) ReadAt(p []byte, off int64) (int, error) {
+	return copy(p, img[off:]), nil
+}
+
+func (img image) WriteAt(p []byte, off int64) (int, error) {
+	return copy(img[off:], p), nil
+}
+
+func (img image) Sync() error {
+	return nil
+}
+
+// payloadByte fills the encrypted payload of the fixtures
+const payloadByte = 0x5A
+
+// luks1Fixture builds a LUKS1 device like cryptsetup lays it out: a
+// 256-bit key, 4000 stripes, keyslots every 0x1000 sectors from sector 8
+// and the payload at 2 MiB. Slots 0 and 3 are active.
+func luks1Fixture() image {
+	img := make(image, 4<<20)
+	copy(img, primaryMagic)
+	binary.BigEndian.PutUint16(img[6:], 1)
+	copy(img[8:], "aes")
+	copy(img[40:], "xts-plain64")
+	copy(img[72:], "sha256")
+	binary.BigEndian.PutUint32(img[104:], 4096)
+	binary.BigEndian.PutUint32(img[108:], 32)
+	copy(img[168:], "4f1e5c2a-1111-2222-3333-444455556666")
+
+	for i := 0; i < luks1Keyslots; i++ {
+		slot := img[208+48*i:]
+		state := uint32(0x0000DEAD)
+		if i == 0 || i == 3 {
+			state = luks1SlotActive
+		}
+		binary.BigEndian.PutUint32(slot, state)
+		binary.BigEndian.PutUint32(slot[40:], uint32(8+i*0x1000/8))
+		binary.BigEndian.PutUint32(slot[44:], 4000)
+	}
+	for i := 4096; i < 2<<20; i++ {
+		img[i] = 0xC3
+	}
+	fill(img[2<<20:], payloadByte)
+	return img
+}
+
+// luks2Fixture builds a LUKS2 device with 16 KiB headers, two keyslots in
+// the keyslots area and the payload at 16 MiB
+func luks2Fixture() image {
+	const hdrSize = 16384
+	img := make(image, 20<<20)
+	metadata := `{"keyslots":{"0":{"type":"luks2","area":{"type":"raw","offset":"32768","size":"258048"}},` +
+		`"1":{"type":"luks2","area":{"type":"raw","offset":"290816","size":"258048"}}},` +
+		`"segments":{"0":{"type":"crypt","offset":"16777216","size":"dynamic","encryption":"aes-xts-plain64"}},` +
+		`"config":{"json_size":"12288","keyslots_size":"16744448"}}`
+
+	for i, magic := range [][]byte{primaryMagic, secondaryMagic} {
+		header := img[i*hdrSize:]
+		copy(header, magic)
+		binary.BigEndian.PutUint16(header[6:], 2)
+		binary.BigEndian.PutUint64(header[8:], hdrSize)
+		copy(header[168:], "8d7b0f7e-aaaa-bbbb-cccc-ddddeeeeffff")
+		binary.BigEndian.PutUint64(header[256:], uint64(i*hdrSize))
+		copy(header[luks2BinarySize:], metadata)
+	}
+	fill(img[32768:32768+2*258048], 0xC3)
+	fill(img[16<<20:], payloadByte)
+	return img
+}
+
+func fill(b []byte, v byte) {
+	for i := range b {
+		b[i] = v
+	}
+}
+
+func TestDetect_LUKS1(t *testing.T) {
+	img := luks1Fixture()
+	headers := Detect(img, int64(len(img)))
+	require.Len(t, headers, 1)
+
+	h := headers[0]
+	assert.Equal(t, 1, h.Version)
+	assert.Equal(t, "aes-xts-plain64", h.Cipher)
+	assert.Equal(t, "4f1e5c2a-1111-2222-3333-444455556666", h.UUID)
+	assert.Equal(t, int64(2<<20), h.DataOffset)
+	require.Len(t, h.Keyslots, 8)
+	assert.True(t, h.Keyslots[0].Active)
+	assert.False(t, h.Keyslots[1].Active)
+	assert.Equal(t, int64(4096), h.Keyslots[0].Offset)
+	assert.Equal(t, int64(128000), h.Keyslots[0].Size)
+}
+
+func TestDetect_LUKS2(t *testing.T) {
+	img := luks2Fixture()
+	headers := Detect(img, int64(len(img)))
+	require.Len(t, headers, 2)
+
+	assert.False(t, headers[0].Secondary)
+	assert.True(t, headers[1].Secondary)
+	assert.Equal(t, int64(16384), headers[1].Offset)
+	for _, h := range headers {
+		assert.Equal(t, 2, h.Version)
+		assert.Equal(t, int64(16384), h.Size)
+		assert.Equal(t, "aes-xts-plain64", h.Cipher)
+		assert.Equal(t, int64(16<<20), h.DataOffset)
+		assert.Len(t, h.Keyslots, 2)
+	}
+}
+
+func TestDetect_None(t *testing.T) {
+	assert.Empty(t, Detect(make(image, 1<<20), 1<<20))
+
+	// A LUKS magic with an unknown version is not a header
+	img := make(image, 1<<20)
+	copy(img, primaryMagic)
+	binary.BigEndian.PutUint16(img[6:], 7)
+	assert.Empty(t, Detect(img, int64(len(img))))
+}
+
+func TestErase(t *testing.T) {
+	tests := []struct {
+		name    string
+		img     image
+		payload int64
+	}{
+		{"luks1", luks1Fixture(), 2 << 20},
+		{"luks2", luks2Fixture(), 16 << 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := int64(len(tt.img))
+			result, err := Erase(tt.img, size)
+			require.NoError(t, err)
+			assert.NotEmpty(t, result.Headers)
+			assert.Empty(t, Detect(tt.img, size))
+			assert.NoError(t, Verify(tt.img, size))
+
+			// Every keyslot byte and the whole metadata area were replaced
+			assert.Equal(t, []Area{{Offset: 0, Length: tt.payload}}, result.Areas)
+			assert.Equal(t, tt.payload, result.Bytes)
+			assert.False(t, bytes.Contains(tt.img[:tt.payload], bytes.Repeat([]byte{0xC3}, 64)), "key material left")
+
+			// The payload is left alone
+			assert.Equal(t, bytes.Repeat([]byte{payloadByte}, int(size-tt.payload)), []byte(tt.img[tt.payload:]))
+		})
+	}
+}
+
+func TestErase_SecondaryOnly(t *testing.T) {
+	img := luks2Fixture()
+	fill(img[:16384], 0)
+	size := int64(len(img))
+
+	headers := Detect(img, size)
+	require.Len(t, headers, 1)
+	assert.True(t, headers[0].Secondary)
+
+	_, err := Erase(img, size)
+	require.NoError(t, err)
+	assert.Empty(t, Detect(img, size))
+}
+
+func TestErase_NoHeader(t *testing.T) {
+	_, err := Erase(make(image, 1<<20), 1<<20)
+	assert.ErrorIs(t, err, ErrNoHeader)
+}
+
+func TestAreas_Clipped(t *testing.T) {
+	// Keyslots reaching into the payload are cut at its start
+	headers := []Header{{Version: 1, Size: luks1HeaderSize, DataOffset: 8192, Keyslots: []Keyslot{
+		{Offset: 4096, Size: 128000},
+		{Offset: 1 << 40, Size: 128000},
+	}}}
+	assert.Equal(t, []Area{{Offset: 0, Length: 8192}}, Areas(headers, 1<<20))
+
+	// Without a payload offset only the known areas are covered
+	headers[0].DataOffset = 0
+	assert.Equal(t, []Area{{Offset: 0, Length: luks1HeaderSize}, {Offset: 4096, Length: 128000}}, Areas(headers, 1<<20))
+}
@@ -0,0 +1,15 @@
+package plan
+
+import (
+	"os"
+	"syscall"
+)
+
+// identity returns the device and inode numbers of a file
+func identity(info os.FileInfo) (dev, ino uint64) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return uint64(st.Dev), st.Ino
+}
@@ -0,0 +1,435 @@
+// Package plan freezes what a wipe would do into a manifest of concrete
+// files and directories, recording the identity of each, and applies such
+// a manifest later. Entries that changed since planning are refused, and
+// nothing outside the manifest is touched.
+package plan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/forensic"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+)
+
+// Version is the manifest format written by this package
+const Version = 1
+
+// Refusal and loading errors
+var (
+	// ErrChanged is returned for entries whose identity changed
+	ErrChanged = errors.New("changed since planning")
+	// ErrMissing is returned for entries that no longer exist
+	ErrMissing = errors.New("gone since planning")
+	// ErrNotPlanned is returned for entries that appeared since planning
+	ErrNotPlanned = errors.New("not part of the plan")
+	// ErrVersion is returned for manifests of an unknown format
+	ErrVersion = errors.New("unsupported plan version")
+)
+
+// Entry types
+const (
+	TypeFile      = "file"
+	TypeDirectory = "directory"
+	TypeSymlink   = "symlink"
+	TypeOther     = "other"
+)
+
+// Job is a set of targets wiped together: paths given on the command line,
+// a clean target or the files of a forensic operation
+type Job struct {
+	Source    string   `json:"source"`
+	Targets   []string `json:"targets"`
+	Recursive bool     `json:"recursive"`
+}
+
+// Entry is a file or directory of a plan with the identity it had when the
+// plan was made
+type Entry struct {
+	Source  string    `json:"source"`
+	Path    string    `json:"path"`
+	Type    string    `json:"type"`
+	Size    int64     `json:"size"`
+	Dev     uint64    `json:"dev,omitempty"`
+	Inode   uint64    `json:"inode,omitempty"`
+	ModTime time.Time `json:"mtime"`
+	// Refused explains why the entry is left alone
+	Refused string `json:"refused,omitempty"`
+}
+
+// Totals summarise a manifest
+type Totals struct {
+	Files   int   `json:"files"`
+	Dirs    int   `json:"directories"`
+	Bytes   int64 `json:"bytes"`
+	Refused int   `json:"refused"`
+	Actions int   `json:"actions"`
+}
+
+// Manifest is a frozen plan
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Method  string    `json:"method"`
+	Passes  []string  `json:"passes"`
+	Verify  string    `json:"verify"`
+	Auto    bool      `json:"auto,omitempty"`
+	// FreeSpaceReserve is the space left free by free space wiping actions
+	FreeSpaceReserve int64            `json:"free_space_reserve,omitempty"`
+	Jobs             []Job            `json:"jobs"`
+	Entries          []Entry          `json:"entries"`
+	Actions          []forensic.Trace `json:"actions,omitempty"`
+	Totals           Totals           `json:"totals"`
+}
+
+// Build resolves jobs and forensic actions into a manifest, the way a wipe
+// with options would expand them, guard and limits included. Targets
+// already covered by an earlier job are left out.
+func Build(s *shredder.Shredder, options shredder.WipeOptions, jobs []Job, actions []forensic.Trace) *Manifest {
+	method := options.Method
+	if method.IsZero() {
+		method = shredder.DefaultMethod(options.Passes)
+	}
+	m := &Manifest{
+		Version: Version,
+		Created: time.Now().UTC(),
+		Method:  method.Name,
+		Verify:  options.Verify.String(),
+		Auto:    options.Auto,
+		Actions: actions,
+	}
+	for _, p := range method.Passes {
+		m.Passes = append(m.Passes, p.String())
+	}
+
+	planned := make(map[string]bool)
+	for _, job := range jobs {
+		var targets []string
+		for _, target := range job.Targets {
+			if abs, err := filepath.Abs(target); err == nil {
+				target = abs
+			}
+			if !planned[target] {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		job.Targets = targets
+		m.Jobs = append(m.Jobs, job)
+
+		jobOptions := options
+		jobOptions.Recursive = job.Recursive
+		for _, target := range s.Resolve(targets, jobOptions) {
+			if planned[target.Path] {
+				continue
+			}
+			planned[target.Path] = true
+			m.Entries = append(m.Entries, newEntry(job.Source, target))
+		}
+	}
+
+	m.Totals = m.totals()
+	return m
+}
+
+// newEntry records a resolved target with its current identity
+func newEntry(source string, target shredder.Target) Entry {
+	entry := Entry{Source: source, Path: target.Path}
+	if target.Err != nil {
+		entry.Refused = target.Err.Error()
+	}
+
+	info, err := os.Lstat(target.Path)
+	if err != nil {
+		if entry.Refused == "" {
+			entry.Refused = err.Error()
+		}
+		return entry
+	}
+	entry.Type = entryType(info)
+	entry.Size = info.Size()
+	entry.ModTime = info.ModTime()
+	entry.Dev, entry.Inode = identity(info)
+	return entry
+}
+
+// totals counts the entries and actions of the manifest
+func (m *Manifest) totals() Totals {
+	totals := Totals{Actions: len(m.Actions)}
+	for _, entry := range m.Entries {
+		switch {
+		case entry.Refused != "":
+			totals.Refused++
+		case entry.Type == TypeDirectory:
+			totals.Dirs++
+		default:
+			totals.Files++
+			totals.Bytes += entry.Size
+		}
+	}
+	return totals
+}
+
+// Options rebuilds the wipe options frozen in the manifest
+func (m *Manifest) Options() (shredder.WipeOptions, error) {
+	method, err := shredder.ParseMethod(strings.Join(m.Passes, ","))
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+	method.Name = m.Method
+
+	verify, err := shredder.ParseVerifyMode(m.Verify)
+	if err != nil {
+		return shredder.WipeOptions{}, err
+	}
+
+	return shredder.WipeOptions{
+		Passes: method.PassCount(),
+		Method: method,
+		Verify: verify,
+		Auto:   m.Auto,
+	}, nil
+}
+
+// Save writes the manifest to path
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Load reads a manifest written by Save
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid plan %s: %w", path, err)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("%w %d in %s", ErrVersion, m.Version, path)
+	}
+	return m, nil
+}
+
+// Apply wipes the entries of the manifest with options, normally those of
+// Options. Every entry is checked against its planned identity when the
+// job is planned, and every file again once it is open, right before it is
+// overwritten. Entries that changed, disappeared or were not planned at
+// all are refused; guard, if set, still vets whatever passes. Forensic
+// actions are not run, see RunActions.
+func Apply(ctx context.Context, s *shredder.Shredder, m *Manifest, guard shredder.Guard, options shredder.WipeOptions) []shredder.WipeResult {
+	frozen := newFrozen(m, guard)
+	options.Guard = frozen
+
+	var results []shredder.WipeResult
+	for _, job := range m.Jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		jobOptions := options
+		jobOptions.Recursive = job.Recursive
+		results = append(results, s.WipeFiles(ctx, job.Targets, jobOptions)...)
+	}
+	if ctx.Err() != nil {
+		return results
+	}
+
+	for _, path := range frozen.missing() {
+		err := &os.PathError{Op: "wipe", Path: path, Err: ErrMissing}
+		results = append(results, shredder.WipeResult{Path: path, Error: err, Refused: true})
+	}
+	return results
+}
+
+// RunActions runs the forensic actions of the manifest in order
+func RunActions(ctx context.Context, af *forensic.AntiForensic, m *Manifest, options forensic.ForensicCleanOptions) []forensic.CleanResult {
+	options.FreeSpaceReserve = m.FreeSpaceReserve
+
+	var results []forensic.CleanResult
+	for _, action := range m.Actions {
+		if ctx.Err() != nil {
+			break
+		}
+		result := forensic.CleanResult{Operation: action.Operation, Success: true, Details: action.Action + " " + action.Target}
+		if err := af.RunTrace(ctx, action, options); err != nil {
+			result.Success = false
+			result.Error = fmt.Errorf("%s %s: %w", action.Action, action.Target, err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// frozen is the guard of an applied manifest. It only lets through the
+// planned entries that still have their planned identity.
+type frozen struct {
+	entries map[string]Entry
+	order   []string
+	inner   shredder.Guard
+	// seen holds the entries checked while applying, refused the
+	// directories whose content was skipped with them
+	seen    map[string]bool
+	refused []string
+}
+
+func newFrozen(m *Manifest, inner shredder.Guard) *frozen {
+	f := &frozen{entries: make(map[string]Entry, len(m.Entries)), inner: inner, seen: make(map[string]bool)}
+	for _, entry := range m.Entries {
+		f.entries[entry.Path] = entry
+		f.order = append(f.order, entry.Path)
+	}
+	return f
+}
+
+// Check implements shredder.Guard
+func (f *frozen) Check(path string) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	entry, ok := f.entries[path]
+	if !ok {
+		return ErrNotPlanned
+	}
+	f.seen[path] = true
+
+	err := entry.verify()
+	if err == nil && f.inner != nil {
+		err = f.inner.Check(path)
+	}
+	if err != nil && entry.Type == TypeDirectory {
+		f.refused = append(f.refused, path)
+	}
+	return err
+}
+
+// CheckOpened implements shredder.OpenedGuard. It is called by the workers
+// concurrently and only reads the entries.
+func (f *frozen) CheckOpened(path string, info os.FileInfo) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	entry, ok := f.entries[path]
+	if !ok {
+		return ErrNotPlanned
+	}
+	return entry.matches(info)
+}
+
+// Limit implements shredder.Guard
+func (f *frozen) Limit(files int, bytes int64) error {
+	if f.inner == nil {
+		return nil
+	}
+	return f.inner.Limit(files, bytes)
+}
+
+// missing returns the planned entries never reached while applying, other
+// than those refused when planned or skipped with a refused directory
+func (f *frozen) missing() []string {
+	var missing []string
+	for _, path := range f.order {
+		if f.seen[path] || f.entries[path].Refused != "" || f.skipped(path) {
+			continue
+		}
+		if f.entries[path].Type == TypeSymlink {
+			// Links are refused before any guard sees them
+			continue
+		}
+		missing = append(missing, path)
+	}
+	return missing
+}
+
+// skipped reports whether path lies below a refused directory
+func (f *frozen) skipped(path string) bool {
+	for _, dir := range f.refused {
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+	return false
+}
+
+// verify compares the entry with what is on disk now
+func (e Entry) verify() error {
+	if e.Refused != "" {
+		return fmt.Errorf("refused when planned: %s", e.Refused)
+	}
+
+	info, err := os.Lstat(e.Path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMissing, err)
+	}
+	return e.matches(info)
+}
+
+// matches compares the entry with info
+func (e Entry) matches(info os.FileInfo) error {
+	if kind := entryType(info); kind != e.Type {
+		return fmt.Errorf("%w: planned as a %s, now a %s", ErrChanged, e.Type, kind)
+	}
+	if dev, ino := identity(info); dev != e.Dev || ino != e.Inode {
+		return fmt.Errorf("%w: replaced by another file (device %d inode %d, planned %d/%d)", ErrChanged, dev, ino, e.Dev, e.Inode)
+	}
+	if e.Type == TypeDirectory {
+		// Directories change as their content is wiped
+		return nil
+	}
+	if info.Size() != e.Size {
+		return fmt.Errorf("%w: size is %d bytes, planned %d", ErrChanged, info.Size(), e.Size)
+	}
+	if !info.ModTime().Equal(e.ModTime) {
+		return fmt.Errorf("%w: modified at %s, planned %s", ErrChanged, info.ModTime().Format(time.RFC3339), e.ModTime.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// entryType names the kind of a file
+func entryType(info os.FileInfo) string {
+	switch mode := info.Mode(); {
+	case mode.IsDir():
+		return TypeDirectory
+	case mode&os.ModeSymlink != 0:
+		return TypeSymlink
+	case mode.IsRegular():
+		return TypeFile
+	default:
+		return TypeOther
+	}
+}
+
+// FromTraces turns forensic traces into jobs, one per operation for its
+// files and directories, and the remaining actions
+func FromTraces(traces []forensic.Trace) ([]Job, []forensic.Trace) {
+	var jobs []Job
+	var actions []forensic.Trace
+	index := make(map[string]int)
+	for _, trace := range traces {
+		if trace.Path == "" {
+			actions = append(actions, trace)
+			continue
+		}
+		i, ok := index[trace.Operation]
+		if !ok {
+			i = len(jobs)
+			index[trace.Operation] = i
+			jobs = append(jobs, Job{Source: "forensic: " + trace.Operation, Recursive: true})
+		}
+		jobs[i].Targets = append(jobs[i].Targets, trace.Path)
+	}
+	return jobs, actions
+}
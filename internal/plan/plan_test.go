@@ -0,0 +1,217 @@
+package plan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/forensic"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture creates dir/a.txt, dir/b.txt and dir/sub/c.txt
+func fixture(t *testing.T) string {
+	dir := filepath.Join(t.TempDir(), "dir")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+	for name, content := range map[string]string{"a.txt": "alpha", "b.txt": "bravo!", "sub/c.txt": "charlie"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+// build plans a recursive wipe of dir and round-trips it through a file
+func build(t *testing.T, dir string) *Manifest {
+	m := Build(shredder.New(), shredder.WipeOptions{Passes: 1}, []Job{{Source: "wipe", Targets: []string{dir}, Recursive: true}}, nil)
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, m.Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	return loaded
+}
+
+// byPath indexes results by path
+func byPath(results []shredder.WipeResult) map[string]shredder.WipeResult {
+	index := make(map[string]shredder.WipeResult)
+	for _, result := range results {
+		index[result.Path] = result
+	}
+	return index
+}
+
+func TestBuild(t *testing.T) {
+	dir := fixture(t)
+	m := build(t, dir)
+
+	assert.Equal(t, Totals{Files: 3, Dirs: 2, Bytes: 18}, m.Totals)
+	require.Len(t, m.Entries, 5)
+	for _, entry := range m.Entries {
+		assert.Equal(t, "wipe", entry.Source)
+		assert.Empty(t, entry.Refused)
+		assert.False(t, entry.ModTime.IsZero())
+	}
+	assert.Equal(t, []string{"random"}, m.Passes)
+
+	options, err := m.Options()
+	require.NoError(t, err)
+	assert.Equal(t, 1, options.Passes)
+}
+
+func TestBuild_SkipsTargetsPlannedByEarlierJobs(t *testing.T) {
+	dir := fixture(t)
+	jobs := []Job{
+		{Source: "wipe", Targets: []string{dir}, Recursive: true},
+		{Source: "again", Targets: []string{filepath.Join(dir, "a.txt")}},
+	}
+
+	m := Build(shredder.New(), shredder.WipeOptions{Passes: 1}, jobs, nil)
+
+	assert.Len(t, m.Jobs, 1)
+	assert.Equal(t, 3, m.Totals.Files)
+}
+
+func TestApply(t *testing.T) {
+	dir := fixture(t)
+	m := build(t, dir)
+	options, err := m.Options()
+	require.NoError(t, err)
+
+	results := Apply(context.Background(), shredder.New(), m, nil, options)
+
+	for _, result := range results {
+		assert.True(t, result.Success, "%s: %v", result.Path, result.Error)
+	}
+	assert.NoDirExists(t, dir)
+}
+
+func TestApply_RefusesChangedEntries(t *testing.T) {
+	dir := fixture(t)
+	m := build(t, dir)
+	options, err := m.Options()
+	require.NoError(t, err)
+
+	grown := filepath.Join(dir, "a.txt")
+	replaced := filepath.Join(dir, "b.txt")
+	gone := filepath.Join(dir, "sub", "c.txt")
+	added := filepath.Join(dir, "new.txt")
+	require.NoError(t, os.WriteFile(grown, []byte("alpha and more"), 0o600))
+	require.NoError(t, os.Remove(replaced))
+	require.NoError(t, os.WriteFile(replaced, []byte("bravo!"), 0o600))
+	require.NoError(t, os.Remove(gone))
+	require.NoError(t, os.WriteFile(added, []byte("new"), 0o600))
+
+	results := byPath(Apply(context.Background(), shredder.New(), m, nil, options))
+
+	for _, path := range []string{grown, replaced} {
+		assert.True(t, results[path].Refused, path)
+		assert.ErrorIs(t, results[path].Error, ErrChanged, path)
+		assert.FileExists(t, path)
+	}
+	assert.True(t, results[gone].Refused)
+	assert.ErrorIs(t, results[gone].Error, ErrMissing)
+	assert.True(t, results[added].Refused)
+	assert.ErrorIs(t, results[added].Error, ErrNotPlanned)
+	assert.FileExists(t, added)
+
+	// The emptied subdirectory goes, the top one holds the refused files
+	assert.True(t, results[filepath.Join(dir, "sub")].Success)
+	assert.True(t, results[dir].Kept)
+}
+
+func TestApply_RefusesModifiedFile(t *testing.T) {
+	dir := fixture(t)
+	m := build(t, dir)
+	options, err := m.Options()
+	require.NoError(t, err)
+
+	touched := filepath.Join(dir, "a.txt")
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(touched, later, later))
+
+	results := byPath(Apply(context.Background(), shredder.New(), m, nil, options))
+
+	assert.ErrorIs(t, results[touched].Error, ErrChanged)
+	assert.FileExists(t, touched)
+	assert.True(t, results[filepath.Join(dir, "b.txt")].Success)
+}
+
+// racingGuard changes files on disk right after the planning check of
+// Apply let them through, before any of them is wiped
+type racingGuard struct {
+	changes map[string]func()
+}
+
+func (g racingGuard) Check(path string) error {
+	if change, ok := g.changes[path]; ok {
+		change()
+	}
+	return nil
+}
+
+func (g racingGuard) Limit(files int, bytes int64) error { return nil }
+
+func TestApply_RefusesFilesChangedWhileApplying(t *testing.T) {
+	dir := fixture(t)
+	m := build(t, dir)
+	options, err := m.Options()
+	require.NoError(t, err)
+
+	swapped := filepath.Join(dir, "a.txt")
+	grown := filepath.Join(dir, "b.txt")
+	guard := racingGuard{changes: map[string]func(){
+		swapped: func() {
+			replacement := filepath.Join(dir, "new.txt")
+			require.NoError(t, os.WriteFile(replacement, []byte("other"), 0o600))
+			require.NoError(t, os.Rename(replacement, swapped))
+		},
+		grown: func() {
+			f, err := os.OpenFile(grown, os.O_WRONLY|os.O_APPEND, 0)
+			require.NoError(t, err)
+			_, err = f.WriteString(" and more")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		},
+	}}
+
+	results := byPath(Apply(context.Background(), shredder.New(), m, guard, options))
+
+	for _, path := range []string{swapped, grown} {
+		assert.True(t, results[path].Refused, path)
+		assert.ErrorIs(t, results[path].Error, ErrChanged, path)
+	}
+	content, err := os.ReadFile(swapped)
+	require.NoError(t, err)
+	assert.Equal(t, "other", string(content))
+	content, err = os.ReadFile(grown)
+	require.NoError(t, err)
+	assert.Equal(t, "bravo! and more", string(content))
+	assert.True(t, results[filepath.Join(dir, "sub", "c.txt")].Success)
+}
+
+func TestLoad_Version(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99}`), 0o600))
+
+	_, err := Load(path)
+	assert.ErrorIs(t, err, ErrVersion)
+}
+
+func TestFromTraces(t *testing.T) {
+	traces := []forensic.Trace{
+		{Operation: "System Logs", Path: "/var/log/a.log"},
+		{Operation: "Event Logs", Action: forensic.ActionClearEventLog, Target: "System"},
+		{Operation: "System Logs", Path: "/var/log/b.log"},
+	}
+
+	jobs, actions := FromTraces(traces)
+
+	require.Len(t, jobs, 1)
+	assert.Equal(t, []string{"/var/log/a.log", "/var/log/b.log"}, jobs[0].Targets)
+	assert.True(t, jobs[0].Recursive)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "System", actions[0].Target)
+}
@@ -0,0 +1,57 @@
+package policy
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// mountInfo lists the mounts of the current process
+const mountInfo = "/proc/self/mountinfo"
+
+// mountPoints returns the directories filesystems are mounted on
+func mountPoints() []string {
+	file, err := os.Open(mountInfo)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	return parseMountInfo(file)
+}
+
+// parseMountInfo returns the mount points listed in a mountinfo file
+func parseMountInfo(r io.Reader) []string {
+	var mounts []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, unescapeOctal(fields[4]))
+	}
+	return mounts
+}
+
+// unescapeOctal decodes the \040 style escapes the kernel uses for spaces,
+// tabs, newlines and backslashes in mount points
+func unescapeOctal(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,19 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMountInfo(t *testing.T) {
+	info := strings.Join([]string{
+		"22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw",
+		"23 22 0:21 / /proc rw,nosuid shared:2 - proc proc rw",
+		`24 22 8:2 / /mnt/my\040disk rw,relatime shared:3 - ext4 /dev/sda2 rw`,
+		"short line",
+	}, "\n")
+
+	assert.Equal(t, []string{"/", "/proc", "/mnt/my disk"}, parseMountInfo(strings.NewReader(info)))
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package policy
+
+// mountPoints is only detected on Linux
+func mountPoints() []string {
+	return nil
+}
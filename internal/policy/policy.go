@@ -0,0 +1,217 @@
+// Package policy protects paths that must never be wiped, such as the root
+// directory, system directories, the home directory itself and mount
+// points, and bounds the size of a job. Users can extend the built-in
+// denylist and carve exceptions out of it in a policy file.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/joao-rrondon/wipeOs/internal/config"
+)
+
+// Refusal errors. Every refusal wraps one of them with an explanation.
+var (
+	// ErrProtected is returned for paths covered by the denylist
+	ErrProtected = errors.New("protected path")
+	// ErrLimit is returned for jobs exceeding the file count or size limits
+	ErrLimit = errors.New("job exceeds the safety limits")
+)
+
+// Default job limits, which apply unless the policy file or the command
+// line changes them
+const (
+	DefaultMaxFiles = 10000
+	DefaultMaxBytes = 64 << 30
+)
+
+// systemDirs are protected together with everything below them on Unix
+// systems; the last ones only exist on macOS
+var systemDirs = []string{
+	"/etc", "/usr", "/boot", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/proc", "/sys", "/dev",
+	"/System", "/Library", "/Applications", "/private/etc", "/private/var/db",
+}
+
+// FileName is the name of the policy file in the configuration directory
+const FileName = "policy.json"
+
+// Policy decides which paths may be wiped and how large a job may be
+type Policy struct {
+	// Allow lists paths that may be wiped even though the built-in
+	// denylist protects them, together with everything below them
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists paths that must never be wiped, together with everything
+	// below them. It takes precedence over Allow.
+	Deny []string `json:"deny,omitempty"`
+	// MaxFiles and MaxBytes bound the number of files and their total
+	// size per job. Zero disables a limit.
+	MaxFiles int   `json:"max_files"`
+	MaxBytes int64 `json:"max_bytes"`
+
+	builtin []rule
+}
+
+// rule protects a path, and everything below it when tree is set
+type rule struct {
+	path   string
+	tree   bool
+	reason string
+}
+
+// Default returns the built-in policy with the default limits
+func Default() *Policy {
+	return &Policy{
+		MaxFiles: DefaultMaxFiles,
+		MaxBytes: DefaultMaxBytes,
+		builtin:  builtinRules(),
+	}
+}
+
+// Load returns the built-in policy extended by the policy file at path. A
+// missing file leaves the built-in policy unchanged.
+func Load(path string) (*Policy, error) {
+	p := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// LoadConfig loads the policy file from the configuration directory
+func LoadConfig() (*Policy, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return Default(), nil
+	}
+	return Load(filepath.Join(dir, FileName))
+}
+
+// builtinRules returns the paths protected on this system
+func builtinRules() []rule {
+	var rules []rule
+	if runtime.GOOS == "windows" {
+		if drive := os.Getenv("SystemDrive"); drive != "" {
+			rules = append(rules, rule{path: drive + `\`, reason: "the root directory"})
+		}
+		for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramData"} {
+			if dir := os.Getenv(env); dir != "" {
+				rules = append(rules, rule{path: dir, tree: true, reason: "a system directory"})
+			}
+		}
+	} else {
+		rules = append(rules, rule{path: "/", reason: "the root directory"})
+		for _, dir := range systemDirs {
+			rules = append(rules, rule{path: dir, tree: true, reason: "a system directory"})
+		}
+	}
+
+	if home, err := os.UserHomeDir(); err == nil && home != "" {
+		rules = append(rules, rule{path: home, reason: "your home directory"})
+	}
+	for _, mount := range mountPoints() {
+		rules = append(rules, rule{path: mount, reason: "a mount point"})
+	}
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		rules = append(rules, rule{path: exe, reason: "the wipeOs binary"})
+	}
+
+	for i := range rules {
+		rules[i].path = filepath.Clean(rules[i].path)
+	}
+	return rules
+}
+
+// Check returns an error wrapping ErrProtected that explains why path must
+// not be wiped, or nil. Both the absolute path and, when it differs, the
+// path with symbolic links resolved are checked.
+func (p *Policy) Check(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %s: %v", ErrProtected, path, err)
+	}
+	candidates := []string{abs}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil && resolved != abs {
+		candidates = append(candidates, resolved)
+	}
+
+	for _, candidate := range candidates {
+		if err := p.check(candidate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check applies the rules to a clean absolute path
+func (p *Policy) check(path string) error {
+	for _, dir := range p.Deny {
+		if within(filepath.Clean(dir), path) {
+			return fmt.Errorf("%w: inside %s, denied by the policy file", ErrProtected, dir)
+		}
+	}
+	for _, dir := range p.Allow {
+		if within(filepath.Clean(dir), path) {
+			return nil
+		}
+	}
+
+	for _, r := range p.builtin {
+		switch {
+		case samePath(r.path, path):
+			return fmt.Errorf("%w: %s", ErrProtected, r.reason)
+		case r.tree && within(r.path, path):
+			return fmt.Errorf("%w: inside %s, %s", ErrProtected, r.path, r.reason)
+		}
+	}
+	return nil
+}
+
+// Limit returns an error wrapping ErrLimit when a job of files files
+// holding bytes bytes exceeds the limits
+func (p *Policy) Limit(files int, bytes int64) error {
+	if p.MaxFiles > 0 && files > p.MaxFiles {
+		return fmt.Errorf("%w: %d files, more than the limit of %d (raise it with --max-files or pass --no-limits)", ErrLimit, files, p.MaxFiles)
+	}
+	if p.MaxBytes > 0 && bytes > p.MaxBytes {
+		return fmt.Errorf("%w: %d bytes, more than the limit of %d (raise it with --max-size or pass --no-limits)", ErrLimit, bytes, p.MaxBytes)
+	}
+	return nil
+}
+
+// within reports whether path is root or lies below it
+func within(root, path string) bool {
+	if samePath(root, path) {
+		return true
+	}
+	rel, err := filepath.Rel(fold(root), fold(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// samePath compares paths the way the platform does
+func samePath(a, b string) bool {
+	return fold(a) == fold(b)
+}
+
+// fold lowers paths on Windows, whose filesystems ignore case
+func fold(path string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToLower(path)
+	}
+	return path
+}
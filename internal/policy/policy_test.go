@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_CheckBuiltin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix system directories")
+	}
+	p := Default()
+
+	tests := []struct {
+		path   string
+		reason string
+	}{
+		{"/", "the root directory"},
+		{"/etc", "a system directory"},
+		{"/etc/passwd", "inside /etc"},
+		{"/usr/lib/libc.so", "inside /usr"},
+		{"/boot/vmlinuz", "inside /boot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := p.check(tt.path)
+			require.ErrorIs(t, err, ErrProtected)
+			assert.Contains(t, err.Error(), tt.reason)
+		})
+	}
+
+	assert.NoError(t, p.check("/etcetera/file"))
+	assert.NoError(t, p.check(filepath.Join(t.TempDir(), "file")))
+}
+
+func TestPolicy_CheckHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	p := Default()
+
+	err := p.Check(home)
+	require.ErrorIs(t, err, ErrProtected)
+	assert.Contains(t, err.Error(), "your home directory")
+
+	// Only the home directory itself is protected, not what it holds
+	assert.NoError(t, p.Check(filepath.Join(home, "secret.txt")))
+}
+
+func TestPolicy_CheckExecutable(t *testing.T) {
+	exe, err := os.Executable()
+	require.NoError(t, err)
+
+	err = Default().Check(exe)
+	require.ErrorIs(t, err, ErrProtected)
+	assert.Contains(t, err.Error(), "the wipeOs binary")
+}
+
+func TestPolicy_CheckSymlink(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(home, link); err != nil {
+		t.Skipf("symbolic links unavailable: %v", err)
+	}
+
+	assert.ErrorIs(t, Default().Check(link), ErrProtected)
+}
+
+func TestPolicy_AllowDeny(t *testing.T) {
+	root := t.TempDir()
+	p := Default()
+	p.builtin = append(p.builtin, rule{path: root, tree: true, reason: "a test directory"})
+	p.Allow = []string{filepath.Join(root, "scratch")}
+	p.Deny = []string{filepath.Join(root, "scratch", "keep")}
+
+	assert.ErrorIs(t, p.Check(filepath.Join(root, "file")), ErrProtected)
+	assert.NoError(t, p.Check(filepath.Join(root, "scratch", "file")))
+
+	// Deny takes precedence over Allow
+	err := p.Check(filepath.Join(root, "scratch", "keep", "file"))
+	require.ErrorIs(t, err, ErrProtected)
+	assert.Contains(t, err.Error(), "denied by the policy file")
+}
+
+func TestPolicy_Limit(t *testing.T) {
+	p := &Policy{MaxFiles: 10, MaxBytes: 1 << 20}
+
+	assert.NoError(t, p.Limit(10, 1<<20))
+
+	err := p.Limit(11, 0)
+	require.ErrorIs(t, err, ErrLimit)
+	assert.Contains(t, err.Error(), "--max-files")
+
+	err = p.Limit(1, 1<<20+1)
+	require.ErrorIs(t, err, ErrLimit)
+	assert.Contains(t, err.Error(), "--max-size")
+
+	assert.NoError(t, (&Policy{}).Limit(1<<20, 1<<40))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	p, err := Load(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaxFiles, p.MaxFiles)
+	assert.Equal(t, int64(DefaultMaxBytes), p.MaxBytes)
+
+	path := filepath.Join(dir, FileName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"deny": ["/srv/backups"], "max_files": 50}`), 0o600))
+	p, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/srv/backups"}, p.Deny)
+	assert.Equal(t, 50, p.MaxFiles)
+	assert.Equal(t, int64(DefaultMaxBytes), p.MaxBytes)
+	assert.NotEmpty(t, p.builtin)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"deny": `), 0o600))
+	_, err = Load(path)
+	assert.Error(t, err)
+}
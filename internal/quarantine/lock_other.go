@@ -0,0 +1,16 @@
+//go:build !linux
+
+package quarantine
+
+import "os"
+
+// lockFile is a no-op on platforms without flock: changes to the manifest
+// are only serialized within one process
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without flock
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package quarantine
+
+import (
+	"path/filepath"
+	"syscall"
+)
+
+// mountRoot returns the topmost directory above path on the same
+// filesystem, or "" when it cannot be determined
+func mountRoot(path string) string {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return ""
+	}
+
+	dir := filepath.Dir(path)
+	for {
+		var parent syscall.Stat_t
+		if err := syscall.Stat(dir, &parent); err != nil || parent.Dev != st.Dev {
+			return ""
+		}
+		up := filepath.Dir(dir)
+		if up == dir {
+			return dir
+		}
+		var above syscall.Stat_t
+		if err := syscall.Stat(up, &above); err != nil || above.Dev != st.Dev {
+			return dir
+		}
+		dir = up
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package quarantine
+
+// mountRoot returns "" on this platform: items are staged in the store or
+// next to the target
+func mountRoot(path string) string {
+	return ""
+}
@@ -0,0 +1,460 @@
+// Package quarantine stages wipe targets instead of wiping them right away.
+// Targets are renamed into a staging directory on their own filesystem, so
+// that moving them is atomic and never copies data, and a manifest records
+// where they came from. Staged items can be restored until they are purged,
+// which wipes them like any other target.
+package quarantine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/fsutil"
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+)
+
+// Lookup and staging errors
+var (
+	// ErrNotFound is returned when no item matches a reference
+	ErrNotFound = errors.New("no quarantined item matches")
+	// ErrAmbiguous is returned when a reference matches several items
+	ErrAmbiguous = errors.New("reference matches several quarantined items")
+	// ErrExists is returned when restoring over an existing path
+	ErrExists = errors.New("original path is taken")
+	// ErrInStore is returned when quarantining the quarantine itself
+	ErrInStore = errors.New("path is part of the quarantine")
+)
+
+// DefaultDelay is how long items stay quarantined before a sweep purges
+// them, unless another delay is given
+const DefaultDelay = 24 * time.Hour
+
+const (
+	// manifestName is the name of the manifest in the store directory
+	manifestName = "manifest.json"
+	// lockName is the file locked while the manifest is read and changed,
+	// as sweeps run in another process than the commands adding items
+	lockName = "manifest.lock"
+	// itemsDir holds the items staged on the filesystem of the store
+	itemsDir = "items"
+	// stagingName is the staging directory created on other filesystems
+	stagingName = ".wipeos-quarantine"
+)
+
+// Item is a quarantined target
+type Item struct {
+	ID string `json:"id"`
+	// Original is the absolute path the item was moved from
+	Original string `json:"original"`
+	// Stored is the current location of the item. Its parent directory
+	// belongs to the item and is wiped with it.
+	Stored string `json:"stored"`
+	IsDir  bool   `json:"is_dir"`
+	// Size is the total size of the regular files of the item
+	Size  int64     `json:"size"`
+	Added time.Time `json:"added"`
+	// PurgeAt is when a sweep wipes the item
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// Due reports whether a sweep at now purges the item
+func (i Item) Due(now time.Time) bool {
+	return !now.Before(i.PurgeAt)
+}
+
+// manifest is the persisted list of quarantined items
+type manifest struct {
+	Items []Item `json:"items"`
+}
+
+// Store is a quarantine kept in a directory, usually below the WipeOs
+// configuration directory
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// Open returns the quarantine kept in dir, creating the directory if needed
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{dir: abs}, nil
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Items returns the quarantined items, oldest first
+func (s *Store) Items() ([]Item, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return m.Items, nil
+}
+
+// Add moves path into quarantine until delay has passed. The path is
+// renamed into the first staging directory on its filesystem: the store
+// itself, the root of the mount holding path, or the parent directory of
+// path.
+func (s *Store) Add(path string, delay time.Duration) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	if within(s.dir, abs) || inStaging(abs) {
+		return Item{}, fmt.Errorf("%s: %w", abs, ErrInStore)
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return Item{}, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Item{}, err
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return Item{}, err
+	}
+	defer unlock()
+
+	m, err := s.load()
+	if err != nil {
+		return Item{}, err
+	}
+
+	now := time.Now().UTC()
+	item := Item{
+		ID:       id,
+		Original: abs,
+		IsDir:    info.IsDir(),
+		Size:     treeSize(abs, info),
+		Added:    now,
+		PurgeAt:  now.Add(delay),
+	}
+
+	var lastErr error
+	for _, staging := range s.stagingDirs(abs) {
+		holder := filepath.Join(staging, id)
+		if err := os.MkdirAll(holder, 0o700); err != nil {
+			lastErr = err
+			continue
+		}
+		stored := filepath.Join(holder, filepath.Base(abs))
+		if err := os.Rename(abs, stored); err != nil {
+			// Most likely another filesystem; try the next staging directory
+			os.Remove(holder)
+			os.Remove(staging)
+			lastErr = err
+			continue
+		}
+
+		item.Stored = stored
+		m.Items = append(m.Items, item)
+		if err := s.save(m); err != nil {
+			// Without a manifest entry the item could not be found again
+			if undoErr := os.Rename(stored, abs); undoErr == nil {
+				os.Remove(holder)
+			}
+			return Item{}, err
+		}
+		return item, nil
+	}
+	return Item{}, fmt.Errorf("cannot move %s to a staging directory on its filesystem: %w", abs, lastErr)
+}
+
+// Find returns the item whose ID starts with ref or, failing that, the
+// most recent item quarantined from the path ref
+func (s *Store) Find(ref string) (Item, error) {
+	items, err := s.Items()
+	if err != nil {
+		return Item{}, err
+	}
+
+	var matches []Item
+	for _, item := range items {
+		if item.ID == ref {
+			return item, nil
+		}
+		if strings.HasPrefix(item.ID, ref) {
+			matches = append(matches, item)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+	default:
+		return Item{}, fmt.Errorf("%w: %s", ErrAmbiguous, ref)
+	}
+
+	if abs, err := filepath.Abs(ref); err == nil {
+		for i := len(items) - 1; i >= 0; i-- {
+			if items[i].Original == abs {
+				return items[i], nil
+			}
+		}
+	}
+	return Item{}, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// Restore moves an item back to its original path, recreating missing
+// parent directories. An existing entry at that path is never replaced.
+func (s *Store) Restore(id string) (Item, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return Item{}, err
+	}
+	defer unlock()
+
+	m, err := s.load()
+	if err != nil {
+		return Item{}, err
+	}
+	index := m.index(id)
+	if index < 0 {
+		return Item{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	item := m.Items[index]
+
+	if err := os.MkdirAll(filepath.Dir(item.Original), 0o755); err != nil {
+		return Item{}, err
+	}
+	if err := shredder.RenameNoReplace(item.Stored, item.Original); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return Item{}, fmt.Errorf("%w: %s", ErrExists, item.Original)
+		}
+		return Item{}, err
+	}
+	os.Remove(filepath.Dir(item.Stored))
+	removeStaging(item)
+
+	m.Items = append(m.Items[:index], m.Items[index+1:]...)
+	return item, s.save(m)
+}
+
+// Purge wipes items through the shredder as one recursive job. Items that
+// are gone afterwards leave the manifest; the others stay so that the purge
+// can be retried.
+func (s *Store) Purge(ctx context.Context, shred *shredder.Shredder, items []Item, options shredder.WipeOptions) ([]shredder.WipeResult, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	targets := make([]string, len(items))
+	for i, item := range items {
+		targets[i] = filepath.Dir(item.Stored)
+	}
+	options.Recursive = true
+	results := shred.WipeFiles(ctx, targets, options)
+	if options.DryRun {
+		return results, nil
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return results, err
+	}
+	defer unlock()
+
+	m, err := s.load()
+	if err != nil {
+		return results, err
+	}
+	kept := m.Items[:0]
+	for _, item := range m.Items {
+		if _, err := os.Lstat(filepath.Dir(item.Stored)); os.IsNotExist(err) && purging(items, item.ID) {
+			removeStaging(item)
+			continue
+		}
+		kept = append(kept, item)
+	}
+	m.Items = kept
+	return results, s.save(m)
+}
+
+// Sweep purges the items that are due at now
+func (s *Store) Sweep(ctx context.Context, shred *shredder.Shredder, now time.Time, options shredder.WipeOptions) ([]Item, []shredder.WipeResult, error) {
+	items, err := s.Items()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var due []Item
+	for _, item := range items {
+		if item.Due(now) {
+			due = append(due, item)
+		}
+	}
+	results, err := s.Purge(ctx, shred, due, options)
+	return due, results, err
+}
+
+// stagingDirs returns the staging directories to try for abs, best first
+func (s *Store) stagingDirs(abs string) []string {
+	candidates := []string{filepath.Join(s.dir, itemsDir)}
+	if root := mountRoot(abs); root != "" {
+		candidates = append(candidates, filepath.Join(root, stagingName))
+	}
+	candidates = append(candidates, filepath.Join(filepath.Dir(abs), stagingName))
+
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, dir := range candidates {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// lock serializes changes to the manifest, within this process through
+// s.mu and with other processes through a lock on the lock file. The
+// returned function releases both.
+func (s *Store) lock() (func(), error) {
+	s.mu.Lock()
+	f, err := os.OpenFile(filepath.Join(s.dir, lockName), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
+
+// load reads the manifest. The caller must hold the lock.
+func (s *Store) load() (*manifest, error) {
+	m := &manifest{}
+	data, err := os.ReadFile(filepath.Join(s.dir, manifestName))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid quarantine manifest: %w", err)
+	}
+	sort.SliceStable(m.Items, func(a, b int) bool {
+		return m.Items[a].Added.Before(m.Items[b].Added)
+	})
+	return m, nil
+}
+
+// save atomically and durably writes the manifest. The caller must hold
+// the lock.
+func (s *Store) save(m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteSynced(filepath.Join(s.dir, manifestName), data, 0o600)
+}
+
+// index returns the position of the item with the given ID, or -1
+func (m *manifest) index(id string) int {
+	for i, item := range m.Items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// purging reports whether id is one of items
+func purging(items []Item, id string) bool {
+	for _, item := range items {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// removeStaging removes the staging directory of item when it was created
+// outside the store and no longer holds anything
+func removeStaging(item Item) {
+	staging := filepath.Dir(filepath.Dir(item.Stored))
+	if filepath.Base(staging) == stagingName {
+		os.Remove(staging)
+	}
+}
+
+// treeSize returns the total size of the regular files at or below path
+func treeSize(path string, info os.FileInfo) int64 {
+	if !info.IsDir() {
+		if info.Mode().IsRegular() {
+			return info.Size()
+		}
+		return 0
+	}
+
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// within reports whether path is root or lies below it
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// inStaging reports whether path lies in a staging directory
+func inStaging(path string) bool {
+	for _, elem := range strings.Split(path, string(filepath.Separator)) {
+		if elem == stagingName {
+			return true
+		}
+	}
+	return false
+}
+
+// newID returns a sortable, unique item identifier
+func newID() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix), nil
+}
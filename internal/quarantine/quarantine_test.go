@@ -0,0 +1,187 @@
+package quarantine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joao-rrondon/wipeOs/internal/shredder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture returns a store and a directory on the same filesystem holding
+// secret.txt and sub/nested.txt
+func fixture(t *testing.T) (*Store, string) {
+	root := t.TempDir()
+	store, err := Open(filepath.Join(root, "config", "quarantine"))
+	require.NoError(t, err)
+
+	data := filepath.Join(root, "data")
+	require.NoError(t, os.MkdirAll(filepath.Join(data, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "secret.txt"), []byte("secret"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(data, "sub", "nested.txt"), []byte("nested data"), 0o600))
+	return store, data
+}
+
+func TestStore_AddAndRestore(t *testing.T) {
+	store, data := fixture(t)
+	target := filepath.Join(data, "sub")
+
+	item, err := store.Add(target, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, target, item.Original)
+	assert.True(t, item.IsDir)
+	assert.Equal(t, int64(len("nested data")), item.Size)
+	assert.NoFileExists(t, filepath.Join(target, "nested.txt"))
+	assert.FileExists(t, filepath.Join(item.Stored, "nested.txt"))
+	assert.Equal(t, filepath.Join(store.Dir(), itemsDir, item.ID, "sub"), item.Stored)
+
+	items, err := store.Items()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, item.ID, items[0].ID)
+
+	// Items are found by ID prefix or by original path
+	found, err := store.Find(item.ID[:len(item.ID)-2])
+	require.NoError(t, err)
+	assert.Equal(t, item.ID, found.ID)
+	found, err = store.Find(target)
+	require.NoError(t, err)
+	assert.Equal(t, item.ID, found.ID)
+
+	restored, err := store.Restore(item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, target, restored.Original)
+	assert.FileExists(t, filepath.Join(target, "nested.txt"))
+	assert.NoDirExists(t, filepath.Dir(item.Stored))
+
+	items, err = store.Items()
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestStore_RestoreRefusesExistingPath(t *testing.T) {
+	store, data := fixture(t)
+	target := filepath.Join(data, "secret.txt")
+
+	item, err := store.Add(target, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(target, []byte("new"), 0o600))
+
+	_, err = store.Restore(item.ID)
+	assert.ErrorIs(t, err, ErrExists)
+	assert.FileExists(t, item.Stored)
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+}
+
+func TestStore_RestoreRefusesEmptyDirectory(t *testing.T) {
+	store, data := fixture(t)
+	target := filepath.Join(data, "sub")
+
+	item, err := store.Add(target, time.Hour)
+	require.NoError(t, err)
+	// A plain rename would replace an empty directory
+	require.NoError(t, os.Mkdir(target, 0o755))
+
+	_, err = store.Restore(item.ID)
+	assert.ErrorIs(t, err, ErrExists)
+	assert.FileExists(t, filepath.Join(item.Stored, "nested.txt"))
+	entries, err := os.ReadDir(target)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestStore_ConcurrentStores(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the manifest is only locked across processes on Linux")
+	}
+	store, data := fixture(t)
+	// A second store on the same directory stands in for another process
+	other, err := Open(store.Dir())
+	require.NoError(t, err)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i, s := range []*Store{store, other} {
+		for j := 0; j < n; j++ {
+			target := filepath.Join(data, fmt.Sprintf("file-%d-%d", i, j))
+			require.NoError(t, os.WriteFile(target, []byte("data"), 0o600))
+			wg.Add(1)
+			go func(s *Store) {
+				defer wg.Done()
+				_, err := s.Add(target, time.Hour)
+				assert.NoError(t, err)
+			}(s)
+		}
+	}
+	wg.Wait()
+
+	// No store lost the items added by the other
+	items, err := store.Items()
+	require.NoError(t, err)
+	assert.Len(t, items, 2*n)
+}
+
+func TestStore_AddRefusesStore(t *testing.T) {
+	store, _ := fixture(t)
+
+	_, err := store.Add(store.Dir(), time.Hour)
+	assert.ErrorIs(t, err, ErrInStore)
+	_, err = store.Add(filepath.Join(t.TempDir(), stagingName, "x"), time.Hour)
+	assert.ErrorIs(t, err, ErrInStore)
+}
+
+func TestStore_Sweep(t *testing.T) {
+	store, data := fixture(t)
+
+	due, err := store.Add(filepath.Join(data, "secret.txt"), 0)
+	require.NoError(t, err)
+	later, err := store.Add(filepath.Join(data, "sub"), time.Hour)
+	require.NoError(t, err)
+
+	swept, results, err := store.Sweep(context.Background(), shredder.New(), time.Now(), shredder.WipeOptions{Passes: 1})
+	require.NoError(t, err)
+	require.Len(t, swept, 1)
+	assert.Equal(t, due.ID, swept[0].ID)
+	for _, result := range results {
+		assert.True(t, result.Success, "%s: %v", result.Path, result.Error)
+	}
+	assert.NoDirExists(t, filepath.Dir(due.Stored))
+	assert.FileExists(t, filepath.Join(later.Stored, "nested.txt"))
+
+	items, err := store.Items()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, later.ID, items[0].ID)
+}
+
+func TestStore_PurgeDryRun(t *testing.T) {
+	store, data := fixture(t)
+
+	item, err := store.Add(filepath.Join(data, "secret.txt"), time.Hour)
+	require.NoError(t, err)
+
+	_, err = store.Purge(context.Background(), shredder.New(), []Item{item}, shredder.WipeOptions{Passes: 1, DryRun: true})
+	require.NoError(t, err)
+	assert.FileExists(t, item.Stored)
+
+	items, err := store.Items()
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestStore_FindMissing(t *testing.T) {
+	store, _ := fixture(t)
+
+	_, err := store.Find("nothing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
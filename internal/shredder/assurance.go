@@ -0,0 +1,115 @@
+package shredder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Assurance tells how confident the shredder is that the overwrite passes
+// hit the blocks that held the original data
+type Assurance string
+
+// Assurance levels reported in WipeResult.Assurance
+const (
+	// AssuranceNone means no overwrite was assessed, as in dry runs and
+	// for entries that are only unlinked
+	AssuranceNone Assurance = ""
+	// AssuranceInPlace means the physical extents of the file were the
+	// same before and after the passes
+	AssuranceInPlace Assurance = "in-place confirmed"
+	// AssuranceRelocated means the passes were, or on this filesystem must
+	// have been, written to other blocks than the original data
+	AssuranceRelocated Assurance = "relocated"
+	// AssuranceUnknown means the physical placement could not be checked
+	AssuranceUnknown Assurance = "unknown"
+)
+
+// FIEMAP extent flags that matter for the assessment
+const (
+	fiemapExtentUnknown    = 0x00000002
+	fiemapExtentDelalloc   = 0x00000004
+	fiemapExtentEncoded    = 0x00000008
+	fiemapExtentNotAligned = 0x00000100
+	fiemapExtentDataInline = 0x00000200
+	fiemapExtentShared     = 0x00002000
+)
+
+// physicalExtent maps a logical range of a file to its location on the
+// device
+type physicalExtent struct {
+	logical  uint64
+	physical uint64
+	length   uint64
+	flags    uint32
+}
+
+// filesystem describes the filesystem a file lives on
+type filesystem struct {
+	name string
+	// copyOnWrite is set for filesystems that write modified data to new
+	// blocks by design
+	copyOnWrite bool
+}
+
+// assessOverwrite compares the physical extents of a file before and after
+// its overwrite passes. err is the error of mapping either of them.
+func assessOverwrite(fs filesystem, before, after []physicalExtent, err error) (Assurance, string) {
+	if err != nil {
+		if fs.copyOnWrite {
+			return AssuranceRelocated, fmt.Sprintf("%s is copy-on-write: passes go to new blocks and the original data survives until they are reused", fs.name)
+		}
+		return AssuranceUnknown, fmt.Sprintf("cannot map physical extents on %s: %v", fs.name, err)
+	}
+
+	for _, e := range before {
+		if e.flags&fiemapExtentShared != 0 {
+			return AssuranceRelocated, "blocks are shared with reflinked copies or snapshots, which keep the original data"
+		}
+		if e.flags&(fiemapExtentUnknown|fiemapExtentDelalloc|fiemapExtentEncoded|fiemapExtentNotAligned|fiemapExtentDataInline) != 0 {
+			return AssuranceUnknown, fmt.Sprintf("extents on %s are compressed, inline or not yet allocated, so their location cannot be compared", fs.name)
+		}
+	}
+
+	if !sameExtents(before, after) {
+		return AssuranceRelocated, fmt.Sprintf("%s wrote the passes to new blocks; the original blocks may still hold the data", fs.name)
+	}
+	if len(before) == 0 {
+		return AssuranceInPlace, "file has no allocated data"
+	}
+	return AssuranceInPlace, fmt.Sprintf("physical extents on %s unchanged by the overwrite", fs.name)
+}
+
+// sameExtents reports whether two extent maps place the same logical ranges
+// at the same physical locations, regardless of how they are split
+func sameExtents(a, b []physicalExtent) bool {
+	a, b = mergeExtents(a), mergeExtents(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].logical != b[i].logical || a[i].physical != b[i].physical || a[i].length != b[i].length {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeExtents sorts extents and joins those that are contiguous both
+// logically and physically
+func mergeExtents(extents []physicalExtent) []physicalExtent {
+	sorted := append([]physicalExtent(nil), extents...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].logical < sorted[j].logical })
+
+	var merged []physicalExtent
+	for _, e := range sorted {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.logical+last.length == e.logical && last.physical+last.length == e.physical {
+				last.length += e.length
+				continue
+			}
+		}
+		merged = append(merged, e)
+	}
+	return merged
+}
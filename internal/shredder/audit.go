@@ -0,0 +1,89 @@
+package shredder
+
+import "fmt"
+
+// Audited operations
+const (
+	AuditWipeFile        = "wipe-file"
+	AuditUnlink          = "unlink"
+	AuditRemoveDirectory = "remove-directory"
+	AuditWipeDevice      = "wipe-device"
+	AuditWipeFreeSpace   = "wipe-free-space"
+)
+
+// Outcomes of audited operations
+const (
+	OutcomeDone    = "done"
+	OutcomeFailed  = "failed"
+	OutcomeRefused = "refused"
+	// OutcomeKept is the outcome of directories left in place because
+	// they still hold entries that were not wiped
+	OutcomeKept = "kept"
+)
+
+// AuditEvent describes one operation that destroyed, or set out to
+// destroy, data
+type AuditEvent struct {
+	Operation string
+	Target    string
+	Outcome   string
+	// Detail names the method applied, or the reason of a failure
+	Detail string
+}
+
+// Auditor keeps a record of every operation. Implementations must be safe
+// for concurrent use.
+type Auditor interface {
+	Audit(event AuditEvent) error
+}
+
+// audit hands event to the auditor of options. Dry runs change nothing and
+// are not recorded. An auditor that fails is reported but does not stop
+// the job, whose files may already be half overwritten.
+func (s *Shredder) audit(options WipeOptions, event AuditEvent) {
+	if options.Audit == nil || options.DryRun {
+		return
+	}
+	if err := options.Audit.Audit(event); err != nil {
+		s.logger.Error().Err(err).Str("target", event.Target).Msg("failed to append to audit log")
+	}
+}
+
+// resultEvent describes the outcome of a file or directory
+func resultEvent(result WipeResult) AuditEvent {
+	event := AuditEvent{Operation: AuditWipeFile, Target: result.Path, Outcome: OutcomeDone}
+	switch {
+	case result.IsDir:
+		event.Operation = AuditRemoveDirectory
+	case result.UnlinkedOnly:
+		event.Operation = AuditUnlink
+	}
+
+	switch {
+	case result.Refused:
+		event.Outcome = OutcomeRefused
+	case result.Kept:
+		event.Outcome = OutcomeKept
+	case !result.Success:
+		event.Outcome = OutcomeFailed
+	}
+
+	if result.Error != nil {
+		event.Detail = result.Error.Error()
+	} else if result.Method != "" {
+		event.Detail = fmt.Sprintf("%s, %d passes", result.Method, result.Passes)
+	}
+	return event
+}
+
+// outcomeOf returns the outcome of an operation that ended with err
+func outcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeDone
+	case isRefusal(err):
+		return OutcomeRefused
+	default:
+		return OutcomeFailed
+	}
+}
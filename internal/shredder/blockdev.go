@@ -0,0 +1,245 @@
+package shredder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrInUse is returned for devices that are mounted, used as swap, held by
+// a device-mapper or loop device, or otherwise opened exclusively
+var ErrInUse = errors.New("device is mounted or in use")
+
+// DeviceResult represents the result of wiping a block device or disk image
+type DeviceResult struct {
+	Path    string
+	Success bool
+	Error   error
+	// Image is set for regular files wiped as raw disk images
+	Image bool
+	// Size is the total size of the device or image
+	Size int64
+	// Offset and Length delimit the range that was overwritten
+	Offset int64
+	Length int64
+	// Serial and Model identify a block device, as far as the kernel
+	// reports them
+	Serial string
+	Model  string
+	// ImageHash is the hex SHA-256 of the whole image after the wipe, so
+	// the state it was left in can be checked later
+	ImageHash string
+	// Method is the name of the wipe method applied
+	Method string
+	// Passes is the number of overwrite passes completed
+	Passes int
+	// Verification is the read-back status of the overwrite passes
+	Verification VerifyStatus
+	// MismatchOffset is the device offset of the first mismatch found
+	// during verification
+	MismatchOffset int64
+}
+
+// WipeDevice overwrites a block device, partition or raw image file in
+// place with the method of options. length bytes are overwritten from
+// offset; a length of zero runs to the end of the device. Devices that are
+// mounted or otherwise in use are refused with ErrInUse. Nothing is
+// removed.
+func (s *Shredder) WipeDevice(ctx context.Context, path string, offset, length int64, options WipeOptions) DeviceResult {
+	result := s.wipeDevice(ctx, path, offset, length, options)
+	event := AuditEvent{Operation: AuditWipeDevice, Target: path, Outcome: outcomeOf(result.Error)}
+	if result.Error != nil {
+		event.Detail = result.Error.Error()
+	} else {
+		event.Detail = fmt.Sprintf("%s, %d passes, %d bytes from offset %d", result.Method, result.Passes, result.Length, result.Offset)
+	}
+	s.audit(options, event)
+	return result
+}
+
+// wipeDevice performs the wipe of WipeDevice
+func (s *Shredder) wipeDevice(ctx context.Context, path string, offset, length int64, options WipeOptions) DeviceResult {
+	method := options.method()
+	result := DeviceResult{Path: path, Offset: offset, Method: method.Name}
+
+	flag := os.O_RDWR
+	if options.DryRun {
+		flag = os.O_RDONLY
+	}
+	file, image, err := OpenDevice(path, flag)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer file.Close()
+	result.Image = image
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	result.Size = size
+	if !image {
+		if info, err := file.Stat(); err == nil {
+			result.Serial, result.Model = deviceIdentity(info)
+		}
+	}
+
+	if length == 0 {
+		length = size - offset
+	}
+	if offset < 0 || length < 0 || offset > size || offset+length > size {
+		result.Error = fmt.Errorf("range %d+%d is outside the %d bytes of %s", offset, length, size, path)
+		return result
+	}
+	result.Length = length
+
+	if options.DryRun {
+		s.logger.Info().Str("device", path).Int64("offset", offset).Int64("length", length).Str("method", method.Name).Msg("would wipe device (dry run)")
+		result.Passes = method.PassCount()
+		result.Success = true
+		return result
+	}
+
+	s.logger.Info().Str("device", path).Str("serial", result.Serial).Int64("offset", offset).Int64("length", length).Str("method", method.Name).Msg("wiping device")
+	options.progress = newProgressEmitter(options.Progress)
+	options.progress.emit(ProgressEvent{Kind: EventJobStarted, Files: 1, Total: length * int64(method.PassCount()), Passes: method.PassCount()})
+	defer options.progress.emit(ProgressEvent{Kind: EventJobFinished})
+	options.progress.emit(ProgressEvent{Kind: EventFileStarted, Path: path, Total: length, Passes: method.PassCount()})
+
+	err = s.overwriteDevice(ctx, file, path, extent{offset: offset, length: length}, options, &result)
+	if err == nil && result.Image {
+		result.ImageHash, err = hashFile(ctx, file)
+	}
+	if err != nil {
+		options.progress.emit(ProgressEvent{Kind: EventError, Path: path, Err: err})
+		result.Error = err
+		return result
+	}
+	options.progress.emit(ProgressEvent{Kind: EventFileFinished, Path: path, Passes: result.Passes})
+
+	result.Success = true
+	s.logger.Info().Str("device", path).Int("passes", result.Passes).Str("verification", string(result.Verification)).Msg("device wiped successfully")
+	return result
+}
+
+// OpenDevice opens a block device or raw disk image with flag. Anything
+// else is rejected, and devices that are mounted or otherwise in use are
+// refused with ErrInUse. image reports whether path is a regular file.
+func OpenDevice(path string, flag int) (file *os.File, image bool, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false, err
+	}
+	mode := info.Mode()
+	switch {
+	case mode.IsRegular():
+		image = true
+	case mode&os.ModeDevice != 0 && mode&os.ModeCharDevice == 0:
+	default:
+		return nil, false, fmt.Errorf("%s is neither a block device nor a disk image", path)
+	}
+
+	if err := checkNotInUse(path, info); err != nil {
+		return nil, image, fmt.Errorf("%s: %w", path, err)
+	}
+
+	file, err = openDevice(path, flag, image)
+	if err != nil {
+		return nil, image, err
+	}
+	return file, image, nil
+}
+
+// overwriteDevice runs the passes of the method over one range of an open
+// device
+func (s *Shredder) overwriteDevice(ctx context.Context, file *os.File, path string, target extent, options WipeOptions, result *DeviceResult) error {
+	method := options.method()
+	extents := []extent{target}
+
+	buf := buffers.get(options.blockSize())
+	defer buffers.put(buf)
+
+	direct := options.DirectIO
+	if direct {
+		if err := setDirect(file, true); err != nil {
+			s.logger.Warn().Err(err).Str("device", path).Msg("direct I/O unavailable, using buffered writes")
+			direct = false
+		} else {
+			setDirect(file, false)
+		}
+	}
+
+	for pass, p := range method.Passes {
+		seed, err := newPassSeed()
+		if err != nil {
+			return err
+		}
+
+		options.progress.emit(ProgressEvent{Kind: EventPassStarted, Path: path, Pass: pass + 1, Passes: len(method.Passes)})
+		report, flush := options.progress.bytesReporter(path, pass+1, len(method.Passes))
+		reached, err := s.performPass(ctx, file, target.offset+target.length, passSpec{
+			pattern: p,
+			seed:    seed,
+			extents: extents,
+			buf:     *buf,
+			direct:  direct,
+			report:  report,
+		})
+		flush()
+		if err != nil {
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				syncData(file)
+				return fmt.Errorf("interrupted during pass %d at offset %d: %w", pass+1, reached, err)
+			}
+			return fmt.Errorf("pass %d (%s) failed at offset %d: %w", pass+1, p, reached, err)
+		}
+		if err := syncData(file); err != nil {
+			return err
+		}
+		result.Passes = pass + 1
+
+		if options.Verify == VerifyAll || (options.Verify == VerifyLast && pass == len(method.Passes)-1) {
+			offset, err := s.verifyPass(ctx, file, extents, p, seed, *buf)
+			if err != nil {
+				return fmt.Errorf("pass %d (%s) verification failed: %w", pass+1, p, err)
+			}
+			if offset >= 0 {
+				result.Verification = VerifyFailed
+				result.MismatchOffset = offset
+				return fmt.Errorf("pass %d (%s) verification failed: mismatch at offset %d", pass+1, p, offset)
+			}
+			result.Verification = VerifyPassed
+		}
+	}
+
+	return nil
+}
+
+// hashFile returns the hex SHA-256 of the whole file
+func hashFile(ctx context.Context, file io.ReaderAt) (string, error) {
+	hash := sha256.New()
+	buf := buffers.get(DefaultBlockSize)
+	defer buffers.put(buf)
+
+	for offset := int64(0); ; {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, err := file.ReadAt(*buf, offset)
+		hash.Write((*buf)[:n])
+		offset += int64(n)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,206 @@
+package shredder
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkNotInUse refuses block devices that are mounted, used as swap or
+// held by another device, including through one of their partitions, and
+// images attached to a loop device
+func checkNotInUse(path string, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if info.Mode().IsRegular() {
+		if loop := loopDeviceOf(sysfsRoot, path); loop != "" {
+			return fmt.Errorf("attached to %s: %w", loop, ErrInUse)
+		}
+		return nil
+	}
+
+	devs := append([]uint64{uint64(st.Rdev)}, partitionsOf(sysfsRoot, uint64(st.Rdev))...)
+	for _, dev := range devs {
+		name := fmt.Sprintf("%d:%d", unix.Major(dev), unix.Minor(dev))
+		if holders, err := os.ReadDir(filepath.Join(sysfsRoot, "dev", "block", name, "holders")); err == nil && len(holders) > 0 {
+			return fmt.Errorf("held by %s: %w", holders[0].Name(), ErrInUse)
+		}
+	}
+
+	if mount, err := mountedOn("/proc/self/mountinfo", devs); err == nil && mount != "" {
+		return fmt.Errorf("mounted on %s: %w", mount, ErrInUse)
+	}
+	if swapped, err := usedAsSwap("/proc/swaps", devs); err == nil && swapped {
+		return fmt.Errorf("used as swap: %w", ErrInUse)
+	}
+	return nil
+}
+
+// openDevice opens a device for wiping. Block devices are opened with
+// O_EXCL, which the kernel refuses while any filesystem has them mounted.
+func openDevice(path string, flag int, image bool) (*os.File, error) {
+	if !image {
+		flag |= unix.O_EXCL
+	}
+	file, err := os.OpenFile(path, flag, 0)
+	if errors.Is(err, unix.EBUSY) {
+		return nil, fmt.Errorf("%s: %w", path, ErrInUse)
+	}
+	return file, err
+}
+
+// deviceIdentity returns the serial number and model the kernel reports
+// for a block device, or for the disk holding a partition
+func deviceIdentity(info os.FileInfo) (serial, model string) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+
+	link := filepath.Join(sysfsRoot, "dev", "block", fmt.Sprintf("%d:%d", unix.Major(uint64(st.Rdev)), unix.Minor(uint64(st.Rdev))))
+	dir, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return "", ""
+	}
+	device := filepath.Join(diskDir(dir), "device")
+
+	serial = readSysfs(filepath.Join(device, "serial"))
+	if serial == "" {
+		// SCSI and SATA disks expose the unit serial number VPD page,
+		// whose payload follows a 4 byte header
+		if page, err := os.ReadFile(filepath.Join(device, "vpd_pg80")); err == nil && len(page) > 4 {
+			serial = strings.TrimSpace(string(page[4:]))
+		}
+	}
+	if serial == "" {
+		serial = readSysfs(filepath.Join(device, "wwid"))
+	}
+	return serial, readSysfs(filepath.Join(device, "model"))
+}
+
+// partitionsOf lists the device numbers of the partitions of a disk
+func partitionsOf(root string, dev uint64) []uint64 {
+	dir := filepath.Join(root, "dev", "block", fmt.Sprintf("%d:%d", unix.Major(dev), unix.Minor(dev)))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var partitions []uint64
+	for _, entry := range entries {
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), "partition")); err != nil {
+			continue
+		}
+		var major, minor uint32
+		if _, err := fmt.Sscanf(readSysfs(filepath.Join(dir, entry.Name(), "dev")), "%d:%d", &major, &minor); err == nil {
+			partitions = append(partitions, unix.Mkdev(major, minor))
+		}
+	}
+	return partitions
+}
+
+// mountedOn returns the mount point of the first mount backed by one of
+// devs, matching either the device number or the mount source
+func mountedOn(mountinfo string, devs []uint64) (string, error) {
+	file, err := os.Open(mountinfo)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		var major, minor uint32
+		if _, err := fmt.Sscanf(fields[2], "%d:%d", &major, &minor); err == nil && containsDev(devs, unix.Mkdev(major, minor)) {
+			return fields[4], nil
+		}
+		for i, field := range fields {
+			if field == "-" && i+2 < len(fields) && isDeviceOf(fields[i+2], devs) {
+				return fields[4], nil
+			}
+		}
+	}
+	return "", scanner.Err()
+}
+
+// usedAsSwap reports whether one of devs is an active swap area
+func usedAsSwap(swaps string, devs []uint64) (bool, error) {
+	file, err := os.Open(swaps)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && isDeviceOf(fields[0], devs) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// isDeviceOf reports whether path is a block device node for one of devs
+func isDeviceOf(path string, devs []uint64) bool {
+	if !strings.HasPrefix(path, "/") {
+		return false
+	}
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil || st.Mode&unix.S_IFMT != unix.S_IFBLK {
+		return false
+	}
+	return containsDev(devs, uint64(st.Rdev))
+}
+
+func containsDev(devs []uint64, dev uint64) bool {
+	for _, d := range devs {
+		if d == dev {
+			return true
+		}
+	}
+	return false
+}
+
+// loopDeviceOf returns the loop device an image file is attached to, if any
+func loopDeviceOf(root, path string) string {
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(target); err == nil {
+		target = resolved
+	}
+
+	loops, _ := filepath.Glob(filepath.Join(root, "block", "loop*", "loop", "backing_file"))
+	for _, backing := range loops {
+		if readSysfs(backing) == target {
+			return filepath.Base(filepath.Dir(filepath.Dir(backing)))
+		}
+	}
+	return ""
+}
+
+// RereadPartitions asks the kernel to reload the partition table of a block
+// device after it was changed
+func RereadPartitions(file *os.File) error {
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, file.Fd(), unix.BLKRRPART, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
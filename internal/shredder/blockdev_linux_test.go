@@ -0,0 +1,44 @@
+package shredder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestMountedOn(t *testing.T) {
+	mountinfo := filepath.Join(t.TempDir(), "mountinfo")
+	require.NoError(t, os.WriteFile(mountinfo, []byte(
+		"22 1 8:2 / / rw,relatime shared:1 - ext4 /dev/sda2 rw\n"+
+			"41 22 0:40 / /home rw,relatime shared:2 - btrfs /dev/nonexistent rw\n"), 0644))
+
+	mount, err := mountedOn(mountinfo, []uint64{unix.Mkdev(8, 0), unix.Mkdev(8, 2)})
+	require.NoError(t, err)
+	assert.Equal(t, "/", mount)
+
+	mount, err = mountedOn(mountinfo, []uint64{unix.Mkdev(8, 16)})
+	require.NoError(t, err)
+	assert.Empty(t, mount)
+}
+
+func TestLoopDeviceOf(t *testing.T) {
+	root := t.TempDir()
+	image := writeImage(t, 4096)
+	backing := filepath.Join(root, "block", "loop3", "loop", "backing_file")
+	require.NoError(t, os.MkdirAll(filepath.Dir(backing), 0755))
+
+	assert.Empty(t, loopDeviceOf(root, image))
+	require.NoError(t, os.WriteFile(backing, []byte(image+"\n"), 0644))
+	assert.Equal(t, "loop3", loopDeviceOf(root, image))
+}
+
+func TestShredder_WipeDevice_CharDevice(t *testing.T) {
+	result := New().WipeDevice(context.Background(), "/dev/null", 0, 0, WipeOptions{Passes: 1})
+	assert.False(t, result.Success)
+	assert.Error(t, result.Error)
+}
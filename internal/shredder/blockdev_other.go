@@ -0,0 +1,29 @@
+//go:build !linux
+
+package shredder
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkNotInUse cannot inspect mounts on this platform; block devices in
+// use are left to the operating system to refuse
+func checkNotInUse(path string, info os.FileInfo) error {
+	return nil
+}
+
+// openDevice opens a device or image for wiping
+func openDevice(path string, flag int, image bool) (*os.File, error) {
+	return os.OpenFile(path, flag, 0)
+}
+
+// deviceIdentity is only available on Linux
+func deviceIdentity(info os.FileInfo) (serial, model string) {
+	return "", ""
+}
+
+// RereadPartitions is only supported on Linux
+func RereadPartitions(file *os.File) error {
+	return fmt.Errorf("rereading partition tables is not supported on this platform")
+}
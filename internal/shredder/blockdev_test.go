@@ -0,0 +1,83 @@
+package shredder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeImage creates a disk image filled with 0xA5
+func writeImage(t *testing.T, size int) string {
+	image := filepath.Join(t.TempDir(), "disk.img")
+	require.NoError(t, os.WriteFile(image, bytes.Repeat([]byte{0xA5}, size), 0644))
+	return image
+}
+
+func TestShredder_WipeDevice_Image(t *testing.T) {
+	image := writeImage(t, 256<<10)
+	method, err := ParseMethod("0x00")
+	require.NoError(t, err)
+
+	var events []ProgressEvent
+	options := WipeOptions{Method: method, Verify: VerifyLast, Progress: ProgressFunc(func(e ProgressEvent) { events = append(events, e) })}
+	result := New().WipeDevice(context.Background(), image, 0, 0, options)
+	require.True(t, result.Success, "%v", result.Error)
+
+	assert.True(t, result.Image)
+	assert.Equal(t, int64(256<<10), result.Size)
+	assert.Equal(t, int64(256<<10), result.Length)
+	assert.Equal(t, 1, result.Passes)
+	assert.Equal(t, VerifyPassed, result.Verification)
+	assert.NotEmpty(t, events)
+
+	content, err := os.ReadFile(image)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, 256<<10), content)
+	sum := sha256.Sum256(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), result.ImageHash)
+}
+
+func TestShredder_WipeDevice_Range(t *testing.T) {
+	image := writeImage(t, 64<<10)
+	method, err := ParseMethod("0x00")
+	require.NoError(t, err)
+
+	result := New().WipeDevice(context.Background(), image, 4096, 8192, WipeOptions{Method: method})
+	require.True(t, result.Success, "%v", result.Error)
+	assert.Equal(t, int64(4096), result.Offset)
+	assert.Equal(t, int64(8192), result.Length)
+
+	content, err := os.ReadFile(image)
+	require.NoError(t, err)
+	require.Len(t, content, 64<<10)
+	assert.Equal(t, bytes.Repeat([]byte{0xA5}, 4096), content[:4096])
+	assert.Equal(t, make([]byte, 8192), content[4096:12288])
+	assert.Equal(t, bytes.Repeat([]byte{0xA5}, 64<<10-12288), content[12288:])
+}
+
+func TestShredder_WipeDevice_Invalid(t *testing.T) {
+	image := writeImage(t, 8192)
+	s := New()
+
+	result := s.WipeDevice(context.Background(), image, 4096, 8192, WipeOptions{Passes: 1})
+	assert.False(t, result.Success)
+	assert.Error(t, result.Error)
+
+	result = s.WipeDevice(context.Background(), filepath.Dir(image), 0, 0, WipeOptions{Passes: 1})
+	assert.False(t, result.Success)
+	assert.Error(t, result.Error)
+
+	result = s.WipeDevice(context.Background(), image, 0, 0, WipeOptions{Passes: 2, DryRun: true})
+	assert.True(t, result.Success, "%v", result.Error)
+	assert.Equal(t, 2, result.Passes)
+	content, err := os.ReadFile(image)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xA5}, 8192), content)
+}
@@ -0,0 +1,84 @@
+package shredder
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// blockAlign is the alignment of I/O buffers and of the block size. O_DIRECT
+// needs buffers, offsets and lengths aligned to the logical block size of
+// the device, which is at most 4 KiB on common hardware.
+const blockAlign = 4096
+
+// DefaultBlockSize is the size of the writes issued by an overwrite pass
+const DefaultBlockSize = 1 << 20
+
+// MaxBlockSize bounds the block size to keep memory use per worker sane
+const MaxBlockSize = 64 << 20
+
+// ValidateBlockSize checks that size is a multiple of 4 KiB between 4 KiB
+// and MaxBlockSize. Zero selects DefaultBlockSize.
+func ValidateBlockSize(size int) error {
+	if size == 0 {
+		return nil
+	}
+	if size < blockAlign || size > MaxBlockSize || size%blockAlign != 0 {
+		return fmt.Errorf("block size must be a multiple of 4 KiB between 4 KiB and %d MiB, got %d", MaxBlockSize>>20, size)
+	}
+	return nil
+}
+
+// blockSize returns the write size that applies to these options
+func (o WipeOptions) blockSize() int {
+	if o.BlockSize <= 0 || ValidateBlockSize(o.BlockSize) != nil {
+		return DefaultBlockSize
+	}
+	return o.BlockSize
+}
+
+// bufferPool hands out aligned buffers, reused across passes and files
+type bufferPool struct {
+	mu    sync.Mutex
+	pools map[int]*sync.Pool
+}
+
+// buffers is shared by all wipes of the process
+var buffers = &bufferPool{pools: make(map[int]*sync.Pool)}
+
+// get returns an aligned buffer of the given size. Its contents are
+// undefined.
+func (bp *bufferPool) get(size int) *[]byte {
+	return bp.pool(size).Get().(*[]byte)
+}
+
+// put returns a buffer obtained from get
+func (bp *bufferPool) put(buf *[]byte) {
+	bp.pool(len(*buf)).Put(buf)
+}
+
+func (bp *bufferPool) pool(size int) *sync.Pool {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	p, ok := bp.pools[size]
+	if !ok {
+		p = &sync.Pool{New: func() any {
+			buf := alignedBuffer(size)
+			return &buf
+		}}
+		bp.pools[size] = p
+	}
+	return p
+}
+
+// alignedBuffer allocates a buffer of the given size starting at a
+// blockAlign boundary
+func alignedBuffer(size int) []byte {
+	raw := make([]byte, size+blockAlign)
+	shift := 0
+	if rem := int(uintptr(unsafe.Pointer(&raw[0])) % blockAlign); rem != 0 {
+		shift = blockAlign - rem
+	}
+	return raw[shift : shift+size : shift+size]
+}
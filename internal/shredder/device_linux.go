@@ -0,0 +1,34 @@
+package shredder
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceOf returns the st_dev of the filesystem holding path, or 0 when
+// it cannot be determined
+func deviceOf(path string) uint64 {
+	var st syscall.Stat_t
+	if err := syscall.Lstat(path, &st); err != nil {
+		return 0
+	}
+	return uint64(st.Dev)
+}
+
+// isRotational reports whether the block device behind dev is a spinning
+// disk, or a mapping stacked on one. Devices without a sysfs queue, such as
+// tmpfs or network filesystems, are treated as non-rotational.
+func isRotational(dev uint64) bool {
+	if dev == 0 {
+		return false
+	}
+	return deviceMedia(sysfsRoot, dev).Rotational
+}
+
+// linkCount returns the number of names of an entry described by Lstat
+func linkCount(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 1
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package shredder
+
+import "os"
+
+// deviceOf returns 0 on platforms where devices are not distinguished, so
+// all files share a single worker pool
+func deviceOf(path string) uint64 {
+	return 0
+}
+
+// isRotational is only detected on Linux
+func isRotational(dev uint64) bool {
+	return false
+}
+
+// linkCount is only detected on Linux; every entry is treated as having a
+// single name
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
@@ -0,0 +1,74 @@
+package shredder
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// DiscardMode selects whether the blocks of a file are released to the
+// device, which on SSDs and thin-provisioned storage is what actually makes
+// the data unreadable
+type DiscardMode int
+
+const (
+	// DiscardNone only overwrites
+	DiscardNone DiscardMode = iota
+	// DiscardAfter punches the file out after the overwrite passes
+	DiscardAfter
+	// DiscardOnly punches the file out instead of overwriting it
+	DiscardOnly
+)
+
+// String returns the flag value for the mode
+func (m DiscardMode) String() string {
+	switch m {
+	case DiscardAfter:
+		return "after"
+	case DiscardOnly:
+		return "only"
+	default:
+		return "none"
+	}
+}
+
+// ParseDiscardMode parses a --discard flag value
+func ParseDiscardMode(value string) (DiscardMode, error) {
+	switch value {
+	case "", "none":
+		return DiscardNone, nil
+	case "after":
+		return DiscardAfter, nil
+	case "only":
+		return DiscardOnly, nil
+	default:
+		return DiscardNone, fmt.Errorf("invalid discard mode %q (expected none, after or only)", value)
+	}
+}
+
+// trimDevices trims the filesystems that held discarded files, so that the
+// blocks released by hole punching are passed down to the device. It runs
+// once per device, from the directory of one of its files, and records the
+// outcome on every discarded file of that device.
+func (s *Shredder) trimDevices(plan []planEntry, groups map[uint64][]int) {
+	for dev, indexes := range groups {
+		var discarded []int
+		for _, i := range indexes {
+			if plan[i].result.Success && plan[i].result.Discarded {
+				discarded = append(discarded, i)
+			}
+		}
+		if len(discarded) == 0 {
+			continue
+		}
+
+		dir := filepath.Dir(plan[discarded[0]].path)
+		err := trimFilesystem(dir)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("dir", dir).Uint64("device", dev).Msg("failed to trim filesystem")
+		}
+		for _, i := range discarded {
+			plan[i].result.Trimmed = err == nil
+			plan[i].result.TrimError = err
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package shredder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// planEntry is one step of a WipeFiles call, in result order
+type planEntry struct {
+	path string
+	// root is the directory below which path is opened without following
+	// symbolic links
+	root string
+	// wipe marks a file that still has to be overwritten
+	wipe bool
+	// result holds the outcome, either resolved while planning or filled
+	// in by a worker
+	result WipeResult
+	// tree is set for the teardown of a recursively wiped directory, which
+	// runs after every file has been processed
+	tree []string
+}
+
+// planWipe expands the targets into an ordered list of files to wipe and
+// directory trees to tear down
+func (s *Shredder) planWipe(paths []string, options WipeOptions) []planEntry {
+	var plan []planEntry
+	visited := make(map[string]bool)
+
+	for _, path := range paths {
+		plan = append(plan, s.planTarget(path, options, visited)...)
+	}
+
+	return plan
+}
+
+// planTarget plans a single target. Symbolic links are refused unless
+// options.FollowSymlinks is set, in which case their target is planned
+// instead. visited holds the directories already walked, so that links
+// cannot make a directory be wiped twice or loop.
+func (s *Shredder) planTarget(path string, options WipeOptions, visited map[string]bool) []planEntry {
+	info, err := s.fs.Lstat(path)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if !options.FollowSymlinks {
+			return []planEntry{refusedEntry(path, ErrSymlink)}
+		}
+
+		resolved, err := s.fs.EvalSymlinks(path)
+		if err != nil {
+			return []planEntry{{path: path, result: WipeResult{Path: path, Success: false, Error: err}}}
+		}
+		s.logger.Debug().Str("link", path).Str("target", resolved).Msg("following symbolic link")
+		path = resolved
+		info, err = s.fs.Lstat(path)
+	}
+
+	if err := options.guard(path); err != nil {
+		return []planEntry{refusedEntry(path, err)}
+	}
+
+	if options.Recursive && err == nil && info.IsDir() {
+		key, absErr := filepath.Abs(path)
+		if absErr != nil {
+			key = path
+		}
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+		return s.planDirectory(path, options, visited)
+	}
+
+	return []planEntry{{path: path, root: filepath.Dir(path), wipe: true}}
+}
+
+// planDirectory walks a directory and lists its files followed by the
+// teardown of the tree. The walk never descends through symbolic links;
+// links are refused or, with options.FollowSymlinks, planned as targets of
+// their own.
+func (s *Shredder) planDirectory(dirPath string, options WipeOptions, visited map[string]bool) []planEntry {
+	var plan []planEntry
+	var dirs []string
+
+	err := s.fs.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			plan = append(plan, planEntry{path: path, result: WipeResult{Path: path, Success: false, Error: err}})
+			return nil
+		}
+
+		if path != dirPath && info.Mode()&os.ModeSymlink == 0 {
+			if err := options.guard(path); err != nil {
+				plan = append(plan, refusedEntry(path, err))
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			plan = append(plan, s.planTarget(path, options, visited)...)
+		case info.IsDir():
+			dirs = append(dirs, path)
+		default:
+			plan = append(plan, planEntry{path: path, root: dirPath, wipe: true})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		plan = append(plan, planEntry{path: dirPath, result: WipeResult{Path: dirPath, Success: false, Error: err}})
+	}
+
+	return append(plan, planEntry{path: dirPath, root: dirPath, tree: dirs})
+}
+
+// refusedEntry is a plan entry for a target left alone for safety
+func refusedEntry(path string, reason error) planEntry {
+	err := &os.PathError{Op: "wipe", Path: path, Err: reason}
+	return planEntry{path: path, result: WipeResult{Path: path, Success: false, Error: err, Refused: true}}
+}
+
+// runPlan wipes every pending file of the plan. Files are grouped by the
+// device they live on and each group is served by its own pool of workers,
+// limited to one worker on rotational disks. A global limit of
+// options.Jobs bounds the total concurrency.
+func (s *Shredder) runPlan(ctx context.Context, plan []planEntry, options WipeOptions) {
+	jobs := options.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	groups := make(map[uint64][]int)
+	for i := range plan {
+		if plan[i].wipe {
+			dev := deviceOf(plan[i].path)
+			groups[dev] = append(groups[dev], i)
+		}
+	}
+
+	// With automatic selection every device gets its own policy
+	deviceOptions := make(map[uint64]WipeOptions, len(groups))
+	for dev, indexes := range groups {
+		deviceOptions[dev] = options
+		if options.Auto {
+			deviceOptions[dev] = s.autoPolicy(plan[indexes[0]].path, options).apply(options)
+		}
+	}
+
+	sizes := make(map[int]int64)
+	passes := make(map[uint64]int, len(groups))
+	var total int64
+	var maxPasses int
+	for dev, indexes := range groups {
+		opts := deviceOptions[dev]
+		if opts.Discard != DiscardOnly {
+			passes[dev] = opts.method().PassCount()
+		}
+		maxPasses = max(maxPasses, passes[dev])
+		for _, i := range indexes {
+			if info, err := s.fs.Lstat(plan[i].path); err == nil {
+				sizes[i] = allocatedSize(info)
+				total += sizes[i] * int64(passes[dev])
+			}
+		}
+	}
+
+	options.progress.emit(ProgressEvent{Kind: EventJobStarted, Files: len(sizes), Total: total, Passes: maxPasses})
+	defer options.progress.emit(ProgressEvent{Kind: EventJobFinished})
+
+	devices := make([]uint64, 0, len(groups))
+	for dev := range groups {
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i] < devices[j] })
+
+	slots := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for _, dev := range devices {
+		indexes := groups[dev]
+		opts := deviceOptions[dev]
+		devPasses := passes[dev]
+
+		workers := jobs
+		if isRotational(dev) {
+			workers = 1
+		}
+		if workers > len(indexes) {
+			workers = len(indexes)
+		}
+
+		s.logger.Debug().Uint64("device", dev).Int("files", len(indexes)).Int("workers", workers).Msg("starting device worker pool")
+
+		queue := make(chan int, len(indexes))
+		for _, i := range indexes {
+			queue <- i
+		}
+		close(queue)
+
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range queue {
+					slots <- struct{}{}
+					path := plan[i].path
+					if err := ctx.Err(); err != nil {
+						// Leave files that were not started untouched
+						plan[i].result = WipeResult{Path: path, Error: err}
+					} else {
+						options.progress.emit(ProgressEvent{Kind: EventFileStarted, Path: path, Total: sizes[i], Passes: devPasses})
+						plan[i].result = s.wipeFileAt(ctx, plan[i].root, path, opts)
+						s.audit(options, resultEvent(plan[i].result))
+					}
+
+					err := plan[i].result.Error
+					if err != nil {
+						options.progress.emit(ProgressEvent{Kind: EventError, Path: path, Err: err})
+					} else {
+						options.progress.emit(ProgressEvent{Kind: EventFileFinished, Path: path, Passes: plan[i].result.Passes})
+					}
+					if options.Journal != nil && !options.DryRun {
+						if jerr := options.Journal.Finish(path, err); jerr != nil {
+							s.logger.Warn().Err(jerr).Str("file", path).Msg("failed to update wipe journal")
+						}
+					}
+					<-slots
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+
+	if !options.DryRun {
+		s.trimDevices(plan, groups)
+	}
+}
+
+// collectResults flattens the plan into results, tearing down directory
+// trees once their files are done. Entries that were not wiped keep the
+// directories above them.
+func (s *Shredder) collectResults(ctx context.Context, plan []planEntry, options WipeOptions) []WipeResult {
+	var results []WipeResult
+	var leftovers []string
+
+	for _, entry := range plan {
+		if entry.tree != nil {
+			// An interrupted job keeps its directories for the resume
+			if !options.DryRun && ctx.Err() == nil {
+				removed := s.removeTree(entry.path, entry.tree, leftovers, options)
+				for _, result := range removed {
+					s.audit(options, resultEvent(result))
+				}
+				results = append(results, removed...)
+			}
+			continue
+		}
+		if !entry.wipe {
+			// Refused or rejected while planning, so no worker saw it
+			s.audit(options, resultEvent(entry.result))
+		}
+		if !entry.result.Success {
+			leftovers = append(leftovers, entry.path)
+		}
+		results = append(results, entry.result)
+	}
+
+	return results
+}
@@ -0,0 +1,61 @@
+package shredder
+
+// extent is a range of a file holding allocated data
+type extent struct {
+	offset int64
+	length int64
+}
+
+// wholeFile returns a single extent covering size bytes
+func wholeFile(size int64) []extent {
+	if size <= 0 {
+		return nil
+	}
+	return []extent{{offset: 0, length: size}}
+}
+
+// extentBytes returns the number of bytes covered by extents
+func extentBytes(extents []extent) int64 {
+	var total int64
+	for _, e := range extents {
+		total += e.length
+	}
+	return total
+}
+
+// fileExtents returns the allocated extents of the first size bytes of an
+// open file. Holes read back as zeros and hold no data, so only these
+// ranges need overwriting. When the filesystem cannot report holes the
+// whole file is returned.
+func (s *Shredder) fileExtents(file File, path string, size int64) []extent {
+	extents, err := dataExtents(file, size)
+	if err != nil {
+		s.logger.Debug().Err(err).Str("file", path).Msg("cannot map sparse extents, overwriting the whole file")
+		return wholeFile(size)
+	}
+	if extents == nil {
+		// Entirely sparse, there is nothing to overwrite
+		extents = []extent{}
+	}
+	return extents
+}
+
+// slackExtents extends the extent that ends at size to the next multiple of
+// blockSize, so that a pass also covers the file slack: the unused tail of
+// the last allocated block, which may still hold earlier contents. It
+// returns the extended extents and the number of slack bytes added, which
+// is zero when the file ends on a block boundary or in a hole.
+func slackExtents(extents []extent, size, blockSize int64) ([]extent, int64) {
+	if blockSize <= 0 || size%blockSize == 0 || len(extents) == 0 {
+		return extents, 0
+	}
+	last := extents[len(extents)-1]
+	if last.offset+last.length != size {
+		return extents, 0
+	}
+
+	slack := blockSize - size%blockSize
+	extended := append([]extent(nil), extents...)
+	extended[len(extended)-1].length += slack
+	return extended, slack
+}
@@ -0,0 +1,54 @@
+package shredder
+
+import (
+	"errors"
+	"os"
+)
+
+// Refusal errors. Targets failing with these are reported with
+// WipeResult.Refused set and are left untouched.
+var (
+	// ErrSymlink is returned for symbolic links when symlinks are not
+	// followed, including a path component swapped for a link after the
+	// target was planned
+	ErrSymlink = errors.New("refusing to follow symbolic link")
+	// ErrReplaced is returned when a name no longer refers to the file
+	// that was opened and overwritten
+	ErrReplaced = errors.New("entry was replaced after it was opened")
+	// ErrHardLinked is returned for regular files with other names when
+	// the hard link policy is HardLinkSkip
+	ErrHardLinked = errors.New("refusing to overwrite file with other hard links")
+)
+
+// isRefusal reports whether err means a target was deliberately not touched
+func isRefusal(err error) bool {
+	return errors.Is(err, ErrSymlink) || errors.Is(err, ErrReplaced) || errors.Is(err, ErrHardLinked)
+}
+
+// sameEntry closes h and fails with ErrReplaced unless h refers to the
+// entry described by info
+func sameEntry(h Entry, info os.FileInfo) error {
+	if sameFile(info, h.Info()) {
+		return nil
+	}
+	h.Close()
+	return &os.PathError{Op: "open", Path: h.Path(), Err: ErrReplaced}
+}
+
+// Path returns the current path of the entry
+func (h *handle) Path() string {
+	return h.path
+}
+
+// Info describes the entry as it was opened
+func (h *handle) Info() os.FileInfo {
+	return h.info
+}
+
+// File returns the open file, nil when the entry was not opened
+func (h *handle) File() File {
+	if h.file == nil {
+		return nil
+	}
+	return h.file
+}
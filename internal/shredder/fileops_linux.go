@@ -0,0 +1,222 @@
+package shredder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// handle is an open file or directory together with a descriptor of its
+// parent directory. Every component below root is opened with O_NOFOLLOW
+// relative to its parent, and metadata changes, renames and the final
+// unlink go through the parent descriptor after checking that the name
+// still refers to the inode that was opened.
+type handle struct {
+	// path is the current path of the entry, for reporting
+	path  string
+	dirfd int
+	name  string
+	file  *os.File
+	info  os.FileInfo
+}
+
+// openHandle opens path, which must lie below root, without following
+// symbolic links in any component after root
+func openHandle(root, path string, flag int) (*handle, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		root, rel = filepath.Dir(path), filepath.Base(path)
+	}
+
+	dirfd, err := unix.Open(root, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: root, Err: err}
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	current := root
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		next, err := unix.Openat(dirfd, part, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+		if err != nil {
+			err = symlinkError(dirfd, part, err)
+			unix.Close(dirfd)
+			return nil, &os.PathError{Op: "open", Path: current, Err: err}
+		}
+		unix.Close(dirfd)
+		dirfd = next
+	}
+
+	// The entry may have been swapped for a FIFO or a device since it was
+	// examined. O_NONBLOCK keeps opening a FIFO from waiting for a peer
+	// forever; it is cleared once the entry proves to be a regular file.
+	name := parts[len(parts)-1]
+	nonblock := flag&unix.O_PATH == 0
+	if nonblock {
+		flag |= unix.O_NONBLOCK
+	}
+	fd, err := unix.Openat(dirfd, name, flag|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		err = symlinkError(dirfd, name, err)
+		unix.Close(dirfd)
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+
+	file := os.NewFile(uintptr(fd), path)
+	info, err := file.Stat()
+	if err == nil && nonblock {
+		err = clearNonblock(path, fd, info)
+	}
+	if err != nil {
+		file.Close()
+		unix.Close(dirfd)
+		return nil, err
+	}
+
+	return &handle{path: path, dirfd: dirfd, name: name, file: file, info: info}, nil
+}
+
+// clearNonblock restores blocking I/O on a regular file opened with
+// O_NONBLOCK. Only regular files and directories are opened for content, so
+// any other type means the entry was replaced.
+func clearNonblock(path string, fd int, info os.FileInfo) error {
+	switch {
+	case info.Mode().IsDir():
+		return nil
+	case !info.Mode().IsRegular():
+		return &os.PathError{Op: "open", Path: path, Err: ErrReplaced}
+	}
+	flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFL, 0)
+	if err == nil {
+		_, err = unix.FcntlInt(uintptr(fd), unix.F_SETFL, flags&^unix.O_NONBLOCK)
+	}
+	if err != nil {
+		return &os.PathError{Op: "fcntl", Path: path, Err: err}
+	}
+	return nil
+}
+
+// openNodeHandle opens any kind of entry below root for metadata changes
+// and removal only. The O_PATH descriptor neither blocks on FIFOs nor
+// triggers the side effects of opening a device.
+func openNodeHandle(root, path string) (*handle, error) {
+	return openHandle(root, path, unix.O_PATH)
+}
+
+// symlinkError maps the failure of an O_NOFOLLOW open to ErrSymlink when
+// the entry turns out to be a symbolic link
+func symlinkError(dirfd int, name string, err error) error {
+	if err != unix.ELOOP && err != unix.ENOTDIR {
+		return err
+	}
+	var st unix.Stat_t
+	if unix.Fstatat(dirfd, name, &st, unix.AT_SYMLINK_NOFOLLOW) == nil && st.Mode&unix.S_IFMT == unix.S_IFLNK {
+		return ErrSymlink
+	}
+	return err
+}
+
+// check verifies that the name still refers to the opened inode
+func (h *handle) check() error {
+	var st unix.Stat_t
+	if err := unix.Fstatat(h.dirfd, h.name, &st, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &os.PathError{Op: "lstat", Path: h.path, Err: err}
+	}
+	opened, ok := h.info.Sys().(*syscall.Stat_t)
+	if !ok || uint64(st.Dev) != uint64(opened.Dev) || st.Ino != opened.Ino {
+		return &os.PathError{Op: "check", Path: h.path, Err: ErrReplaced}
+	}
+	return nil
+}
+
+// Exists reports whether name is taken in the parent directory
+func (h *handle) Exists(name string) bool {
+	var st unix.Stat_t
+	return unix.Fstatat(h.dirfd, name, &st, unix.AT_SYMLINK_NOFOLLOW) != unix.ENOENT
+}
+
+// Truncate cuts a file to zero length through its descriptor
+func (h *handle) Truncate() error {
+	return h.file.Truncate(0)
+}
+
+// Chtimes sets the access and modification times of the entry
+func (h *handle) Chtimes(t time.Time) error {
+	if err := h.check(); err != nil {
+		return err
+	}
+	ts := unix.NsecToTimespec(t.UnixNano())
+	if err := unix.UtimesNanoAt(h.dirfd, h.name, []unix.Timespec{ts, ts}, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &os.PathError{Op: "chtimes", Path: h.path, Err: err}
+	}
+	return nil
+}
+
+// Rename gives the entry a new name in the same directory without
+// replacing an existing entry
+func (h *handle) Rename(name string) error {
+	if err := h.check(); err != nil {
+		return err
+	}
+	newPath := filepath.Join(filepath.Dir(h.path), name)
+	if err := renameNoReplace(h.dirfd, h.name, h.dirfd, name); err != nil {
+		return &os.LinkError{Op: "rename", Old: h.path, New: newPath, Err: err}
+	}
+	h.name, h.path = name, newPath
+	return nil
+}
+
+// RenameNoReplace renames oldpath to newpath, failing with an error
+// matching os.ErrExist instead of replacing an entry at newpath
+func RenameNoReplace(oldpath, newpath string) error {
+	if err := renameNoReplace(unix.AT_FDCWD, oldpath, unix.AT_FDCWD, newpath); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	return nil
+}
+
+// renameNoReplace renames with RENAME_NOREPLACE. Filesystems without it
+// get the new name checked free right before a plain rename.
+func renameNoReplace(olddirfd int, oldname string, newdirfd int, newname string) error {
+	err := unix.Renameat2(olddirfd, oldname, newdirfd, newname, unix.RENAME_NOREPLACE)
+	if err != unix.EINVAL && err != unix.ENOSYS {
+		return err
+	}
+	var st unix.Stat_t
+	switch err := unix.Fstatat(newdirfd, newname, &st, unix.AT_SYMLINK_NOFOLLOW); err {
+	case nil:
+		return unix.EEXIST
+	case unix.ENOENT:
+		return unix.Renameat(olddirfd, oldname, newdirfd, newname)
+	default:
+		return err
+	}
+}
+
+// Remove unlinks the entry
+func (h *handle) Remove() error {
+	if err := h.check(); err != nil {
+		return err
+	}
+	flags := 0
+	if h.info.IsDir() {
+		flags = unix.AT_REMOVEDIR
+	}
+	if err := unix.Unlinkat(h.dirfd, h.name, flags); err != nil {
+		return &os.PathError{Op: "remove", Path: h.path, Err: err}
+	}
+	return nil
+}
+
+// Close releases the descriptors of the handle
+func (h *handle) Close() error {
+	err := h.file.Close()
+	if cerr := unix.Close(h.dirfd); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,177 @@
+package shredder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestShredder_WipeDirectory_SpecialFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	testDir := filepath.Join(tmpDir, "testdir")
+	require.NoError(t, os.Mkdir(testDir, 0755))
+	fifo := filepath.Join(testDir, "pipe")
+	require.NoError(t, unix.Mkfifo(fifo, 0644))
+	regular := filepath.Join(testDir, "file.txt")
+	require.NoError(t, os.WriteFile(regular, []byte("data"), 0644))
+
+	results := New().wipeDirectory(context.Background(), testDir, WipeOptions{Passes: 1, Force: true})
+
+	byPath := map[string]WipeResult{}
+	for _, result := range results {
+		byPath[result.Path] = result
+	}
+
+	require.Contains(t, byPath, fifo)
+	assert.True(t, byPath[fifo].Success, "%v", byPath[fifo].Error)
+	assert.Equal(t, TypeFIFO, byPath[fifo].Type)
+	assert.True(t, byPath[fifo].UnlinkedOnly)
+	assert.Equal(t, 0, byPath[fifo].Passes)
+
+	assert.Equal(t, TypeRegular, byPath[regular].Type)
+	assert.False(t, byPath[regular].UnlinkedOnly)
+	assert.Equal(t, 1, byPath[regular].Passes)
+
+	_, err := os.Stat(testDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOpenHandle_SwappedForFIFO(t *testing.T) {
+	dir := t.TempDir()
+	fifo := filepath.Join(dir, "pipe")
+	require.NoError(t, unix.Mkfifo(fifo, 0o644))
+
+	// Neither open waits for a reader or writer on the other end
+	for _, flag := range []int{os.O_WRONLY, os.O_RDWR} {
+		_, err := openHandle(dir, fifo, flag)
+		assert.Error(t, err)
+	}
+	_, err := openHandle(dir, fifo, os.O_RDWR)
+	assert.ErrorIs(t, err, ErrReplaced)
+
+	regular := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(regular, []byte("data"), 0o644))
+	h, err := openHandle(dir, regular, os.O_RDWR)
+	require.NoError(t, err)
+	defer h.Close()
+	flags, err := unix.FcntlInt(h.file.Fd(), unix.F_GETFL, 0)
+	require.NoError(t, err)
+	assert.Zero(t, flags&unix.O_NONBLOCK)
+}
+
+func TestShredder_WipeFile_HardLinks(t *testing.T) {
+	tests := []struct {
+		policy      HardLinkPolicy
+		success     bool
+		refused     bool
+		unlinked    bool
+		otherIntact bool
+	}{
+		{HardLinkSkip, false, true, false, true},
+		{HardLinkUnlink, true, false, true, true},
+		{HardLinkOverwrite, true, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			tmpDir := t.TempDir()
+			testFile := filepath.Join(tmpDir, "file.txt")
+			other := filepath.Join(tmpDir, "other.txt")
+			require.NoError(t, os.WriteFile(testFile, []byte("shared data"), 0644))
+			require.NoError(t, os.Link(testFile, other))
+
+			result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 1, Force: true, HardLinks: tt.policy})
+
+			assert.Equal(t, tt.success, result.Success, "%v", result.Error)
+			assert.Equal(t, tt.refused, result.Refused)
+			assert.Equal(t, tt.unlinked, result.UnlinkedOnly)
+			assert.Equal(t, uint64(2), result.Links)
+			if tt.refused {
+				assert.ErrorIs(t, result.Error, ErrHardLinked)
+			}
+
+			_, err := os.Lstat(testFile)
+			assert.Equal(t, tt.refused, err == nil)
+
+			content, err := os.ReadFile(other)
+			require.NoError(t, err)
+			assert.Equal(t, tt.otherIntact, string(content) == "shared data")
+		})
+	}
+}
+
+func TestMapPhysical(t *testing.T) {
+	tmpDir := t.TempDir()
+	file, err := os.Create(filepath.Join(tmpDir, "test.bin"))
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = file.Write(make([]byte, 3*4096))
+	require.NoError(t, err)
+
+	extents, err := mapPhysical(file, 3*4096)
+	if err != nil {
+		t.Skipf("FIEMAP not supported by %s: %v", filesystemOf(file).name, err)
+	}
+	require.NotEmpty(t, extents)
+
+	var total uint64
+	for _, e := range extents {
+		total += e.length
+	}
+	assert.GreaterOrEqual(t, total, uint64(3*4096))
+}
+
+func TestPunchHole(t *testing.T) {
+	tmpDir := t.TempDir()
+	file, err := os.Create(filepath.Join(tmpDir, "test.bin"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	data := make([]byte, 64<<10)
+	for i := range data {
+		data[i] = 0xA5
+	}
+	_, err = file.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, file.Sync())
+
+	if err := punchHole(file, int64(len(data))); err != nil {
+		t.Skipf("hole punching not supported by %s: %v", filesystemOf(file).name, err)
+	}
+
+	content := make([]byte, len(data))
+	_, err = file.ReadAt(content, 0)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, len(data)), content)
+
+	extents, err := dataExtents(file, int64(len(data)))
+	require.NoError(t, err)
+	assert.Empty(t, extents)
+}
+
+func TestShredder_WipeFiles_DiscardOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+	require.NoError(t, os.WriteFile(testFile, make([]byte, 64<<10), 0644))
+
+	results := New().WipeFiles(context.Background(), []string{testFile}, WipeOptions{Passes: 3, Force: true, Discard: DiscardOnly})
+	require.Len(t, results, 1)
+	result := results[0]
+	if result.DiscardError != nil {
+		t.Skipf("hole punching not supported: %v", result.DiscardError)
+	}
+
+	assert.True(t, result.Success, "%v", result.Error)
+	assert.True(t, result.Discarded)
+	assert.Equal(t, "discard", result.Method)
+	assert.Equal(t, 0, result.Passes)
+	// FITRIM needs privileges, but its outcome is always recorded
+	assert.True(t, result.Trimmed || result.TrimError != nil)
+	_, err := os.Stat(testFile)
+	assert.True(t, os.IsNotExist(err))
+}
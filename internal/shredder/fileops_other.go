@@ -0,0 +1,147 @@
+//go:build !linux
+
+package shredder
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// handle is an open file or directory. Without *at system calls every
+// operation goes through the path, after checking with Lstat that the name
+// still refers to the file that was opened. The file is closed before it is
+// renamed or removed, as some platforms refuse to do either on open files.
+type handle struct {
+	// path is the current path of the entry, for reporting
+	path string
+	// file is nil for entries that are never opened
+	file *os.File
+	info os.FileInfo
+}
+
+// openHandle opens path without following a symbolic link in its last
+// component. root is not used on this platform.
+func openHandle(root, path string, flag int) (*handle, error) {
+	before, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if before.Mode()&os.ModeSymlink != 0 {
+		return nil, &os.PathError{Op: "open", Path: path, Err: ErrSymlink}
+	}
+
+	file, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !os.SameFile(before, info) {
+		file.Close()
+		return nil, &os.PathError{Op: "open", Path: path, Err: ErrReplaced}
+	}
+
+	return &handle{path: path, file: file, info: info}, nil
+}
+
+// openNodeHandle describes any kind of entry for metadata changes and
+// removal only. The entry is not opened, so FIFOs and devices are never
+// touched.
+func openNodeHandle(root, path string) (*handle, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil, &os.PathError{Op: "open", Path: path, Err: ErrSymlink}
+	}
+	return &handle{path: path, info: info}, nil
+}
+
+// check verifies that the name still refers to the opened file
+func (h *handle) check() error {
+	current, err := os.Lstat(h.path)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(current, h.info) {
+		return &os.PathError{Op: "check", Path: h.path, Err: ErrReplaced}
+	}
+	return nil
+}
+
+// Exists reports whether name is taken in the parent directory
+func (h *handle) Exists(name string) bool {
+	_, err := os.Lstat(filepath.Join(filepath.Dir(h.path), name))
+	return !os.IsNotExist(err)
+}
+
+// Truncate cuts a file to zero length through its descriptor
+func (h *handle) Truncate() error {
+	if h.file == nil {
+		return os.ErrInvalid
+	}
+	return h.file.Truncate(0)
+}
+
+// Chtimes sets the access and modification times of the entry
+func (h *handle) Chtimes(t time.Time) error {
+	if err := h.check(); err != nil {
+		return err
+	}
+	return os.Chtimes(h.path, t, t)
+}
+
+// Rename gives the entry a new name in the same directory
+func (h *handle) Rename(name string) error {
+	if err := h.check(); err != nil {
+		return err
+	}
+	h.closeFile()
+	newPath := filepath.Join(filepath.Dir(h.path), name)
+	if err := os.Rename(h.path, newPath); err != nil {
+		return err
+	}
+	h.path = newPath
+	return nil
+}
+
+// RenameNoReplace renames oldpath to newpath, failing with an error
+// matching os.ErrExist instead of replacing an entry at newpath. Without
+// RENAME_NOREPLACE the new name is checked free right before renaming.
+func RenameNoReplace(oldpath, newpath string) error {
+	if _, err := os.Lstat(newpath); err == nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: os.ErrExist}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(oldpath, newpath)
+}
+
+// Remove unlinks the entry
+func (h *handle) Remove() error {
+	if err := h.check(); err != nil {
+		return err
+	}
+	h.closeFile()
+	return os.Remove(h.path)
+}
+
+// Close releases the file of the handle
+func (h *handle) Close() error {
+	h.closeFile()
+	return nil
+}
+
+// closeFile closes the file if the entry was opened
+func (h *handle) closeFile() {
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+}
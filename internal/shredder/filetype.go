@@ -0,0 +1,81 @@
+package shredder
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileType classifies a wipe target by the kind of filesystem entry it is
+type FileType string
+
+// File types reported in WipeResult.Type
+const (
+	TypeRegular   FileType = "regular"
+	TypeDirectory FileType = "directory"
+	TypeSymlink   FileType = "symlink"
+	TypeFIFO      FileType = "fifo"
+	TypeSocket    FileType = "socket"
+	TypeDevice    FileType = "device"
+	TypeOther     FileType = "other"
+)
+
+// classify returns the file type of an entry described by Lstat
+func classify(info os.FileInfo) FileType {
+	mode := info.Mode()
+	switch {
+	case mode.IsRegular():
+		return TypeRegular
+	case mode.IsDir():
+		return TypeDirectory
+	case mode&os.ModeSymlink != 0:
+		return TypeSymlink
+	case mode&os.ModeNamedPipe != 0:
+		return TypeFIFO
+	case mode&os.ModeSocket != 0:
+		return TypeSocket
+	case mode&os.ModeDevice != 0:
+		return TypeDevice
+	default:
+		return TypeOther
+	}
+}
+
+// HardLinkPolicy selects how regular files with more than one name are
+// handled. Overwriting such a file destroys the data for every name.
+type HardLinkPolicy int
+
+const (
+	// HardLinkSkip refuses to touch the file
+	HardLinkSkip HardLinkPolicy = iota
+	// HardLinkUnlink removes this name without overwriting the data
+	HardLinkUnlink
+	// HardLinkOverwrite overwrites the shared data and removes this name
+	HardLinkOverwrite
+)
+
+// String returns the flag value of the policy
+func (p HardLinkPolicy) String() string {
+	switch p {
+	case HardLinkUnlink:
+		return "unlink"
+	case HardLinkOverwrite:
+		return "overwrite"
+	default:
+		return "skip"
+	}
+}
+
+// ParseHardLinkPolicy parses "skip", "unlink" or "overwrite"
+func ParseHardLinkPolicy(value string) (HardLinkPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "skip":
+		return HardLinkSkip, nil
+	case "unlink":
+		return HardLinkUnlink, nil
+	case "overwrite":
+		return HardLinkOverwrite, nil
+	default:
+		return HardLinkSkip, fmt.Errorf("invalid hard link policy %q, expected skip, unlink or overwrite", value)
+	}
+}
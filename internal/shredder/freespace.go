@@ -0,0 +1,280 @@
+package shredder
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// freeSpaceChunk is the size of each fill file, kept well below the
+	// file size limits of FAT and most quotas
+	freeSpaceChunk = 1 << 30
+	// smallFilePayload is the size of each small file, small enough to be
+	// stored inline in the inode where the filesystem supports it
+	smallFilePayload = 60
+	// smallFileBatch is the number of small files created between two
+	// checks of the free space
+	smallFileBatch = 64
+	// smallFileShare is the fraction of the free space the small files may
+	// take from the fill files, on filesystems that store them in blocks
+	smallFileShare = 16
+)
+
+// maxSmallFiles bounds the number of small files created to scrub
+// directory and inode tables
+var maxSmallFiles = 1 << 16
+
+// FreeSpaceResult represents the result of wiping the free space of a
+// filesystem
+type FreeSpaceResult struct {
+	Path    string
+	Success bool
+	Error   error
+	// Bytes is the amount of free space covered by every pass
+	Bytes int64
+	// Files is the number of fill files written
+	Files int
+	// SmallFiles is the number of small files created to scrub directory
+	// and inode tables
+	SmallFiles int
+	// Method is the name of the wipe method applied
+	Method string
+	// Passes is the number of overwrite passes applied to the fill files
+	Passes int
+}
+
+// WipeFreeSpace overwrites the free space of the filesystem holding dir.
+// Many small files are created first to take over unused directory entries
+// and inodes, then fill files are written with the passes of options until
+// the filesystem is full or only reserve bytes are left. Everything is
+// synced and removed afterwards, also when ctx is cancelled or a write
+// fails, so the filesystem is never left full.
+func (s *Shredder) WipeFreeSpace(ctx context.Context, dir string, reserve int64, options WipeOptions) FreeSpaceResult {
+	result := s.wipeFreeSpace(ctx, dir, reserve, options)
+	event := AuditEvent{Operation: AuditWipeFreeSpace, Target: dir, Outcome: outcomeOf(result.Error)}
+	if result.Error != nil {
+		event.Detail = result.Error.Error()
+	} else {
+		event.Detail = fmt.Sprintf("%s, %d passes, %d bytes", result.Method, result.Passes, result.Bytes)
+	}
+	s.audit(options, event)
+	return result
+}
+
+// wipeFreeSpace performs the wipe of WipeFreeSpace
+func (s *Shredder) wipeFreeSpace(ctx context.Context, dir string, reserve int64, options WipeOptions) FreeSpaceResult {
+	method := options.method()
+	result := FreeSpaceResult{Path: dir, Method: method.Name}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if !info.IsDir() {
+		result.Error = fmt.Errorf("%s is not a directory", dir)
+		return result
+	}
+
+	free, err := freeBytes(dir)
+	if err != nil && reserve > 0 {
+		result.Error = fmt.Errorf("cannot keep a reserve of %d bytes: %w", reserve, err)
+		return result
+	}
+
+	if options.DryRun {
+		result.Bytes = max(free-reserve, 0)
+		result.Passes = method.PassCount()
+		result.Success = true
+		s.logger.Info().Str("path", dir).Int64("bytes", result.Bytes).Str("method", method.Name).Msg("would wipe free space (dry run)")
+		return result
+	}
+
+	tmp, err := os.MkdirTemp(dir, ".wipeos-free-")
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); err != nil {
+			s.logger.Error().Err(err).Str("path", tmp).Msg("failed to remove free space fill files")
+			if result.Error == nil {
+				result.Error = err
+				result.Success = false
+			}
+		}
+	}()
+
+	s.logger.Info().Str("path", dir).Int64("free", free).Int64("reserve", reserve).Str("method", method.Name).Msg("wiping free space")
+	options.progress = newProgressEmitter(options.Progress)
+	options.progress.emit(ProgressEvent{Kind: EventJobStarted, Total: max(free-reserve, 0) * int64(method.PassCount()), Passes: method.PassCount()})
+	defer options.progress.emit(ProgressEvent{Kind: EventJobFinished})
+
+	floor := reserve + max(free-reserve, 0)/smallFileShare*(smallFileShare-1)
+	if err := s.createSmallFiles(ctx, filepath.Join(tmp, "small"), floor, &result); err != nil {
+		result.Error = err
+		return result
+	}
+	if err := s.fillFreeSpace(ctx, tmp, reserve, options, &result); err != nil {
+		options.progress.emit(ProgressEvent{Kind: EventError, Path: tmp, Err: err})
+		result.Error = err
+		return result
+	}
+
+	result.Passes = method.PassCount()
+	result.Success = true
+	s.logger.Info().Str("path", dir).Int64("bytes", result.Bytes).Int("files", result.Files).Int("small_files", result.SmallFiles).Msg("free space wiped successfully")
+	return result
+}
+
+// createSmallFiles creates up to maxSmallFiles files with long names and a
+// tiny random payload in dir, stopping when the filesystem runs out of
+// inodes or space or when only floor bytes are left. The filesystem is
+// synced once done, so that their inodes and directory entries reach the
+// disk before they are removed.
+func (s *Shredder) createSmallFiles(ctx context.Context, dir string, floor int64, result *FreeSpaceResult) (err error) {
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return err
+	}
+	defer func() {
+		if syncErr := syncFilesystem(dir); syncErr != nil && err == nil {
+			err = syncErr
+		}
+	}()
+
+	payload := make([]byte, smallFilePayload)
+	padding := strings.Repeat("x", 200)
+	for i := 0; i < maxSmallFiles; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if i%smallFileBatch == 0 {
+			if free, err := freeBytes(dir); err == nil && free <= floor {
+				return nil
+			}
+		}
+
+		if _, err := rand.Read(payload); err != nil {
+			return err
+		}
+		name := filepath.Join(dir, fmt.Sprintf("%08d%s", i, padding))
+		err := writeSmallFile(name, payload)
+		if isNoSpace(err) {
+			os.Remove(name)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result.SmallFiles++
+	}
+	return nil
+}
+
+// writeSmallFile creates name with payload
+func writeSmallFile(name string, payload []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(payload)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// fillFreeSpace writes fill files to dir until the filesystem is full or
+// only reserve bytes are left. Each file gets every pass of the method
+// before the next one is created.
+func (s *Shredder) fillFreeSpace(ctx context.Context, dir string, reserve int64, options WipeOptions, result *FreeSpaceResult) error {
+	buf := buffers.get(options.blockSize())
+	defer buffers.put(buf)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		size := int64(freeSpaceChunk)
+		if free, err := freeBytes(dir); err == nil {
+			size = min(size, free-reserve)
+		}
+		size -= size % blockAlign
+		if size <= 0 {
+			return nil
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("fill-%06d", result.Files))
+		written, full, err := s.fillFile(ctx, path, size, options, *buf)
+		if written > 0 {
+			result.Files++
+			result.Bytes += written
+		}
+		if err != nil || full {
+			return err
+		}
+	}
+}
+
+// fillFile writes a fill file of up to size bytes with every pass of the
+// method. full reports that the filesystem ran out of space during the
+// first pass; the file is then cut back to what was written and the
+// remaining passes cover that part only.
+func (s *Shredder) fillFile(ctx context.Context, path string, size int64, options WipeOptions, buf []byte) (written int64, full bool, err error) {
+	method := options.method()
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if isNoSpace(err) {
+			return 0, true, nil
+		}
+		return 0, false, err
+	}
+	defer file.Close()
+
+	options.progress.emit(ProgressEvent{Kind: EventFileStarted, Path: path, Total: size, Passes: len(method.Passes)})
+	for pass, p := range method.Passes {
+		seed, err := newPassSeed()
+		if err != nil {
+			return written, full, err
+		}
+
+		options.progress.emit(ProgressEvent{Kind: EventPassStarted, Path: path, Pass: pass + 1, Passes: len(method.Passes)})
+		report, flush := options.progress.bytesReporter(path, pass+1, len(method.Passes))
+		reached, err := s.performPass(ctx, file, size, passSpec{
+			pattern: p,
+			seed:    seed,
+			buf:     buf,
+			direct:  options.DirectIO,
+			report:  report,
+		})
+		if err == nil {
+			err = syncData(file)
+		}
+		flush()
+
+		if err != nil && pass == 0 && isNoSpace(err) {
+			// The filesystem is full: keep what made it to disk
+			size = reached - reached%blockAlign
+			full = true
+			if err = file.Truncate(size); err == nil {
+				err = syncData(file)
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				return written, full, fmt.Errorf("interrupted during pass %d of %s: %w", pass+1, filepath.Base(path), err)
+			}
+			return written, full, fmt.Errorf("pass %d (%s) of %s failed: %w", pass+1, p, filepath.Base(path), err)
+		}
+		written = size
+	}
+
+	options.progress.emit(ProgressEvent{Kind: EventFileFinished, Path: path, Passes: len(method.Passes)})
+	return written, full, nil
+}
@@ -0,0 +1,82 @@
+package shredder
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// budgetReserve returns a reserve that leaves about budget bytes of the
+// free space of dir to the wipe
+func budgetReserve(t *testing.T, dir string, budget int64) int64 {
+	free, err := freeBytes(dir)
+	require.NoError(t, err)
+	if free < 2*budget {
+		t.Skip("not enough free space")
+	}
+	return free - budget
+}
+
+func TestShredder_WipeFreeSpace(t *testing.T) {
+	saved := maxSmallFiles
+	maxSmallFiles = 100
+	defer func() { maxSmallFiles = saved }()
+
+	dir := t.TempDir()
+	reserve := budgetReserve(t, dir, 8<<20)
+
+	result := New().WipeFreeSpace(context.Background(), dir, reserve, WipeOptions{Passes: 2})
+	require.NoError(t, result.Error)
+	assert.True(t, result.Success)
+	assert.Equal(t, 2, result.Passes)
+	assert.Equal(t, 100, result.SmallFiles)
+	assert.Positive(t, result.Bytes)
+	assert.LessOrEqual(t, result.Bytes, int64(16<<20))
+	assert.GreaterOrEqual(t, result.Files, 1)
+
+	// Everything written is removed again
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShredder_WipeFreeSpace_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	reserve := budgetReserve(t, dir, 8<<20)
+
+	result := New().WipeFreeSpace(context.Background(), dir, reserve, WipeOptions{Passes: 3, DryRun: true})
+	require.NoError(t, result.Error)
+	assert.Equal(t, 3, result.Passes)
+	assert.Positive(t, result.Bytes)
+	assert.Zero(t, result.Files)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShredder_WipeFreeSpace_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	reserve := budgetReserve(t, dir, 8<<20)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := New().WipeFreeSpace(ctx, dir, reserve, WipeOptions{Passes: 1})
+	assert.ErrorIs(t, result.Error, context.Canceled)
+	assert.False(t, result.Success)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShredder_WipeFreeSpace_NotDirectory(t *testing.T) {
+	file := writeImage(t, 4096)
+
+	result := New().WipeFreeSpace(context.Background(), file, 0, WipeOptions{})
+	assert.Error(t, result.Error)
+}
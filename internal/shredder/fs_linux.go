@@ -0,0 +1,104 @@
+package shredder
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// zfsSuperMagic is not defined by x/sys
+const zfsSuperMagic = 0x2fc12fc1
+
+// filesystems maps statfs magic numbers to the filesystems worth naming
+var filesystems = map[int64]filesystem{
+	unix.EXT4_SUPER_MAGIC:      {name: "ext2/3/4"},
+	unix.XFS_SUPER_MAGIC:       {name: "xfs"},
+	unix.BTRFS_SUPER_MAGIC:     {name: "btrfs", copyOnWrite: true},
+	zfsSuperMagic:              {name: "zfs", copyOnWrite: true},
+	unix.F2FS_SUPER_MAGIC:      {name: "f2fs", copyOnWrite: true},
+	unix.NILFS_SUPER_MAGIC:     {name: "nilfs2", copyOnWrite: true},
+	unix.TMPFS_MAGIC:           {name: "tmpfs"},
+	unix.OVERLAYFS_SUPER_MAGIC: {name: "overlayfs"},
+	unix.NFS_SUPER_MAGIC:       {name: "nfs"},
+	unix.SMB2_SUPER_MAGIC:      {name: "smb"},
+	unix.CIFS_SUPER_MAGIC:      {name: "cifs"},
+	unix.FUSE_SUPER_MAGIC:      {name: "fuse"},
+	unix.MSDOS_SUPER_MAGIC:     {name: "vfat"},
+	unix.EXFAT_SUPER_MAGIC:     {name: "exfat"},
+	unix.ECRYPTFS_SUPER_MAGIC:  {name: "ecryptfs"},
+}
+
+// filesystemOf classifies the filesystem holding an open file
+func filesystemOf(file File) filesystem {
+	f, ok := file.(*os.File)
+	if !ok {
+		return filesystem{name: "unknown filesystem"}
+	}
+	var st unix.Statfs_t
+	if err := unix.Fstatfs(int(f.Fd()), &st); err != nil {
+		return filesystem{name: "unknown filesystem"}
+	}
+	if fs, ok := filesystems[int64(uint32(st.Type))]; ok {
+		return fs
+	}
+	return filesystem{name: fmt.Sprintf("filesystem 0x%x", uint32(st.Type))}
+}
+
+// FIEMAP ioctl interface, see linux/fiemap.h
+const (
+	fsIocFiemap      = 0xC020660B
+	fiemapFlagSync   = 0x00000001
+	fiemapExtentLast = 0x00000001
+	fiemapBatch      = 64
+)
+
+type fiemapExtent struct {
+	Logical    uint64
+	Physical   uint64
+	Length     uint64
+	Reserved64 [2]uint64
+	Flags      uint32
+	Reserved   [3]uint32
+}
+
+type fiemapRequest struct {
+	Start         uint64
+	Length        uint64
+	Flags         uint32
+	MappedExtents uint32
+	ExtentCount   uint32
+	Reserved      uint32
+	Extents       [fiemapBatch]fiemapExtent
+}
+
+// mapPhysical returns the physical extents of the first size bytes of a
+// file with FIEMAP, after syncing its data
+func mapPhysical(file File, size int64) ([]physicalExtent, error) {
+	f, ok := file.(*os.File)
+	if !ok {
+		return nil, errNoDescriptor
+	}
+	var extents []physicalExtent
+	for start := uint64(0); start < uint64(size); {
+		req := fiemapRequest{Start: start, Length: uint64(size) - start, Flags: fiemapFlagSync, ExtentCount: fiemapBatch}
+		if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), fsIocFiemap, uintptr(unsafe.Pointer(&req))); errno != 0 {
+			return nil, errno
+		}
+		if req.MappedExtents == 0 {
+			break
+		}
+
+		mapped := req.Extents[:req.MappedExtents]
+		for _, e := range mapped {
+			extents = append(extents, physicalExtent{logical: e.Logical, physical: e.Physical, length: e.Length, flags: e.Flags})
+		}
+		last := mapped[len(mapped)-1]
+		if last.Flags&fiemapExtentLast != 0 {
+			break
+		}
+		start = last.Logical + last.Length
+	}
+	return extents, nil
+}
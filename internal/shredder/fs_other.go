@@ -0,0 +1,19 @@
+//go:build !linux
+
+package shredder
+
+import (
+	"errors"
+	"runtime"
+)
+
+// filesystemOf names the platform, as filesystems are only classified on
+// Linux
+func filesystemOf(file File) filesystem {
+	return filesystem{name: runtime.GOOS + " filesystem"}
+}
+
+// mapPhysical is only supported on Linux
+func mapPhysical(file File, size int64) ([]physicalExtent, error) {
+	return nil, errors.New("physical extent mapping is only supported on Linux")
+}
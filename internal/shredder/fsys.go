@@ -0,0 +1,108 @@
+package shredder
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FS is the filesystem a Shredder works on. OSFS is the real one; MemFS
+// keeps everything in memory and can inject faults, so that error paths
+// can be tested.
+type FS interface {
+	Stat(path string) (os.FileInfo, error)
+	Lstat(path string) (os.FileInfo, error)
+	ReadDir(path string) ([]os.DirEntry, error)
+	// Walk walks the tree rooted at root like filepath.Walk, without
+	// following symbolic links
+	Walk(root string, fn filepath.WalkFunc) error
+	EvalSymlinks(path string) (string, error)
+	RemoveAll(path string) error
+	// Open opens path, which must lie below root, with flag and without
+	// following symbolic links in any component after root
+	Open(root, path string, flag int) (Entry, error)
+	// OpenNode opens any kind of entry below root for metadata changes
+	// and removal only, without reading or writing it
+	OpenNode(root, path string) (Entry, error)
+}
+
+// Entry is an open file or directory. Metadata changes, renames and the
+// removal fail with ErrReplaced once the name no longer refers to the
+// entry that was opened.
+type Entry interface {
+	// Path is the current path of the entry, for reporting
+	Path() string
+	// Info describes the entry as it was opened
+	Info() os.FileInfo
+	// File is the open file, nil for entries opened with OpenNode on
+	// platforms that do not open them
+	File() File
+	// Exists reports whether name is taken in the parent directory
+	Exists(name string) bool
+	// Truncate cuts a file to zero length through its descriptor
+	Truncate() error
+	// Chtimes sets the access and modification times of the entry
+	Chtimes(t time.Time) error
+	// Rename gives the entry a new name in the same directory without
+	// replacing an existing entry
+	Rename(name string) error
+	// Remove unlinks the entry
+	Remove() error
+	Close() error
+}
+
+// File is the open file of an Entry that passes are written to
+type File interface {
+	io.Writer
+	io.ReaderAt
+	io.Seeker
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+	Sync() error
+}
+
+// errNoDescriptor is returned by operations that need a real file
+// descriptor when given another File
+var errNoDescriptor = errors.New("not an operating system file")
+
+// OSFS is the filesystem of the operating system
+type OSFS struct{}
+
+func (OSFS) Stat(path string) (os.FileInfo, error)  { return os.Stat(path) }
+func (OSFS) Lstat(path string) (os.FileInfo, error) { return os.Lstat(path) }
+
+func (OSFS) ReadDir(path string) ([]os.DirEntry, error) { return os.ReadDir(path) }
+
+func (OSFS) Walk(root string, fn filepath.WalkFunc) error { return filepath.Walk(root, fn) }
+
+func (OSFS) EvalSymlinks(path string) (string, error) { return filepath.EvalSymlinks(path) }
+
+func (OSFS) RemoveAll(path string) error { return os.RemoveAll(path) }
+
+func (OSFS) Open(root, path string, flag int) (Entry, error) {
+	h, err := openHandle(root, path, flag)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (OSFS) OpenNode(root, path string) (Entry, error) {
+	h, err := openNodeHandle(root, path)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// sameFile reports whether two descriptions refer to the same file, for
+// both OSFS and MemFS entries
+func sameFile(a, b os.FileInfo) bool {
+	if na, ok := a.Sys().(*memNode); ok {
+		nb, ok := b.Sys().(*memNode)
+		return ok && na == nb
+	}
+	return os.SameFile(a, b)
+}
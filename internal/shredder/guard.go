@@ -0,0 +1,84 @@
+package shredder
+
+import "os"
+
+// Guard vets the entries of a job before anything is written, such as a
+// safety policy protecting system directories. Entries it refuses are
+// reported with WipeResult.Refused set and the explanation as Error.
+type Guard interface {
+	// Check returns an error explaining why path must be left alone, or
+	// nil
+	Check(path string) error
+	// Limit returns an error explaining why a job wiping files files that
+	// hold bytes bytes must not run, or nil
+	Limit(files int, bytes int64) error
+}
+
+// OpenedGuard is a Guard that also vets every file once it is open, right
+// before it is overwritten, so that a file replaced or modified after
+// planning is still caught
+type OpenedGuard interface {
+	Guard
+	// CheckOpened returns an error explaining why the file opened at path,
+	// described by info as its descriptor reports it, must be left alone,
+	// or nil
+	CheckOpened(path string, info os.FileInfo) error
+}
+
+// guard checks path against the guard of the options, if any
+func (o WipeOptions) guard(path string) error {
+	if o.Guard == nil {
+		return nil
+	}
+	return o.Guard.Check(path)
+}
+
+// checkOpened checks an opened file against the guard of the options, if
+// it is an OpenedGuard
+func (o WipeOptions) checkOpened(path string, info os.FileInfo) error {
+	g, ok := o.Guard.(OpenedGuard)
+	if !ok {
+		return nil
+	}
+	return g.CheckOpened(path, info)
+}
+
+// limitPlan checks the size of a planned job against the guard of the
+// options. A job over the limits is refused as a whole: every pending
+// file is refused with the explanation and no directory is torn down.
+func (s *Shredder) limitPlan(plan []planEntry, options WipeOptions) []planEntry {
+	if options.Guard == nil {
+		return plan
+	}
+
+	var files int
+	var bytes int64
+	for _, entry := range plan {
+		if !entry.wipe {
+			continue
+		}
+		files++
+		if info, err := s.fs.Lstat(entry.path); err == nil {
+			bytes += info.Size()
+		}
+	}
+
+	err := options.Guard.Limit(files, bytes)
+	if err == nil {
+		return plan
+	}
+	s.logger.Warn().Err(err).Int("files", files).Int64("bytes", bytes).Msg("job refused by safety limits")
+
+	var limited []planEntry
+	for _, entry := range plan {
+		switch {
+		case entry.tree != nil:
+			continue
+		case entry.wipe:
+			limited = append(limited, refusedEntry(entry.path, err))
+		default:
+			limited = append(limited, entry)
+		}
+	}
+	return limited
+}
@@ -0,0 +1,98 @@
+package shredder
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTestProtected = errors.New("protected for the test")
+	errTestLimit     = errors.New("too many files for the test")
+)
+
+// testGuard protects the paths in protected and applies maxFiles
+type testGuard struct {
+	protected map[string]bool
+	maxFiles  int
+}
+
+func (g testGuard) Check(path string) error {
+	if g.protected[path] {
+		return errTestProtected
+	}
+	return nil
+}
+
+func (g testGuard) Limit(files int, bytes int64) error {
+	if g.maxFiles > 0 && files > g.maxFiles {
+		return errTestLimit
+	}
+	return nil
+}
+
+func TestShredder_GuardRefusesProtectedEntries(t *testing.T) {
+	mem := NewMemFS()
+	dir := filepath.Join(string(filepath.Separator), "data")
+	wiped := filepath.Join(dir, "wiped.txt")
+	protectedFile := filepath.Join(dir, "keep.txt")
+	protectedDir := filepath.Join(dir, "system")
+	inside := filepath.Join(protectedDir, "config")
+	for _, path := range []string{wiped, protectedFile, inside} {
+		require.NoError(t, mem.WriteFile(path, []byte("data")))
+	}
+
+	guard := testGuard{protected: map[string]bool{protectedFile: true, protectedDir: true}}
+	results := NewWithFS(mem).WipeFiles(context.Background(), []string{dir}, WipeOptions{Passes: 1, Recursive: true, Guard: guard})
+
+	byPath := map[string]WipeResult{}
+	for _, result := range results {
+		byPath[result.Path] = result
+	}
+	assert.True(t, byPath[wiped].Success, "%v", byPath[wiped].Error)
+	for _, path := range []string{protectedFile, protectedDir} {
+		assert.True(t, byPath[path].Refused, path)
+		assert.ErrorIs(t, byPath[path].Error, errTestProtected)
+	}
+	assert.NotContains(t, byPath, inside)
+	assert.True(t, byPath[dir].Kept)
+
+	assert.False(t, mem.Exists(wiped))
+	assert.True(t, mem.Exists(protectedFile))
+	assert.True(t, mem.Exists(inside))
+}
+
+func TestShredder_GuardRefusesTarget(t *testing.T) {
+	mem, path, content := memFixture(t)
+
+	guard := testGuard{protected: map[string]bool{path: true}}
+	results := NewWithFS(mem).WipeFiles(context.Background(), []string{path}, WipeOptions{Passes: 1, Guard: guard})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Refused)
+	data, err := mem.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
+func TestShredder_GuardLimitRefusesJob(t *testing.T) {
+	mem := NewMemFS()
+	dir := filepath.Join(string(filepath.Separator), "data")
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, mem.WriteFile(filepath.Join(dir, name), []byte("data")))
+	}
+
+	results := NewWithFS(mem).WipeFiles(context.Background(), []string{dir}, WipeOptions{Passes: 1, Recursive: true, Guard: testGuard{maxFiles: 2}})
+
+	require.Len(t, results, 3)
+	for _, result := range results {
+		assert.True(t, result.Refused, result.Path)
+		assert.ErrorIs(t, result.Error, errTestLimit)
+		assert.True(t, mem.Exists(result.Path))
+	}
+	assert.True(t, mem.Exists(dir))
+}
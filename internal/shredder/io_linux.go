@@ -0,0 +1,182 @@
+package shredder
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// setDirect enables or disables O_DIRECT on an open file, bypassing the
+// page cache so that writes go straight to the device. Filesystems without
+// direct I/O support, such as tmpfs, return an error.
+func setDirect(file File, on bool) error {
+	f, ok := file.(*os.File)
+	if !ok {
+		return errNoDescriptor
+	}
+	fd := int(f.Fd())
+	flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFL, 0)
+	if err != nil {
+		return err
+	}
+	if on {
+		flags |= unix.O_DIRECT
+	} else {
+		flags &^= unix.O_DIRECT
+	}
+	_, err = unix.FcntlInt(uintptr(fd), unix.F_SETFL, flags)
+	return err
+}
+
+// syncData flushes the written data of a file to the device. Passes never
+// change the size of a file, so fdatasync skips the needless metadata
+// flush of fsync.
+func syncData(file File) error {
+	f, ok := file.(*os.File)
+	if !ok {
+		return file.Sync()
+	}
+	return unix.Fdatasync(int(f.Fd()))
+}
+
+// syncFilesystem flushes the data and metadata of every file on the
+// filesystem holding dir with a single syncfs, rather than one fsync per
+// file
+func syncFilesystem(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return unix.Syncfs(int(d.Fd()))
+}
+
+// dropCache evicts the cached pages of extents, so that reading them back
+// fetches what reached the device rather than what was just written. The
+// pages must be clean, as they are after syncData.
+func dropCache(file File, extents []extent) error {
+	f, ok := file.(*os.File)
+	if !ok {
+		return nil
+	}
+	fd := int(f.Fd())
+	for _, e := range extents {
+		if err := unix.Fadvise(fd, e.offset, e.length, unix.FADV_DONTNEED); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dataExtents maps the allocated ranges of the first size bytes of a file
+// with SEEK_DATA and SEEK_HOLE
+func dataExtents(file File, size int64) ([]extent, error) {
+	f, ok := file.(*os.File)
+	if !ok {
+		return nil, errNoDescriptor
+	}
+	fd := int(f.Fd())
+	var extents []extent
+	for offset := int64(0); offset < size; {
+		data, err := unix.Seek(fd, offset, unix.SEEK_DATA)
+		if err == unix.ENXIO {
+			// No data past offset
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if data >= size {
+			break
+		}
+
+		hole, err := unix.Seek(fd, data, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		if hole > size {
+			hole = size
+		}
+		extents = append(extents, extent{offset: data, length: hole - data})
+		offset = hole
+	}
+	return extents, nil
+}
+
+// allocatedSize estimates the bytes of data allocated to an entry described
+// by Lstat, which is less than its size for sparse files
+func allocatedSize(info os.FileInfo) int64 {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+	if allocated := int64(st.Blocks) * 512; allocated < info.Size() {
+		return allocated
+	}
+	return info.Size()
+}
+
+// fsBlockSize returns the filesystem block size of an entry described by
+// stat, or 0 when it is unknown
+func fsBlockSize(info os.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blksize)
+	}
+	return 0
+}
+
+// punchHole deallocates the first size bytes of a file, which issues
+// discards for the freed blocks on filesystems mounted with discard
+func punchHole(file File, size int64) error {
+	f, ok := file.(*os.File)
+	if !ok {
+		return errNoDescriptor
+	}
+	if size == 0 {
+		return nil
+	}
+	return unix.Fallocate(int(f.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, 0, size)
+}
+
+// fitrim is _IOWR('X', 121, struct fstrim_range)
+const fitrim = 0xC0185879
+
+type fstrimRange struct {
+	Start  uint64
+	Len    uint64
+	Minlen uint64
+}
+
+// trimFilesystem discards every unused block of the filesystem holding
+// dir. It usually requires CAP_SYS_ADMIN.
+func trimFilesystem(dir string) error {
+	fd, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: dir, Err: err}
+	}
+	defer unix.Close(fd)
+
+	r := fstrimRange{Len: ^uint64(0)}
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), fitrim, uintptr(unsafe.Pointer(&r))); errno != 0 {
+		return &os.PathError{Op: "fitrim", Path: dir, Err: errno}
+	}
+	return nil
+}
+
+// freeBytes returns the space available to unprivileged users on the
+// filesystem holding dir
+func freeBytes(dir string) (int64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+// isNoSpace reports whether err means the filesystem or quota is full
+func isNoSpace(err error) bool {
+	return errors.Is(err, unix.ENOSPC) || errors.Is(err, unix.EDQUOT) || errors.Is(err, unix.EFBIG)
+}
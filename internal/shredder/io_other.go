@@ -0,0 +1,75 @@
+//go:build !linux
+
+package shredder
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/joao-rrondon/wipeOs/internal/fsutil"
+)
+
+// setDirect is only supported on Linux
+func setDirect(file File, on bool) error {
+	if on {
+		return errors.New("direct I/O is only supported on Linux")
+	}
+	return nil
+}
+
+// syncData flushes the written data of a file to the device
+func syncData(file File) error {
+	return file.Sync()
+}
+
+// syncFilesystem only syncs the entries of dir, as syncfs is only
+// available on Linux
+func syncFilesystem(dir string) error {
+	return fsutil.SyncDir(dir)
+}
+
+// dropCache is only supported on Linux, so elsewhere verification may read
+// back cached pages
+func dropCache(file File, extents []extent) error {
+	return nil
+}
+
+// dataExtents treats the whole file as allocated, as holes are only
+// detected on Linux
+func dataExtents(file File, size int64) ([]extent, error) {
+	return wholeFile(size), nil
+}
+
+// allocatedSize returns the size of the entry, as holes are only detected
+// on Linux
+func allocatedSize(info os.FileInfo) int64 {
+	return info.Size()
+}
+
+// fsBlockSize is only detected on Linux, so slack is not overwritten
+// elsewhere
+func fsBlockSize(info os.FileInfo) int64 {
+	return 0
+}
+
+// punchHole is only supported on Linux
+func punchHole(file File, size int64) error {
+	return errors.New("hole punching is only supported on Linux")
+}
+
+// trimFilesystem is only supported on Linux
+func trimFilesystem(dir string) error {
+	return errors.New("FITRIM is only supported on Linux")
+}
+
+// freeBytes is only reported on Linux
+func freeBytes(dir string) (int64, error) {
+	return 0, errors.New("free space is only reported on Linux")
+}
+
+// isNoSpace reports whether err means the disk is full. Windows reports
+// ERROR_HANDLE_DISK_FULL (39) and ERROR_DISK_FULL (112).
+func isNoSpace(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.Errno(39)) || errors.Is(err, syscall.Errno(112))
+}
@@ -0,0 +1,38 @@
+package shredder
+
+// Checkpoint records how far the overwrite of a file has progressed
+type Checkpoint struct {
+	// Size is the file size when the checkpoint was taken. A checkpoint is
+	// ignored when the file size has changed since.
+	Size int64
+	// Pass is the zero-based index of the pass in progress. It equals the
+	// number of passes once the overwrite is done.
+	Pass int
+	// Offset is the number of bytes of the pass already written and synced
+	Offset int64
+	// Seed keys the random generator of the pass in progress, or of the
+	// last pass once every pass is done
+	Seed []byte
+	// Scrubbed is the name the file was renamed to while being scrubbed
+	// before removal
+	Scrubbed string
+}
+
+// Journal persists the progress of a wipe job so that an interrupted job
+// can be resumed. Implementations must be safe for concurrent use.
+type Journal interface {
+	// Begin is called once per WipeFiles call with its targets
+	Begin(targets []string, options WipeOptions) error
+	// Checkpoint returns where a previous run stopped on path
+	Checkpoint(path string) (Checkpoint, bool)
+	// Record saves the progress made on path
+	Record(path string, cp Checkpoint) error
+	// Finish is called when processing of path ends. err is nil on
+	// success and matches context.Canceled when the job was interrupted
+	// before the file was done.
+	Finish(path string, err error) error
+}
+
+// checkpointInterval is the number of bytes written between two syncs
+// recorded in the journal during a pass
+const checkpointInterval = 64 << 20
@@ -0,0 +1,128 @@
+package shredder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Media describes the block device stack behind a file, as far as it can
+// be detected
+type Media struct {
+	// Known is false when the backing device could not be identified, as
+	// for tmpfs, network filesystems or platforms without sysfs
+	Known bool
+	// Device is the kernel name of the device the filesystem sits on,
+	// such as sda1, nvme0n1p2 or dm-0
+	Device string
+	// Disks lists the physical disks at the bottom of the stack
+	Disks []string
+	// Rotational is set when any of the disks is a spinning disk
+	Rotational bool
+	// Discard is set when the device accepts discard requests
+	Discard bool
+	// Encrypted is set when the data passes through a dm-crypt mapping,
+	// named by Mapping
+	Encrypted bool
+	Mapping   string
+}
+
+// Kind returns "hdd", "ssd" or "unknown"
+func (m Media) Kind() string {
+	switch {
+	case !m.Known:
+		return "unknown"
+	case m.Rotational:
+		return "hdd"
+	default:
+		return "ssd"
+	}
+}
+
+// String describes the device for reports
+func (m Media) String() string {
+	if !m.Known {
+		return "unknown device"
+	}
+
+	desc := fmt.Sprintf("%s (%s", m.Device, m.Kind())
+	if len(m.Disks) > 0 && !(len(m.Disks) == 1 && m.Disks[0] == m.Device) {
+		desc += " on " + strings.Join(m.Disks, ", ")
+	}
+	if m.Encrypted {
+		desc += ", dm-crypt " + m.Mapping
+	}
+	return desc + ")"
+}
+
+// AutoPolicy is the strategy picked for a device when the method is
+// selected automatically
+type AutoPolicy struct {
+	Media   Media
+	Method  WipeMethod
+	Discard DiscardMode
+	// Warning is set when overwriting is not the real remedy for the
+	// medium, such as data on an encrypted mapping
+	Warning string
+}
+
+// String summarizes the policy, e.g. "sda (hdd): dod, 3 passes"
+func (p AutoPolicy) String() string {
+	desc := fmt.Sprintf("%s: %s, %d pass", p.Media, p.Method.Name, p.Method.PassCount())
+	if p.Method.PassCount() != 1 {
+		desc += "es"
+	}
+	if p.Discard != DiscardNone {
+		desc += " + discard"
+	}
+	return desc
+}
+
+// singleRandom is the single pass used on flash, where extra passes only
+// wear the cells and cannot reach remapped blocks anyway
+var singleRandom = WipeMethod{
+	Name:        "random",
+	Description: "Single pass of random data",
+	Passes:      []Pattern{random()},
+}
+
+// choosePolicy picks the method and discard mode for a medium. Spinning
+// disks get the 3-pass DoD scheme, flash a single random pass followed by
+// a discard where the device supports it. Unknown media keep the method of
+// the options. An explicit discard mode in the options is never
+// overridden.
+func choosePolicy(media Media, options WipeOptions) AutoPolicy {
+	policy := AutoPolicy{Media: media, Method: options.method(), Discard: options.Discard}
+
+	switch {
+	case !media.Known:
+	case media.Rotational:
+		policy.Method = builtinMethods["dod"]
+	default:
+		policy.Method = singleRandom
+		if policy.Discard == DiscardNone && media.Discard {
+			policy.Discard = DiscardAfter
+		}
+	}
+
+	if media.Encrypted {
+		policy.Warning = fmt.Sprintf("data sits on the encrypted mapping %s; destroying its key removes it for good, overwriting files may not", media.Mapping)
+	}
+	return policy
+}
+
+// apply returns the options to use for files under the policy
+func (p AutoPolicy) apply(options WipeOptions) WipeOptions {
+	options.Method = p.Method
+	options.Passes = p.Method.PassCount()
+	options.Discard = p.Discard
+	options.Auto = false
+	options.policy = &p
+	return options
+}
+
+// autoPolicy detects the media behind path and picks its policy
+func (s *Shredder) autoPolicy(path string, options WipeOptions) AutoPolicy {
+	policy := choosePolicy(DetectMedia(path), options)
+	s.logger.Debug().Str("file", path).Str("policy", policy.String()).Msg("selected wipe policy")
+	return policy
+}
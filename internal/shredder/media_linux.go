@@ -0,0 +1,96 @@
+package shredder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysfsRoot is where the kernel exposes block devices
+const sysfsRoot = "/sys"
+
+// maxStackDepth bounds the walk down device-mapper stacks
+const maxStackDepth = 8
+
+// DetectMedia identifies the block device stack holding path through
+// /sys/dev/block
+func DetectMedia(path string) Media {
+	dev := deviceOf(path)
+	if dev == 0 {
+		return Media{}
+	}
+	return deviceMedia(sysfsRoot, dev)
+}
+
+// deviceMedia describes the device dev as seen under the sysfs tree at
+// root. Device-mapper devices, including dm-crypt and LVM, are followed
+// through their slaves down to the physical disks.
+func deviceMedia(root string, dev uint64) Media {
+	link := filepath.Join(root, "dev", "block", fmt.Sprintf("%d:%d", unix.Major(dev), unix.Minor(dev)))
+	dir, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return Media{}
+	}
+
+	media := Media{Known: true, Device: filepath.Base(dir)}
+	discard, err := strconv.ParseUint(readSysfs(filepath.Join(diskDir(dir), "queue", "discard_max_bytes")), 10, 64)
+	media.Discard = err == nil && discard > 0
+	walkStack(dir, &media, 0)
+	return media
+}
+
+// walkStack records the mappings and disks below the device at dir
+func walkStack(dir string, media *Media, depth int) {
+	if depth > maxStackDepth {
+		return
+	}
+
+	// dm-crypt mappings carry a CRYPT-LUKS2-... or CRYPT-PLAIN-... uuid
+	if strings.HasPrefix(readSysfs(filepath.Join(dir, "dm", "uuid")), "CRYPT-") {
+		media.Encrypted = true
+		if media.Mapping == "" {
+			media.Mapping = readSysfs(filepath.Join(dir, "dm", "name"))
+		}
+	}
+
+	slaves, err := os.ReadDir(filepath.Join(dir, "slaves"))
+	if err != nil || len(slaves) == 0 {
+		disk := diskDir(dir)
+		media.Disks = append(media.Disks, filepath.Base(disk))
+		if readSysfs(filepath.Join(disk, "queue", "rotational")) == "1" {
+			media.Rotational = true
+		}
+		return
+	}
+
+	for _, slave := range slaves {
+		resolved, err := filepath.EvalSymlinks(filepath.Join(dir, "slaves", slave.Name()))
+		if err != nil {
+			continue
+		}
+		walkStack(resolved, media, depth+1)
+	}
+}
+
+// diskDir returns the whole disk of a partition, whose queue attributes
+// live on the parent, or dir itself
+func diskDir(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "partition")); err == nil {
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+// readSysfs returns the trimmed contents of a sysfs attribute, or "" when
+// it cannot be read
+func readSysfs(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,62 @@
+package shredder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// fakeSysfs builds a sysfs tree with a partitioned spinning disk sda, an
+// NVMe disk and a dm-crypt mapping dm-0 on top of sda2
+func fakeSysfs(t *testing.T) string {
+	root := t.TempDir()
+	attr := func(path, value string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), []byte(value+"\n"), 0644))
+	}
+	link := func(target, name string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755))
+		require.NoError(t, os.Symlink(filepath.Join(root, target), filepath.Join(root, name)))
+	}
+
+	attr("devices/pci/block/sda/queue/rotational", "1")
+	attr("devices/pci/block/sda/queue/discard_max_bytes", "0")
+	attr("devices/pci/block/sda/sda2/partition", "2")
+	attr("devices/pci/block/nvme0n1/queue/rotational", "0")
+	attr("devices/pci/block/nvme0n1/queue/discard_max_bytes", "2199023255040")
+	attr("devices/virtual/block/dm-0/queue/rotational", "0")
+	attr("devices/virtual/block/dm-0/queue/discard_max_bytes", "0")
+	attr("devices/virtual/block/dm-0/dm/uuid", "CRYPT-LUKS2-0123456789abcdef-cryptroot")
+	attr("devices/virtual/block/dm-0/dm/name", "cryptroot")
+	link("devices/pci/block/sda/sda2", "devices/virtual/block/dm-0/slaves/sda2")
+
+	link("devices/pci/block/sda/sda2", "dev/block/8:2")
+	link("devices/pci/block/nvme0n1", "dev/block/259:0")
+	link("devices/virtual/block/dm-0", "dev/block/253:0")
+	return root
+}
+
+func TestDeviceMedia(t *testing.T) {
+	root := fakeSysfs(t)
+
+	tests := []struct {
+		name  string
+		dev   uint64
+		media Media
+	}{
+		{"partition", unix.Mkdev(8, 2), Media{Known: true, Device: "sda2", Disks: []string{"sda"}, Rotational: true}},
+		{"nvme", unix.Mkdev(259, 0), Media{Known: true, Device: "nvme0n1", Disks: []string{"nvme0n1"}, Discard: true}},
+		{"dm-crypt", unix.Mkdev(253, 0), Media{Known: true, Device: "dm-0", Disks: []string{"sda"}, Rotational: true, Encrypted: true, Mapping: "cryptroot"}},
+		{"missing", unix.Mkdev(7, 0), Media{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.media, deviceMedia(root, tt.dev))
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package shredder
+
+// DetectMedia is only implemented on Linux; the medium is always unknown
+// elsewhere
+func DetectMedia(path string) Media {
+	return Media{}
+}
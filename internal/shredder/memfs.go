@@ -0,0 +1,589 @@
+package shredder
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FaultOp is the operation a Fault applies to
+type FaultOp int
+
+const (
+	// FaultOpen fails opening a file or directory
+	FaultOpen FaultOp = iota
+	// FaultWrite fails a write, after letting Fault.Bytes bytes through
+	FaultWrite
+	// FaultSync fails flushing a file
+	FaultSync
+	// FaultRename fails renaming an entry
+	FaultRename
+	// FaultRemove fails removing an entry
+	FaultRemove
+	// FaultReadDir fails listing a directory
+	FaultReadDir
+	// FaultResize changes the size of a file to Fault.Bytes, as if another
+	// process wrote to it, without failing the write
+	FaultResize
+)
+
+// Fault makes an operation of a MemFS fail or misbehave
+type Fault struct {
+	Op FaultOp
+	// Path selects the entry, matched against the path it was opened with
+	// before any rename. An empty path matches every entry.
+	Path string
+	// Pass restricts FaultWrite and FaultResize to the writes of one pass,
+	// counted from 1. A pass starts with a write at offset 0. Zero matches
+	// every pass.
+	Pass int
+	// Err is the error returned, syscall.EIO when unset
+	Err error
+	// Bytes is the number of bytes a failing write lets through, making it
+	// a short write, or the new size for FaultResize
+	Bytes int64
+}
+
+// ShortWrite returns a fault that cuts the first write of a pass on path
+// after n bytes
+func ShortWrite(path string, pass int, n int64) Fault {
+	return Fault{Op: FaultWrite, Path: path, Pass: pass, Bytes: n, Err: io.ErrShortWrite}
+}
+
+// FailWrite returns a fault that fails the writes of a pass on path with
+// err, such as syscall.EIO or syscall.ENOSPC
+func FailWrite(path string, pass int, err error) Fault {
+	return Fault{Op: FaultWrite, Path: path, Pass: pass, Err: err}
+}
+
+// Resize returns a fault that changes the size of path to size when a
+// pass starts writing to it
+func Resize(path string, pass int, size int64) Fault {
+	return Fault{Op: FaultResize, Path: path, Pass: pass, Bytes: size}
+}
+
+// MemFS is an in-memory FS for tests. Paths are absolute and symbolic
+// links are only resolved in the last component. Faults added with Inject
+// make operations on matching entries fail or misbehave.
+type MemFS struct {
+	mu     sync.Mutex
+	nodes  map[string]*memNode
+	faults []Fault
+}
+
+// memNode is a file, directory or symbolic link of a MemFS
+type memNode struct {
+	mode    os.FileMode
+	data    []byte
+	target  string
+	modTime time.Time
+	// passes counts the writes at offset 0 made to the file
+	passes int
+}
+
+// NewMemFS returns an empty in-memory filesystem
+func NewMemFS() *MemFS {
+	root := filepath.Clean(string(filepath.Separator))
+	return &MemFS{nodes: map[string]*memNode{root: {mode: os.ModeDir | 0755, modTime: time.Now()}}}
+}
+
+// Inject adds faults to the filesystem
+func (m *MemFS) Inject(faults ...Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = append(m.faults, faults...)
+}
+
+// MkdirAll creates a directory and any missing parents
+func (m *MemFS) MkdirAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mkdirAll(filepath.Clean(path))
+}
+
+func (m *MemFS) mkdirAll(path string) error {
+	if node, ok := m.nodes[path]; ok {
+		if !node.mode.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+		}
+		return nil
+	}
+	if err := m.mkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	m.nodes[path] = &memNode{mode: os.ModeDir | 0755, modTime: time.Now()}
+	return nil
+}
+
+// WriteFile creates or replaces a regular file, creating missing parent
+// directories
+func (m *MemFS) WriteFile(path string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	if err := m.mkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	m.nodes[path] = &memNode{mode: 0644, data: append([]byte(nil), data...), modTime: time.Now()}
+	return nil
+}
+
+// Symlink creates a symbolic link to target
+func (m *MemFS) Symlink(target, link string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	link = filepath.Clean(link)
+	if err := m.mkdirAll(filepath.Dir(link)); err != nil {
+		return err
+	}
+	m.nodes[link] = &memNode{mode: os.ModeSymlink | 0777, target: target, modTime: time.Now()}
+	return nil
+}
+
+// ReadFile returns the contents of a regular file
+func (m *MemFS) ReadFile(path string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	node, err := m.lookup("open", filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+// Exists reports whether an entry exists at path
+func (m *MemFS) Exists(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.nodes[filepath.Clean(path)]
+	return ok
+}
+
+func (m *MemFS) Stat(path string) (os.FileInfo, error) {
+	resolved, err := m.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	return m.Lstat(resolved)
+}
+
+func (m *MemFS) Lstat(path string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	node, err := m.lookup("lstat", path)
+	if err != nil {
+		return nil, err
+	}
+	return node.info(path), nil
+}
+
+func (m *MemFS) ReadDir(path string) ([]os.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	if f := m.fault(FaultReadDir, path, 0); f != nil {
+		return nil, &os.PathError{Op: "readdirent", Path: path, Err: f.err()}
+	}
+	node, err := m.lookup("open", path)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdirent", Path: path, Err: syscall.ENOTDIR}
+	}
+
+	var entries []os.DirEntry
+	for _, child := range m.children(path) {
+		entries = append(entries, fs.FileInfoToDirEntry(m.nodes[child].info(child)))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *MemFS) Walk(root string, fn filepath.WalkFunc) error {
+	info, err := m.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = m.walk(root, info, fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walk descends into path the way filepath.Walk does
+func (m *MemFS) walk(path string, info os.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	entries, err := m.ReadDir(path)
+	if err1 := fn(path, info, err); err != nil || err1 != nil {
+		return err1
+	}
+	for _, entry := range entries {
+		name := filepath.Join(path, entry.Name())
+		child, err := m.Lstat(name)
+		if err != nil {
+			if err := fn(name, child, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		if err := m.walk(name, child, fn); err != nil && (!child.IsDir() || err != filepath.SkipDir) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemFS) EvalSymlinks(path string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	for i := 0; i < 8; i++ {
+		node, err := m.lookup("lstat", path)
+		if err != nil {
+			return "", err
+		}
+		if node.mode&os.ModeSymlink == 0 {
+			return path, nil
+		}
+		target := node.target
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = filepath.Clean(target)
+	}
+	return "", &os.PathError{Op: "lstat", Path: path, Err: syscall.ELOOP}
+}
+
+func (m *MemFS) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	if _, ok := m.nodes[path]; !ok {
+		return nil
+	}
+	if f := m.fault(FaultRemove, path, 0); f != nil {
+		return &os.PathError{Op: "unlinkat", Path: path, Err: f.err()}
+	}
+	prefix := path + string(filepath.Separator)
+	for name := range m.nodes {
+		if name == path || strings.HasPrefix(name, prefix) {
+			delete(m.nodes, name)
+		}
+	}
+	return nil
+}
+
+func (m *MemFS) Open(root, path string, flag int) (Entry, error) {
+	entry, err := m.open(path)
+	if err != nil {
+		return nil, err
+	}
+	entry.file = &memFile{fs: m, entry: entry, write: flag&(os.O_WRONLY|os.O_RDWR) != 0, read: flag&os.O_WRONLY == 0}
+	return entry, nil
+}
+
+func (m *MemFS) OpenNode(root, path string) (Entry, error) {
+	return m.open(path)
+}
+
+// open looks up an entry to open without following a symbolic link
+func (m *MemFS) open(path string) (*memEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path = filepath.Clean(path)
+	if f := m.fault(FaultOpen, path, 0); f != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: f.err()}
+	}
+	node, err := m.lookup("open", path)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode&os.ModeSymlink != 0 {
+		return nil, &os.PathError{Op: "open", Path: path, Err: ErrSymlink}
+	}
+	return &memEntry{fs: m, node: node, path: path, origin: path, opened: node.info(path)}, nil
+}
+
+// lookup returns the node at a clean path
+func (m *MemFS) lookup(op, path string) (*memNode, error) {
+	node, ok := m.nodes[path]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: path, Err: syscall.ENOENT}
+	}
+	return node, nil
+}
+
+// children lists the paths of the entries directly below dir
+func (m *MemFS) children(dir string) []string {
+	var names []string
+	for name := range m.nodes {
+		if name != dir && filepath.Dir(name) == dir {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// fault returns the first fault matching an operation on path during a
+// pass, or nil
+func (m *MemFS) fault(op FaultOp, path string, pass int) *Fault {
+	for i := range m.faults {
+		f := &m.faults[i]
+		if f.Op == op && (f.Path == "" || filepath.Clean(f.Path) == path) && (f.Pass == 0 || f.Pass == pass) {
+			return f
+		}
+	}
+	return nil
+}
+
+// err returns the error of the fault
+func (f *Fault) err() error {
+	if f.Err == nil {
+		return syscall.EIO
+	}
+	return f.Err
+}
+
+// info describes the node found at path
+func (n *memNode) info(path string) os.FileInfo {
+	return &memInfo{name: filepath.Base(path), size: int64(len(n.data)), mode: n.mode, modTime: n.modTime, node: n}
+}
+
+// memInfo describes a MemFS entry. Sys returns its node, which identifies
+// the entry for sameFile.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	node    *memNode
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return i.size }
+func (i *memInfo) Mode() os.FileMode  { return i.mode }
+func (i *memInfo) ModTime() time.Time { return i.modTime }
+func (i *memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memInfo) Sys() any           { return i.node }
+
+// memEntry is an open MemFS entry
+type memEntry struct {
+	fs   *MemFS
+	node *memNode
+	// path is the current path, origin the path the entry was opened
+	// with, which faults are matched against
+	path   string
+	origin string
+	opened os.FileInfo
+	file   *memFile
+}
+
+func (e *memEntry) Path() string      { return e.path }
+func (e *memEntry) Info() os.FileInfo { return e.opened }
+
+func (e *memEntry) File() File {
+	if e.file == nil {
+		return nil
+	}
+	return e.file
+}
+
+// check verifies that the name still refers to the opened node. The lock
+// must be held.
+func (e *memEntry) check() error {
+	node, err := e.fs.lookup("lstat", e.path)
+	if err != nil {
+		return err
+	}
+	if node != e.node {
+		return &os.PathError{Op: "check", Path: e.path, Err: ErrReplaced}
+	}
+	return nil
+}
+
+func (e *memEntry) Exists(name string) bool {
+	return e.fs.Exists(filepath.Join(filepath.Dir(e.path), name))
+}
+
+func (e *memEntry) Truncate() error {
+	if e.file == nil {
+		return os.ErrInvalid
+	}
+	return e.file.Truncate(0)
+}
+
+func (e *memEntry) Chtimes(t time.Time) error {
+	e.fs.mu.Lock()
+	defer e.fs.mu.Unlock()
+	if err := e.check(); err != nil {
+		return err
+	}
+	e.node.modTime = t
+	return nil
+}
+
+func (e *memEntry) Rename(name string) error {
+	e.fs.mu.Lock()
+	defer e.fs.mu.Unlock()
+	if err := e.check(); err != nil {
+		return err
+	}
+	newPath := filepath.Join(filepath.Dir(e.path), name)
+	if f := e.fs.fault(FaultRename, e.origin, 0); f != nil {
+		return &os.LinkError{Op: "rename", Old: e.path, New: newPath, Err: f.err()}
+	}
+	if _, ok := e.fs.nodes[newPath]; ok {
+		return &os.LinkError{Op: "rename", Old: e.path, New: newPath, Err: syscall.EEXIST}
+	}
+
+	prefix := e.path + string(filepath.Separator)
+	for name, node := range e.fs.nodes {
+		if strings.HasPrefix(name, prefix) {
+			delete(e.fs.nodes, name)
+			e.fs.nodes[newPath+string(filepath.Separator)+strings.TrimPrefix(name, prefix)] = node
+		}
+	}
+	delete(e.fs.nodes, e.path)
+	e.fs.nodes[newPath] = e.node
+	e.path = newPath
+	return nil
+}
+
+func (e *memEntry) Remove() error {
+	e.fs.mu.Lock()
+	defer e.fs.mu.Unlock()
+	if err := e.check(); err != nil {
+		return err
+	}
+	if f := e.fs.fault(FaultRemove, e.origin, 0); f != nil {
+		return &os.PathError{Op: "remove", Path: e.path, Err: f.err()}
+	}
+	if e.node.mode.IsDir() && len(e.fs.children(e.path)) > 0 {
+		return &os.PathError{Op: "remove", Path: e.path, Err: syscall.ENOTEMPTY}
+	}
+	delete(e.fs.nodes, e.path)
+	return nil
+}
+
+func (e *memEntry) Close() error {
+	return nil
+}
+
+// memFile is the open file of a memEntry
+type memFile struct {
+	fs     *MemFS
+	entry  *memEntry
+	offset int64
+	read   bool
+	write  bool
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	node := f.entry.node
+	if !f.write {
+		return 0, &os.PathError{Op: "write", Path: f.entry.path, Err: syscall.EBADF}
+	}
+
+	if f.offset == 0 {
+		node.passes++
+		if fault := f.fs.fault(FaultResize, f.entry.origin, node.passes); fault != nil {
+			node.resize(fault.Bytes)
+		}
+	}
+
+	n := int64(len(p))
+	var err error
+	if fault := f.fs.fault(FaultWrite, f.entry.origin, node.passes); fault != nil {
+		n = min(n, fault.Bytes)
+		err = &os.PathError{Op: "write", Path: f.entry.path, Err: fault.err()}
+	}
+
+	if end := f.offset + n; end > int64(len(node.data)) {
+		node.resize(end)
+	}
+	copy(node.data[f.offset:], p[:n])
+	f.offset += n
+	return int(n), err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if !f.read {
+		return 0, &os.PathError{Op: "read", Path: f.entry.path, Err: syscall.EBADF}
+	}
+	data := f.entry.node.data
+	if off >= int64(len(data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.entry.node.data))
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.entry.path, Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return f.entry.node.info(f.entry.path), nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if !f.write {
+		return &os.PathError{Op: "truncate", Path: f.entry.path, Err: syscall.EBADF}
+	}
+	f.entry.node.resize(size)
+	return nil
+}
+
+func (f *memFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if fault := f.fs.fault(FaultSync, f.entry.origin, 0); fault != nil {
+		return &os.PathError{Op: "sync", Path: f.entry.path, Err: fault.err()}
+	}
+	return nil
+}
+
+// resize grows the data of a node with zeros or cuts it to size
+func (n *memNode) resize(size int64) {
+	if size <= int64(len(n.data)) {
+		n.data = n.data[:size]
+		return
+	}
+	n.data = append(n.data, make([]byte, size-int64(len(n.data)))...)
+}
@@ -0,0 +1,129 @@
+package shredder
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memFixture returns a MemFS holding /data/secret.txt with 64 KiB of text
+func memFixture(t *testing.T) (*MemFS, string, []byte) {
+	mem := NewMemFS()
+	path := filepath.Join(string(filepath.Separator), "data", "secret.txt")
+	content := make([]byte, 64<<10)
+	for i := range content {
+		content[i] = 'a' + byte(i%26)
+	}
+	require.NoError(t, mem.WriteFile(path, content))
+	return mem, path, content
+}
+
+func TestShredder_MemFS_WipeFile(t *testing.T) {
+	mem, path, _ := memFixture(t)
+
+	results := NewWithFS(mem).WipeFiles(context.Background(), []string{path}, WipeOptions{Passes: 3, Verify: VerifyAll})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success, "%v", results[0].Error)
+	assert.Equal(t, 3, results[0].Passes)
+	assert.Equal(t, VerifyPassed, results[0].Verification)
+	assert.False(t, mem.Exists(path))
+	assert.True(t, mem.Exists(filepath.Dir(path)))
+}
+
+func TestShredder_MemFS_Faults(t *testing.T) {
+	tests := []struct {
+		name   string
+		fault  func(path string) Fault
+		passes int
+		err    error
+	}{
+		{"EIO on pass 2", func(path string) Fault { return FailWrite(path, 2, syscall.EIO) }, 1, syscall.EIO},
+		{"ENOSPC on pass 1", func(path string) Fault { return FailWrite(path, 1, syscall.ENOSPC) }, 0, syscall.ENOSPC},
+		{"short write", func(path string) Fault { return ShortWrite(path, 1, 100) }, 0, io.ErrShortWrite},
+		{"sync failure", func(path string) Fault { return Fault{Op: FaultSync, Path: path} }, 0, syscall.EIO},
+		{"permission denied", func(path string) Fault { return Fault{Op: FaultOpen, Path: path, Err: syscall.EACCES} }, 0, os.ErrPermission},
+		{"file grows mid-wipe", func(path string) Fault { return Resize(path, 2, 128<<10) }, 1, ErrSizeChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem, path, content := memFixture(t)
+			mem.Inject(tt.fault(path))
+
+			results := NewWithFS(mem).WipeFiles(context.Background(), []string{path}, WipeOptions{Passes: 3})
+
+			require.Len(t, results, 1)
+			result := results[0]
+			assert.False(t, result.Success)
+			assert.False(t, result.Refused)
+			assert.ErrorIs(t, result.Error, tt.err)
+			assert.Equal(t, tt.passes, result.Passes)
+
+			// A failed file is kept, with whatever the passes reached
+			data, err := mem.ReadFile(path)
+			require.NoError(t, err)
+			if tt.passes > 0 {
+				assert.NotEqual(t, content, data[:len(content)])
+			}
+		})
+	}
+}
+
+func TestShredder_MemFS_WipeDirectory(t *testing.T) {
+	mem := NewMemFS()
+	dir := filepath.Join(string(filepath.Separator), "data")
+	kept := filepath.Join(dir, "sub", "locked.txt")
+	removed := filepath.Join(dir, "other", "file.txt")
+	require.NoError(t, mem.WriteFile(kept, []byte("locked")))
+	require.NoError(t, mem.WriteFile(removed, []byte("gone")))
+	mem.Inject(Fault{Op: FaultRemove, Path: kept, Err: syscall.EACCES})
+
+	results := NewWithFS(mem).WipeFiles(context.Background(), []string{dir}, WipeOptions{Passes: 1, Recursive: true})
+
+	byPath := map[string]WipeResult{}
+	for _, result := range results {
+		byPath[result.Path] = result
+	}
+	assert.ErrorIs(t, byPath[kept].Error, os.ErrPermission)
+	assert.True(t, byPath[removed].Success, "%v", byPath[removed].Error)
+	assert.True(t, byPath[filepath.Dir(kept)].Kept)
+	assert.True(t, byPath[dir].Kept)
+	assert.True(t, byPath[filepath.Dir(removed)].Success)
+
+	assert.False(t, mem.Exists(removed))
+	assert.False(t, mem.Exists(filepath.Dir(removed)))
+	assert.True(t, mem.Exists(dir))
+}
+
+func TestShredder_MemFS_ReadDirFault(t *testing.T) {
+	mem := NewMemFS()
+	dir := filepath.Join(string(filepath.Separator), "data")
+	require.NoError(t, mem.WriteFile(filepath.Join(dir, "file.txt"), []byte("data")))
+	mem.Inject(Fault{Op: FaultReadDir, Path: dir, Err: syscall.EACCES})
+
+	results := NewWithFS(mem).WipeFiles(context.Background(), []string{dir}, WipeOptions{Passes: 1, Recursive: true})
+
+	require.NotEmpty(t, results)
+	assert.ErrorIs(t, results[0].Error, os.ErrPermission)
+	assert.True(t, mem.Exists(filepath.Join(dir, "file.txt")))
+}
+
+func TestShredder_MemFS_Symlink(t *testing.T) {
+	mem, path, _ := memFixture(t)
+	link := filepath.Join(filepath.Dir(path), "link")
+	require.NoError(t, mem.Symlink(path, link))
+
+	results := NewWithFS(mem).WipeFiles(context.Background(), []string{link}, WipeOptions{Passes: 1})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Refused)
+	assert.ErrorIs(t, results[0].Error, ErrSymlink)
+	assert.True(t, mem.Exists(path))
+}
@@ -0,0 +1,195 @@
+package shredder
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pattern describes the data written during a single overwrite pass
+type Pattern struct {
+	// Random fills the pass with cryptographically secure random data
+	Random bool
+	// Bytes is the repeating byte sequence used when Random is false
+	Bytes []byte
+}
+
+// String renders the pattern in the same notation accepted by ParseMethod
+func (p Pattern) String() string {
+	if p.Random {
+		return "random"
+	}
+
+	parts := make([]string, len(p.Bytes))
+	for i, b := range p.Bytes {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return "0x" + strings.Join(parts, "")
+}
+
+// WipeMethod is a named sequence of overwrite passes
+type WipeMethod struct {
+	Name        string
+	Description string
+	Passes      []Pattern
+}
+
+// PassCount returns the number of overwrite passes in the method
+func (m WipeMethod) PassCount() int {
+	return len(m.Passes)
+}
+
+// IsZero reports whether the method is unset
+func (m WipeMethod) IsZero() bool {
+	return m.Name == "" && len(m.Passes) == 0
+}
+
+func random() Pattern {
+	return Pattern{Random: true}
+}
+
+func fill(b ...byte) Pattern {
+	return Pattern{Bytes: b}
+}
+
+// builtinMethods contains the predefined wipe schemes, keyed by name
+var builtinMethods = map[string]WipeMethod{
+	"nist-clear": {
+		Name:        "nist-clear",
+		Description: "NIST SP 800-88 Clear, single pass of zeros",
+		Passes:      []Pattern{fill(0x00)},
+	},
+	"dod": {
+		Name:        "dod",
+		Description: "DoD 5220.22-M (E), 3 passes: zeros, ones, random",
+		Passes:      []Pattern{fill(0x00), fill(0xFF), random()},
+	},
+	"dod-ece": {
+		Name:        "dod-ece",
+		Description: "DoD 5220.22-M (ECE), 7 passes",
+		Passes: []Pattern{
+			fill(0x00), fill(0xFF), random(),
+			random(),
+			fill(0x00), fill(0xFF), random(),
+		},
+	},
+	"schneier": {
+		Name:        "schneier",
+		Description: "Bruce Schneier, 7 passes: ones, zeros, 5 random",
+		Passes: []Pattern{
+			fill(0xFF), fill(0x00),
+			random(), random(), random(), random(), random(),
+		},
+	},
+	"vsitr": {
+		Name:        "vsitr",
+		Description: "German VSITR, 7 passes: alternating zeros/ones, then 0xAA",
+		Passes: []Pattern{
+			fill(0x00), fill(0xFF), fill(0x00), fill(0xFF),
+			fill(0x00), fill(0xFF), fill(0xAA),
+		},
+	},
+	"gutmann": {
+		Name:        "gutmann",
+		Description: "Peter Gutmann, 35 passes",
+		Passes: []Pattern{
+			random(), random(), random(), random(),
+			fill(0x55), fill(0xAA),
+			fill(0x92, 0x49, 0x24), fill(0x49, 0x24, 0x92), fill(0x24, 0x92, 0x49),
+			fill(0x00), fill(0x11), fill(0x22), fill(0x33),
+			fill(0x44), fill(0x55), fill(0x66), fill(0x77),
+			fill(0x88), fill(0x99), fill(0xAA), fill(0xBB),
+			fill(0xCC), fill(0xDD), fill(0xEE), fill(0xFF),
+			fill(0x92, 0x49, 0x24), fill(0x49, 0x24, 0x92), fill(0x24, 0x92, 0x49),
+			fill(0x6D, 0xB6, 0xDB), fill(0xB6, 0xDB, 0x6D), fill(0xDB, 0x6D, 0xB6),
+			random(), random(), random(), random(),
+		},
+	},
+}
+
+// Methods returns all built-in wipe methods sorted by name
+func Methods() []WipeMethod {
+	methods := make([]WipeMethod, 0, len(builtinMethods))
+	for _, m := range builtinMethods {
+		methods = append(methods, m)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+	return methods
+}
+
+// DefaultMethod returns the legacy rotation of random, 0x00, 0xFF and 0xAA
+// repeated for the given number of passes
+func DefaultMethod(passes int) WipeMethod {
+	if passes < 1 {
+		passes = 1
+	}
+
+	rotation := []Pattern{random(), fill(0x00), fill(0xFF), fill(0xAA)}
+	method := WipeMethod{
+		Name:        "standard",
+		Description: fmt.Sprintf("Rotating random/0x00/0xFF/0xAA, %d passes", passes),
+		Passes:      make([]Pattern, passes),
+	}
+	for i := range method.Passes {
+		method.Passes[i] = rotation[i%len(rotation)]
+	}
+	return method
+}
+
+// ParseMethod resolves a method specification. The specification is either
+// the name of a built-in method or a comma separated list of passes, where
+// each pass is "random" or a hex byte sequence such as "0x00" or "0x924924".
+func ParseMethod(spec string) (WipeMethod, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return WipeMethod{}, fmt.Errorf("empty wipe method")
+	}
+
+	if m, ok := builtinMethods[strings.ToLower(spec)]; ok {
+		return m, nil
+	}
+
+	var passes []Pattern
+	for _, field := range strings.Split(spec, ",") {
+		p, err := parsePattern(strings.TrimSpace(field))
+		if err != nil {
+			return WipeMethod{}, fmt.Errorf("invalid wipe method %q: %w", spec, err)
+		}
+		passes = append(passes, p)
+	}
+
+	return WipeMethod{
+		Name:        "custom",
+		Description: spec,
+		Passes:      passes,
+	}, nil
+}
+
+// parsePattern parses a single pass of a custom method
+func parsePattern(field string) (Pattern, error) {
+	if strings.EqualFold(field, "random") {
+		return random(), nil
+	}
+
+	lower := strings.ToLower(field)
+	if !strings.HasPrefix(lower, "0x") {
+		return Pattern{}, fmt.Errorf("pass %q must be 'random' or a hex byte sequence like 0xFF", field)
+	}
+	hex := strings.TrimPrefix(lower, "0x")
+	if hex == "" || len(hex)%2 != 0 {
+		return Pattern{}, fmt.Errorf("pass %q must be 'random' or a hex byte sequence like 0xFF", field)
+	}
+
+	pattern := make([]byte, len(hex)/2)
+	for i := range pattern {
+		b, err := strconv.ParseUint(hex[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return Pattern{}, fmt.Errorf("pass %q is not valid hex", field)
+		}
+		pattern[i] = byte(b)
+	}
+	return Pattern{Bytes: pattern}, nil
+}
@@ -0,0 +1,96 @@
+package shredder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMethod_Builtin(t *testing.T) {
+	tests := []struct {
+		spec   string
+		passes int
+	}{
+		{"nist-clear", 1},
+		{"dod", 3},
+		{"DoD-ECE", 7},
+		{"schneier", 7},
+		{"vsitr", 7},
+		{"gutmann", 35},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			method, err := ParseMethod(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.passes, method.PassCount())
+		})
+	}
+}
+
+func TestParseMethod_Custom(t *testing.T) {
+	method, err := ParseMethod("0x00, random,0XFF,0x924924")
+	require.NoError(t, err)
+
+	assert.Equal(t, "custom", method.Name)
+	require.Equal(t, 4, method.PassCount())
+	assert.Equal(t, []byte{0x00}, method.Passes[0].Bytes)
+	assert.True(t, method.Passes[1].Random)
+	assert.Equal(t, []byte{0xFF}, method.Passes[2].Bytes)
+	assert.Equal(t, []byte{0x92, 0x49, 0x24}, method.Passes[3].Bytes)
+	assert.Equal(t, "0x924924", method.Passes[3].String())
+}
+
+func TestParseMethod_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "unknown", "0x0", "0xZZ", "0x00,,random", "ff", "FF", "0x"} {
+		_, err := ParseMethod(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestDefaultMethod(t *testing.T) {
+	method := DefaultMethod(5)
+
+	require.Equal(t, 5, method.PassCount())
+	assert.True(t, method.Passes[0].Random)
+	assert.Equal(t, []byte{0x00}, method.Passes[1].Bytes)
+	assert.Equal(t, []byte{0xFF}, method.Passes[2].Bytes)
+	assert.Equal(t, []byte{0xAA}, method.Passes[3].Bytes)
+	assert.True(t, method.Passes[4].Random)
+
+	assert.Equal(t, 1, DefaultMethod(0).PassCount())
+}
+
+func TestChoosePolicy(t *testing.T) {
+	options := WipeOptions{Passes: 3}
+
+	hdd := choosePolicy(Media{Known: true, Device: "sda1", Disks: []string{"sda"}, Rotational: true}, options)
+	assert.Equal(t, "dod", hdd.Method.Name)
+	assert.Equal(t, DiscardNone, hdd.Discard)
+	assert.Empty(t, hdd.Warning)
+	assert.Equal(t, "sda1 (hdd on sda): dod, 3 passes", hdd.String())
+
+	ssd := choosePolicy(Media{Known: true, Device: "nvme0n1", Disks: []string{"nvme0n1"}, Discard: true}, options)
+	assert.Equal(t, 1, ssd.Method.PassCount())
+	assert.Equal(t, DiscardAfter, ssd.Discard)
+	assert.Equal(t, "nvme0n1 (ssd): random, 1 pass + discard", ssd.String())
+
+	noDiscard := choosePolicy(Media{Known: true, Device: "sdb"}, options)
+	assert.Equal(t, DiscardNone, noDiscard.Discard)
+
+	explicit := choosePolicy(Media{Known: true, Device: "nvme0n1", Discard: true}, WipeOptions{Passes: 3, Discard: DiscardOnly})
+	assert.Equal(t, DiscardOnly, explicit.Discard)
+
+	unknown := choosePolicy(Media{}, options)
+	assert.Equal(t, 3, unknown.Method.PassCount())
+
+	crypt := choosePolicy(Media{Known: true, Device: "dm-0", Rotational: true, Encrypted: true, Mapping: "cryptroot"}, options)
+	assert.Contains(t, crypt.Warning, "cryptroot")
+
+	applied := ssd.apply(WipeOptions{Passes: 3, Auto: true})
+	assert.False(t, applied.Auto)
+	assert.Equal(t, 1, applied.Passes)
+	assert.Equal(t, DiscardAfter, applied.Discard)
+	require.NotNil(t, applied.policy)
+}
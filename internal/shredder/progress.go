@@ -0,0 +1,106 @@
+package shredder
+
+import "sync"
+
+// EventKind identifies the type of a progress event
+type EventKind int
+
+const (
+	// EventJobStarted is sent once before any file is overwritten. Files
+	// and Total describe the whole job.
+	EventJobStarted EventKind = iota
+	// EventFileStarted is sent when a worker starts on a file. Total is
+	// the file size and Passes the number of passes planned.
+	EventFileStarted
+	// EventPassStarted is sent at the start of each overwrite pass
+	EventPassStarted
+	// EventBytesWritten reports Bytes newly written during Pass
+	EventBytesWritten
+	// EventFileFinished is sent when a file has been wiped and removed
+	EventFileFinished
+	// EventError is sent when wiping a file fails. Err holds the cause.
+	EventError
+	// EventJobFinished is sent once after every file has been processed
+	EventJobFinished
+)
+
+// ProgressEvent describes the progress of a wipe job
+type ProgressEvent struct {
+	Kind   EventKind
+	Path   string
+	Pass   int
+	Passes int
+	// Bytes is the number of bytes written since the previous
+	// EventBytesWritten for the same file
+	Bytes int64
+	// Total is the number of bytes to write: per file for
+	// EventFileStarted, for the whole job (size times passes) for
+	// EventJobStarted
+	Total int64
+	// Files is the number of files in the job for EventJobStarted
+	Files int
+	Err   error
+}
+
+// ProgressSink receives progress events. The shredder serializes calls,
+// so implementations do not need to be safe for concurrent use, but they
+// should return quickly as they hold up the workers.
+type ProgressSink interface {
+	Progress(event ProgressEvent)
+}
+
+// ProgressFunc adapts a function to the ProgressSink interface
+type ProgressFunc func(event ProgressEvent)
+
+// Progress calls f(event)
+func (f ProgressFunc) Progress(event ProgressEvent) {
+	f(event)
+}
+
+// progressReportInterval is the minimum number of bytes between two
+// EventBytesWritten events for a file
+const progressReportInterval = 1 << 20
+
+// progressEmitter serializes events to an optional sink
+type progressEmitter struct {
+	mu   sync.Mutex
+	sink ProgressSink
+}
+
+func newProgressEmitter(sink ProgressSink) *progressEmitter {
+	return &progressEmitter{sink: sink}
+}
+
+// emit delivers an event to the sink, if any
+func (e *progressEmitter) emit(event ProgressEvent) {
+	if e == nil || e.sink == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sink.Progress(event)
+}
+
+// bytesReporter returns a callback for performPass that batches written
+// byte counts into EventBytesWritten events. The returned flush function
+// sends whatever is still pending.
+func (e *progressEmitter) bytesReporter(path string, pass, passes int) (report func(int64), flush func()) {
+	if e == nil || e.sink == nil {
+		return nil, func() {}
+	}
+
+	var pending int64
+	flush = func() {
+		if pending > 0 {
+			e.emit(ProgressEvent{Kind: EventBytesWritten, Path: path, Pass: pass, Passes: passes, Bytes: pending})
+			pending = 0
+		}
+	}
+	report = func(n int64) {
+		pending += n
+		if pending >= progressReportInterval {
+			flush()
+		}
+	}
+	return report, flush
+}
@@ -0,0 +1,67 @@
+package shredder
+
+import (
+	"path/filepath"
+)
+
+// Target is an entry a wipe job would process, as listed by Resolve
+type Target struct {
+	Path string
+	// Dir marks a directory, removed once everything below it is wiped
+	Dir bool
+	// Err explains why the entry would be refused, with Refused set, or
+	// why it cannot be wiped
+	Err     error
+	Refused bool
+}
+
+// Resolve expands paths exactly like WipeFiles does, guard and limits
+// included, without changing anything. Files come in wipe order, each
+// tree followed by its directories, parents first.
+func (s *Shredder) Resolve(paths []string, options WipeOptions) []Target {
+	var targets []Target
+	for _, entry := range s.limitPlan(s.planWipe(paths, options), options) {
+		switch {
+		case entry.tree != nil:
+			for _, dir := range entry.tree {
+				targets = append(targets, Target{Path: dir, Dir: true})
+			}
+		case entry.wipe:
+			targets = append(targets, Target{Path: entry.path})
+		default:
+			targets = append(targets, Target{Path: entry.path, Err: entry.result.Error, Refused: entry.result.Refused})
+		}
+	}
+	return targets
+}
+
+// BrowserTargets returns the browser data paths present on this system
+func (s *Shredder) BrowserTargets() []string {
+	var targets []string
+	for _, paths := range s.getBrowserPaths() {
+		for _, path := range paths {
+			if _, err := s.fs.Lstat(path); err == nil {
+				targets = append(targets, path)
+			}
+		}
+	}
+	return targets
+}
+
+// TempTargets returns the entries of the system temporary directories.
+// The directories themselves are kept; only what they hold is wiped, and
+// every entry is vetted on its own.
+func (s *Shredder) TempTargets() []string {
+	var targets []string
+	for _, dir := range s.getSystemTempPaths() {
+		entries, err := s.fs.ReadDir(dir)
+		if err != nil {
+			s.logger.Warn().Str("path", dir).Err(err).Msg("failed to list temp directory")
+			continue
+		}
+		for _, entry := range entries {
+			targets = append(targets, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return targets
+}
@@ -0,0 +1,109 @@
+package shredder
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Scrub actions recorded in ScrubStep.Action
+const (
+	ScrubTruncate   = "truncate"
+	ScrubTimestamps = "timestamps"
+	ScrubRename     = "rename"
+	ScrubRemove     = "remove"
+)
+
+// neutralTime is the access and modification time set before unlinking
+var neutralTime = time.Unix(0, 0).UTC()
+
+// maxScrubRenames bounds the number of renames applied to a single entry
+const maxScrubRenames = 8
+
+// nameAlphabet is the set of characters used for obfuscated names
+const nameAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// ScrubStep records one metadata obfuscation step performed before unlink
+type ScrubStep struct {
+	Action string
+	// Path is the name the entry had after the step
+	Path  string
+	Error error
+}
+
+// scrubAndRemove hides the size, timestamps and name of an open entry and
+// then unlinks it. Regular files are truncated to zero length first. The
+// inode of a file with other hard links is shared, so only its name is
+// scrubbed. renamed, if not nil, is called with the new path after each
+// rename. Each step is recorded; only a failure to remove the entry is
+// returned as an error.
+func (s *Shredder) scrubAndRemove(h Entry, renamed func(path string)) ([]ScrubStep, error) {
+	var steps []ScrubStep
+	shared := !h.Info().IsDir() && linkCount(h.Info()) > 1
+
+	if h.Info().Mode().IsRegular() && !shared {
+		err := h.Truncate()
+		steps = append(steps, ScrubStep{Action: ScrubTruncate, Path: h.Path(), Error: err})
+	}
+
+	if !shared {
+		err := h.Chtimes(neutralTime)
+		steps = append(steps, ScrubStep{Action: ScrubTimestamps, Path: h.Path(), Error: err})
+	}
+
+	length := len(filepath.Base(h.Path()))
+	for i := 0; i < maxScrubRenames && length > 0; i++ {
+		next, err := freeName(h, length)
+		if err == nil {
+			err = h.Rename(next)
+		}
+		if err != nil {
+			steps = append(steps, ScrubStep{Action: ScrubRename, Path: h.Path(), Error: err})
+			break
+		}
+		steps = append(steps, ScrubStep{Action: ScrubRename, Path: h.Path()})
+		if renamed != nil {
+			renamed(h.Path())
+		}
+
+		if length == 1 {
+			break
+		}
+		length /= 2
+	}
+
+	err := h.Remove()
+	steps = append(steps, ScrubStep{Action: ScrubRemove, Path: h.Path(), Error: err})
+	return steps, err
+}
+
+// freeName returns an unused random name of the given length in the
+// directory of h
+func freeName(h Entry, length int) (string, error) {
+	var err error
+	for attempt := 0; attempt < 10; attempt++ {
+		var name string
+		name, err = randomName(length)
+		if err != nil {
+			return "", err
+		}
+		if !h.Exists(name) {
+			return name, nil
+		}
+		err = os.ErrExist
+	}
+	return "", err
+}
+
+// randomName returns a random alphanumeric name of the given length
+func randomName(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = nameAlphabet[int(b)%len(nameAlphabet)]
+	}
+	return string(buf), nil
+}
@@ -1,22 +1,90 @@
 package shredder
 
 import (
-	"crypto/rand"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// ErrSizeChanged is returned when a file grows or shrinks while it is being
+// overwritten, so that part of its data may have escaped the passes
+var ErrSizeChanged = errors.New("file changed size while being wiped")
+
 // WipeOptions contains configuration for the wiping operation
 type WipeOptions struct {
 	Recursive bool
 	Passes    int
 	Force     bool
 	DryRun    bool
+	// Method selects the overwrite scheme. When unset, Passes passes of
+	// the default rotation are used.
+	Method WipeMethod
+	// Auto picks the method and discard mode for each device from its
+	// detected medium, overriding Method, Passes and, unless set, Discard
+	Auto bool
+	// Verify controls which passes are read back and checked
+	Verify VerifyMode
+	// NoScrub disables truncation, timestamp reset and renaming of entries
+	// before they are unlinked
+	NoScrub bool
+	// Jobs bounds the number of files overwritten concurrently. Zero or
+	// less uses one worker per CPU. Rotational disks always get a single
+	// worker.
+	Jobs int
+	// Progress receives progress events while files are wiped
+	Progress ProgressSink
+	// Journal records checkpoints so an interrupted job can be resumed
+	Journal Journal
+	// BlockSize is the size of each write, a multiple of 4 KiB. Zero
+	// selects DefaultBlockSize.
+	BlockSize int
+	// DirectIO writes with O_DIRECT where supported, so passes reach the
+	// device instead of lingering in the page cache
+	DirectIO bool
+	// Discard releases the blocks of each file with hole punching after,
+	// or instead of, the overwrite passes, and trims the filesystem
+	// afterwards where permitted
+	Discard DiscardMode
+	// Slack extends every pass to the end of the last filesystem block of
+	// the file and truncates it back afterwards
+	Slack bool
+	// FollowSymlinks wipes the targets of symbolic links instead of
+	// refusing them. The links themselves are left in place.
+	FollowSymlinks bool
+	// HardLinks selects how regular files with other hard links are
+	// handled
+	HardLinks HardLinkPolicy
+	// Guard, if set, refuses protected entries and jobs that are too
+	// large before anything is written
+	Guard Guard
+	// HashContent records the SHA-256 of every regular file before it is
+	// overwritten, as evidence of what was destroyed
+	HashContent bool
+	// Audit, if set, receives a record of every file, directory, device
+	// and free space wipe
+	Audit Auditor
+
+	// progress serializes events to Progress for the duration of a job
+	progress *progressEmitter
+	// policy is the automatic choice applied to these options
+	policy *AutoPolicy
+}
+
+// method returns the wipe method that applies to these options
+func (o WipeOptions) method() WipeMethod {
+	if o.Method.IsZero() {
+		return DefaultMethod(o.Passes)
+	}
+	return o.Method
 }
 
 // WipeResult represents the result of wiping a single file
@@ -25,191 +93,696 @@ type WipeResult struct {
 	Success bool
 	Error   error
 	Size    int64
+	// PhysicalSize is the number of allocated bytes overwritten by each
+	// pass. It is below Size for sparse files, whose holes hold no data.
+	PhysicalSize int64
+	// SlackBytes is the number of bytes past the end of the file, up to
+	// the end of its last filesystem block, covered by each pass
+	SlackBytes int64
+	// Discarded reports that the blocks of the file were released by hole
+	// punching; DiscardError holds the failure when discard was requested
+	Discarded    bool
+	DiscardError error
+	// Trimmed reports that the filesystem was trimmed after the file was
+	// discarded, passing the freed blocks down to the device; TrimError
+	// holds the failure, typically a missing privilege
+	Trimmed   bool
+	TrimError error
+	// Filesystem names the filesystem the file was overwritten on
+	Filesystem string
+	// Assurance tells whether the passes were confirmed to overwrite the
+	// original blocks, and AssuranceNote explains why
+	Assurance     Assurance
+	AssuranceNote string
+	// Method is the name of the wipe method applied to the file
+	Method string
+	// Policy is the automatic choice made for the device of the file,
+	// nil unless WipeOptions.Auto was set
+	Policy *AutoPolicy
+	// Passes is the number of overwrite passes actually completed
+	Passes int
+	// Verification is the read-back status of the overwrite passes
+	Verification VerifyStatus
+	// MismatchOffset is the byte offset of the first mismatch found during
+	// verification. It is only meaningful when Verification is VerifyFailed.
+	MismatchOffset int64
+	// IsDir reports whether the result describes a directory
+	IsDir bool
+	// Kept reports that a directory was left in place because it still
+	// holds entries that were not wiped
+	Kept bool
+	// Scrub lists the metadata obfuscation steps performed before unlink
+	Scrub []ScrubStep
+	// Refused reports that the entry was left alone for safety, because it
+	// is a symbolic link, has other hard links or was replaced while being
+	// wiped. Error holds ErrSymlink, ErrHardLinked or ErrReplaced.
+	Refused bool
+	// Type classifies the entry
+	Type FileType
+	// Links is the number of names the file had when it was wiped
+	Links uint64
+	// UnlinkedOnly reports that the entry was removed without being
+	// overwritten, as for FIFOs, sockets, devices and hard links under
+	// HardLinkUnlink
+	UnlinkedOnly bool
+	// ContentHash is the hex SHA-256 of the file before it was
+	// overwritten, set when WipeOptions.HashContent is
+	ContentHash string
 }
 
 // Shredder handles secure file deletion
 type Shredder struct {
 	logger zerolog.Logger
+	// fs is the filesystem files are wiped on
+	fs FS
 }
 
-// New creates a new Shredder instance
+// New creates a new Shredder instance working on the filesystem of the
+// operating system
 func New() *Shredder {
+	return NewWithFS(OSFS{})
+}
+
+// NewWithFS creates a new Shredder instance working on fs
+func NewWithFS(fs FS) *Shredder {
 	return &Shredder{
 		logger: log.With().Str("component", "shredder").Logger(),
+		fs:     fs,
 	}
 }
 
-// WipeFiles securely wipes multiple files
-func (s *Shredder) WipeFiles(paths []string, options WipeOptions) []WipeResult {
-	var results []WipeResult
-	
-	for _, path := range paths {
-		if options.Recursive {
-			if info, err := os.Stat(path); err == nil && info.IsDir() {
-				dirResults := s.wipeDirectory(path, options)
-				results = append(results, dirResults...)
-				continue
-			}
+// WipeFiles securely wipes multiple files. Files are overwritten
+// concurrently by per-device worker pools; the returned results keep the
+// order of paths and of the directory walk. When ctx is cancelled, passes
+// in progress stop at the next block boundary, files not yet started are
+// left untouched and their results carry the context error.
+func (s *Shredder) WipeFiles(ctx context.Context, paths []string, options WipeOptions) []WipeResult {
+	options.progress = newProgressEmitter(options.Progress)
+	if options.Journal != nil && !options.DryRun {
+		if err := options.Journal.Begin(paths, options); err != nil {
+			s.logger.Warn().Err(err).Msg("failed to update wipe journal")
 		}
-		
-		result := s.wipeFile(path, options)
-		results = append(results, result)
 	}
-	
-	return results
+
+	plan := s.limitPlan(s.planWipe(paths, options), options)
+	s.runPlan(ctx, plan, options)
+	return s.collectResults(ctx, plan, options)
 }
 
 // wipeFile securely wipes a single file
-func (s *Shredder) wipeFile(path string, options WipeOptions) WipeResult {
-	info, err := os.Stat(path)
+func (s *Shredder) wipeFile(ctx context.Context, path string, options WipeOptions) WipeResult {
+	return s.wipeFileAt(ctx, filepath.Dir(path), path, options)
+}
+
+// wipeFileAt securely wipes a file below root. No symbolic link after root
+// is followed, and the file is only unlinked if its name still refers to
+// the inode that was overwritten.
+func (s *Shredder) wipeFileAt(ctx context.Context, root, path string, options WipeOptions) WipeResult {
+	info, err := s.fs.Lstat(path)
 	if err != nil {
 		return WipeResult{Path: path, Success: false, Error: err}
 	}
 	
-	if info.IsDir() {
-		return WipeResult{Path: path, Success: false, Error: fmt.Errorf("is a directory, use --recursive flag")}
+	fileType := classify(info)
+	switch fileType {
+	case TypeSymlink:
+		return WipeResult{Path: path, Success: false, Error: &os.PathError{Op: "wipe", Path: path, Err: ErrSymlink}, Refused: true, Type: fileType}
+	case TypeDirectory:
+		return WipeResult{Path: path, Success: false, Error: fmt.Errorf("is a directory, use --recursive flag"), Type: fileType}
+	}
+
+	if options.Auto {
+		options = s.autoPolicy(path, options).apply(options)
 	}
 	
+	method := options.method()
+	result := WipeResult{Path: path, Size: info.Size(), Method: method.Name, Policy: options.policy, Type: fileType, Links: linkCount(info)}
+
+	// Special files have no data of their own and opening a FIFO for
+	// writing blocks, so they are only unlinked
+	overwrite := fileType == TypeRegular
+	if overwrite && result.Links > 1 {
+		switch options.HardLinks {
+		case HardLinkUnlink:
+			overwrite = false
+		case HardLinkOverwrite:
+			s.logger.Warn().Str("file", path).Uint64("links", result.Links).Msg("overwriting data shared with other hard links")
+		default:
+			result.Error = &os.PathError{Op: "wipe", Path: path, Err: ErrHardLinked}
+			result.Refused = true
+			return result
+		}
+	}
+	if !overwrite {
+		result.Method = ""
+		result.UnlinkedOnly = true
+	} else if options.Discard == DiscardOnly {
+		result.Method = "discard"
+	}
+
 	if options.DryRun {
-		s.logger.Info().Str("file", path).Msg("would wipe file (dry run)")
-		return WipeResult{Path: path, Success: true, Size: info.Size()}
+		s.logger.Info().Str("file", path).Str("type", string(fileType)).Str("method", result.Method).Msg("would wipe file (dry run)")
+		result.Success = true
+		if overwrite && options.Discard != DiscardOnly {
+			result.Passes = method.PassCount()
+			result.PhysicalSize = allocatedSize(info)
+			if options.Slack {
+				_, result.SlackBytes = slackExtents(wholeFile(info.Size()), info.Size(), fsBlockSize(info))
+			}
+		}
+		return result
+	}
+	
+	var h Entry
+	if overwrite {
+		flag := os.O_WRONLY
+		if options.Verify != VerifyNone || options.HashContent {
+			flag = os.O_RDWR
+		}
+		h, err = s.fs.Open(root, path, flag)
+	} else {
+		h, err = s.fs.OpenNode(root, path)
+	}
+	if err == nil {
+		err = sameEntry(h, info)
+	}
+	if err != nil {
+		result.Error = err
+		result.Refused = isRefusal(err)
+		return result
+	}
+	if err := options.checkOpened(path, h.Info()); err != nil {
+		h.Close()
+		result.Error = &os.PathError{Op: "wipe", Path: path, Err: err}
+		result.Refused = true
+		return result
+	}
+	defer h.Close()
+
+	if overwrite && options.HashContent {
+		if result.ContentHash, err = hashFile(ctx, h.File()); err != nil {
+			result.Error = fmt.Errorf("hash content: %w", err)
+			return result
+		}
 	}
 	
 	// Perform overwrite passes
-	if err := s.overwriteFile(path, options.Passes); err != nil {
-		return WipeResult{Path: path, Success: false, Error: err}
+	if overwrite && options.Discard != DiscardOnly {
+		if err := s.overwriteFile(ctx, h.File(), path, options, &result); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+	
+	// Release the blocks to the device
+	if overwrite && options.Discard != DiscardNone {
+		if err := punchHole(h.File(), info.Size()); err != nil {
+			result.DiscardError = err
+			if options.Discard == DiscardOnly {
+				// Nothing else destroyed the data, so keep the file
+				result.Error = fmt.Errorf("discard failed: %w", err)
+				return result
+			}
+			s.logger.Warn().Err(err).Str("file", path).Msg("failed to discard file blocks")
+		} else {
+			result.Discarded = true
+		}
 	}
 	
 	// Remove the file
-	if err := os.Remove(path); err != nil {
-		return WipeResult{Path: path, Success: false, Error: err}
+	if options.NoScrub {
+		err = h.Remove()
+	} else {
+		result.Scrub, err = s.scrubAndRemove(h, s.recordScrubbed(path, info.Size(), result.Passes, options))
+	}
+	if err != nil {
+		result.Error = err
+		result.Refused = isRefusal(err)
+		return result
 	}
 	
-	s.logger.Info().Str("file", path).Int64("size", info.Size()).Msg("file wiped successfully")
-	return WipeResult{Path: path, Success: true, Size: info.Size()}
+	if overwrite {
+		s.logger.Info().Str("file", path).Int64("size", info.Size()).Str("method", method.Name).Int("passes", result.Passes).Str("verification", string(result.Verification)).Msg("file wiped successfully")
+	} else {
+		s.logger.Info().Str("file", path).Str("type", string(fileType)).Uint64("links", result.Links).Msg("entry unlinked without overwriting")
+	}
+	result.Success = true
+	return result
 }
 
 // wipeDirectory recursively wipes all files in a directory
-func (s *Shredder) wipeDirectory(dirPath string, options WipeOptions) []WipeResult {
+func (s *Shredder) wipeDirectory(ctx context.Context, dirPath string, options WipeOptions) []WipeResult {
+	options.Recursive = true
+	return s.WipeFiles(ctx, []string{dirPath}, options)
+}
+
+// removeTree removes the directories of a wiped tree bottom-up. dirs is in
+// walk order, so it is processed in reverse to handle children first. A
+// directory is only removed when everything below it was wiped: directories
+// holding one of the leftovers, entries that failed or were refused, are
+// kept, and so is anything that is not empty by the time it is reached.
+func (s *Shredder) removeTree(root string, dirs []string, leftovers []string, options WipeOptions) []WipeResult {
 	var results []WipeResult
-	
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			results = append(results, WipeResult{Path: path, Success: false, Error: err})
-			return nil
+
+	kept := make(map[string]int)
+	for _, path := range leftovers {
+		for dir := filepath.Dir(path); isWithin(root, dir); dir = filepath.Dir(dir) {
+			kept[dir]++
+			if dir == root {
+				break
+			}
 		}
-		
-		if !info.IsDir() {
-			result := s.wipeFile(path, options)
-			results = append(results, result)
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		if n := kept[dir]; n > 0 {
+			results = append(results, WipeResult{Path: dir, Error: fmt.Errorf("kept, %d entries below it were not wiped", n), IsDir: true, Type: TypeDirectory, Kept: true})
+			continue
 		}
-		
+
+		if entries, err := s.fs.ReadDir(dir); err == nil && len(entries) > 0 {
+			// Entries created while the tree was being wiped are not ours
+			// to delete
+			results = append(results, WipeResult{Path: dir, Error: fmt.Errorf("kept, %d entries appeared during the wipe", len(entries)), IsDir: true, Type: TypeDirectory, Kept: true})
+			s.keepParents(root, dir, kept)
+			continue
+		}
+
+		h, err := s.fs.Open(root, dir, os.O_RDONLY)
+		var steps []ScrubStep
+		if err == nil {
+			if options.NoScrub {
+				err = h.Remove()
+			} else {
+				steps, err = s.scrubAndRemove(h, nil)
+			}
+			h.Close()
+		}
+		if err != nil {
+			s.keepParents(root, dir, kept)
+		}
+		results = append(results, WipeResult{Path: dir, Success: err == nil, Error: err, IsDir: true, Type: TypeDirectory, Scrub: steps, Refused: isRefusal(err)})
+	}
+
+	return results
+}
+
+// recordScrubbed returns a function recording in the journal each name
+// path is given while being scrubbed, so that a resumed job can finish
+// removing it, or nil without a journal
+func (s *Shredder) recordScrubbed(path string, size int64, passes int, options WipeOptions) func(string) {
+	if options.Journal == nil {
 		return nil
-	})
-	
-	if err != nil {
-		results = append(results, WipeResult{Path: dirPath, Success: false, Error: err})
 	}
-	
-	// Remove empty directories
-	if !options.DryRun {
-		os.RemoveAll(dirPath)
+	return func(name string) {
+		if err := options.Journal.Record(path, Checkpoint{Size: size, Pass: passes, Scrubbed: name}); err != nil {
+			s.logger.Warn().Err(err).Str("file", path).Msg("failed to record checkpoint")
+		}
 	}
-	
-	return results
 }
 
-// overwriteFile performs multiple overwrite passes on a file
-func (s *Shredder) overwriteFile(path string, passes int) error {
-	file, err := os.OpenFile(path, os.O_WRONLY, 0)
-	if err != nil {
-		return err
+// keepParents marks the ancestors of a directory that could not be removed
+// as kept
+func (s *Shredder) keepParents(root, dir string, kept map[string]int) {
+	for dir != root && isWithin(root, dir) {
+		dir = filepath.Dir(dir)
+		kept[dir]++
 	}
-	defer file.Close()
-	
+}
+
+// isWithin reports whether path is root or lies below it
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// overwriteFile performs the overwrite passes of the selected method on an
+// open file, verifying passes as requested, and records the outcome in
+// result. When a journal holds a checkpoint for path, the passes continue
+// from there.
+func (s *Shredder) overwriteFile(ctx context.Context, file File, path string, options WipeOptions, result *WipeResult) error {
 	info, err := file.Stat()
 	if err != nil {
 		return err
 	}
 	
 	size := info.Size()
+	method := options.method()
+
+	extents := s.fileExtents(file, path, size)
+	result.PhysicalSize = extentBytes(extents)
+
+	// Passes may run past the end of the file into the slack of its last
+	// block; the file is truncated back after each pass
+	passExtents, passSize := extents, size
+	if options.Slack {
+		var slack int64
+		passExtents, slack = slackExtents(extents, size, fsBlockSize(info))
+		passSize += slack
+		result.SlackBytes = slack
+	}
+
+	buf := buffers.get(options.blockSize())
+	defer buffers.put(buf)
+
+	// Map where the data lives now, to tell afterwards whether the passes
+	// landed on the same blocks
+	fs := filesystemOf(file)
+	result.Filesystem = fs.name
+	before, mapErr := mapPhysical(file, size)
+
+	direct := options.DirectIO
+	if direct {
+		if err := setDirect(file, true); err != nil {
+			s.logger.Warn().Err(err).Str("file", path).Msg("direct I/O unavailable, using buffered writes")
+			direct = false
+		} else {
+			setDirect(file, false)
+		}
+	}
+
+	verify := func(pass int, seed []byte, extents []extent) error {
+		p := method.Passes[pass]
+		offset, err := s.verifyPass(ctx, file, extents, p, seed, *buf)
+		if err != nil {
+			return fmt.Errorf("pass %d (%s) verification failed: %w", pass+1, p, err)
+		}
+		if offset >= 0 {
+			result.Verification = VerifyFailed
+			result.MismatchOffset = offset
+			return fmt.Errorf("pass %d (%s) verification failed: mismatch at offset %d", pass+1, p, offset)
+		}
+		result.Verification = VerifyPassed
+		return nil
+	}
+
+	// A checkpoint past the last pass means only verification and removal
+	// were left
+	var start Checkpoint
+	if options.Journal != nil {
+		if cp, ok := options.Journal.Checkpoint(path); ok && cp.Size == size && cp.Pass <= len(method.Passes) {
+			start = cp
+			result.Passes = cp.Pass
+			if cp.Pass == len(method.Passes) {
+				s.logger.Info().Str("file", path).Msg("passes already done, resuming after the overwrite")
+			} else {
+				s.logger.Info().Str("file", path).Int("pass", cp.Pass+1).Int64("offset", cp.Offset).Msg("resuming wipe from checkpoint")
+			}
+		}
+	}
 	
-	for pass := 0; pass < passes; pass++ {
-		if err := s.performPass(file, size, pass); err != nil {
-			return fmt.Errorf("pass %d failed: %w", pass+1, err)
+	for pass := start.Pass; pass < len(method.Passes); pass++ {
+		p := method.Passes[pass]
+
+		var seed []byte
+		var offset int64
+		if pass == start.Pass && start.Seed != nil {
+			seed, offset = start.Seed, start.Offset
+		} else if seed, err = newPassSeed(); err != nil {
+			return err
+		}
+
+		record := func(offset int64) {
+			if options.Journal == nil {
+				return
+			}
+			cp := Checkpoint{Size: size, Pass: pass, Offset: offset, Seed: seed}
+			if err := options.Journal.Record(path, cp); err != nil {
+				s.logger.Warn().Err(err).Str("file", path).Msg("failed to record checkpoint")
+			}
+		}
+		record(offset)
+
+		options.progress.emit(ProgressEvent{Kind: EventPassStarted, Path: path, Pass: pass + 1, Passes: len(method.Passes)})
+		report, flush := options.progress.bytesReporter(path, pass+1, len(method.Passes))
+		reached, err := s.performPass(ctx, file, passSize, passSpec{
+			pattern:    p,
+			seed:       seed,
+			start:      offset,
+			extents:    passExtents,
+			buf:        *buf,
+			direct:     direct,
+			report:     report,
+			checkpoint: record,
+		})
+		flush()
+		check := options.Verify == VerifyAll || (options.Verify == VerifyLast && pass == len(method.Passes)-1)
+		var verifyErr error
+		verified := false
+		if passSize > size {
+			// Dirty pages past the new end of file are zeroed by the
+			// truncate, so the slack must reach the disk, and be read
+			// back, before it
+			if syncErr := syncData(file); syncErr != nil && err == nil {
+				err = syncErr
+			}
+			if err == nil && check {
+				verifyErr, verified = verify(pass, seed, passExtents), true
+			}
+			if truncErr := file.Truncate(size); truncErr != nil && err == nil {
+				err = truncErr
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				if syncErr := syncData(file); syncErr == nil {
+					record(reached)
+				}
+				return fmt.Errorf("interrupted during pass %d at offset %d: %w", pass+1, reached, err)
+			}
+			return fmt.Errorf("pass %d (%s) failed: %w", pass+1, p, err)
 		}
 		
 		// Sync to ensure data is written to disk
-		if err := file.Sync(); err != nil {
+		if err := syncData(file); err != nil {
+			return err
+		}
+		if info, err := file.Stat(); err == nil && info.Size() != size {
+			return fmt.Errorf("pass %d (%s): %w from %d to %d bytes", pass+1, p, ErrSizeChanged, size, info.Size())
+		}
+		result.Passes = pass + 1
+
+		if options.Journal != nil {
+			// The seed of the last pass is kept so that a resumed run can
+			// still verify it
+			done := Checkpoint{Size: size, Pass: pass + 1}
+			if pass == len(method.Passes)-1 {
+				done.Seed = seed
+			}
+			if err := options.Journal.Record(path, done); err != nil {
+				s.logger.Warn().Err(err).Str("file", path).Msg("failed to record checkpoint")
+			}
+		}
+
+		if verified {
+			if verifyErr != nil {
+				return verifyErr
+			}
+		} else if check {
+			if err := verify(pass, seed, extents); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The slack of a file whose passes were all done before resuming is
+	// gone past the end of file, so only its logical extents are verified
+	if last := len(method.Passes) - 1; start.Pass == last+1 && options.Verify != VerifyNone {
+		if method.Passes[last].Random && start.Seed == nil {
+			s.logger.Warn().Str("file", path).Msg("cannot verify the last pass, its seed was not recorded")
+		} else if err := verify(last, start.Seed, extents); err != nil {
 			return err
 		}
 	}
+
+	after, err := mapPhysical(file, size)
+	if mapErr == nil {
+		mapErr = err
+	}
+	result.Assurance, result.AssuranceNote = assessOverwrite(fs, before, after, mapErr)
+	if result.Assurance != AssuranceInPlace {
+		s.logger.Warn().Str("file", path).Str("assurance", string(result.Assurance)).Msg(result.AssuranceNote)
+	}
 	
 	return nil
 }
 
-// performPass performs a single overwrite pass with different patterns
-func (s *Shredder) performPass(file *os.File, size int64, passNum int) error {
-	// Seek to beginning
-	if _, err := file.Seek(0, 0); err != nil {
-		return err
+// passSpec describes a single overwrite pass
+type passSpec struct {
+	pattern Pattern
+	// seed keys the generator for random patterns
+	seed []byte
+	// start is the offset to resume writing from
+	start int64
+	// extents lists the allocated ranges to overwrite. nil covers the
+	// whole file.
+	extents []extent
+	// buf is the write buffer; its length is the block size. A pooled
+	// buffer of DefaultBlockSize is used when it is nil.
+	buf []byte
+	// direct writes through O_DIRECT while offsets and lengths stay
+	// aligned to blockAlign
+	direct bool
+	// report, if set, is called with the number of bytes of every write
+	report func(int64)
+	// checkpoint, if set, is called with the offset reached every
+	// checkpointInterval bytes, after the data has been synced
+	checkpoint func(int64)
+}
+
+// performPass performs a single overwrite pass over the extents of spec,
+// or the whole size bytes when no extents are given. It stops at the next
+// block boundary when ctx is cancelled and returns the offset reached. With
+// spec.direct, O_DIRECT is switched off for unaligned writes.
+func (s *Shredder) performPass(ctx context.Context, file File, size int64, spec passSpec) (int64, error) {
+	p := spec.pattern
+	if !p.Random && len(p.Bytes) == 0 {
+		return 0, fmt.Errorf("empty overwrite pattern")
 	}
-	
-	var pattern []byte
-	
-	switch passNum % 4 {
-	case 0:
-		// Random data
-		pattern = make([]byte, 4096)
-		if _, err := rand.Read(pattern); err != nil {
-			return err
+
+	stream, err := newPassStream(p, spec.seed)
+	if err != nil {
+		return spec.start, err
+	}
+
+	extents := spec.extents
+	if extents == nil {
+		extents = wholeFile(size)
+	}
+
+	buf := spec.buf
+	if buf == nil {
+		pooled := buffers.get(DefaultBlockSize)
+		defer buffers.put(pooled)
+		buf = *pooled
+	}
+
+	direct := spec.direct && setDirect(file, true) == nil
+	defer func() {
+		if direct {
+			setDirect(file, false)
 		}
-	case 1:
-		// All zeros
-		pattern = make([]byte, 4096)
-	case 2:
-		// All ones (0xFF)
-		pattern = make([]byte, 4096)
-		for i := range pattern {
-			pattern[i] = 0xFF
+	}()
+
+	position := spec.start
+	sinceCheckpoint := int64(0)
+	for _, e := range extents {
+		from, end := max(e.offset, spec.start), min(e.offset+e.length, size)
+		if from >= end {
+			continue
 		}
-	case 3:
-		// Alternating pattern (0xAA)
-		pattern = make([]byte, 4096)
-		for i := range pattern {
-			pattern[i] = 0xAA
+		if _, err := file.Seek(from, io.SeekStart); err != nil {
+			return position, err
+		}
+		stream.seek(from)
+		position = from
+
+		for position < end {
+			if err := ctx.Err(); err != nil {
+				return position, err
+			}
+
+			writeSize := min(int64(len(buf)), end-position)
+			if direct && (position%blockAlign != 0 || writeSize%blockAlign != 0) {
+				if err := setDirect(file, false); err != nil {
+					return position, err
+				}
+				direct = false
+			}
+
+			stream.fill(buf[:writeSize])
+			n, err := file.Write(buf[:writeSize])
+			position += int64(n)
+			if err != nil {
+				return position, err
+			}
+			if spec.report != nil {
+				spec.report(int64(n))
+			}
+
+			sinceCheckpoint += int64(n)
+			if spec.checkpoint != nil && sinceCheckpoint >= checkpointInterval {
+				if err := syncData(file); err != nil {
+					return position, err
+				}
+				spec.checkpoint(position)
+				sinceCheckpoint = 0
+			}
 		}
 	}
 	
-	written := int64(0)
-	for written < size {
-		remaining := size - written
-		writeSize := int64(len(pattern))
-		if remaining < writeSize {
-			writeSize = remaining
+	return position, nil
+}
+
+// verifyPass reads the extents of a file back and compares them against the
+// regenerated pass data, using buf for the expected data. The pass must be
+// synced: its pages are dropped from the cache first, so the comparison
+// covers what the device stored. It returns the offset of the first
+// mismatching byte, or -1 when the contents match.
+func (s *Shredder) verifyPass(ctx context.Context, file File, extents []extent, p Pattern, seed []byte, buf []byte) (int64, error) {
+	stream, err := newPassStream(p, seed)
+	if err != nil {
+		return -1, err
+	}
+	if err := dropCache(file, extents); err != nil {
+		return -1, fmt.Errorf("cannot drop cached pages before reading back: %w", err)
+	}
+
+	pooled := buffers.get(len(buf))
+	defer buffers.put(pooled)
+
+	expected := buf
+	actual := *pooled
+	for _, e := range extents {
+		stream.seek(e.offset)
+		end := e.offset + e.length
+		for offset := e.offset; offset < end; {
+			if err := ctx.Err(); err != nil {
+				return -1, err
+			}
+
+			chunk := min(int64(len(actual)), end-offset)
+			n, err := file.ReadAt(actual[:chunk], offset)
+			if int64(n) < chunk {
+				if err == nil || errors.Is(err, io.EOF) {
+					// The file is shorter than what was written
+					return offset + int64(n), nil
+				}
+				return -1, err
+			}
+
+			stream.fill(expected[:chunk])
+			if i := firstMismatch(expected[:chunk], actual[:chunk]); i >= 0 {
+				return offset + int64(i), nil
+			}
+			offset += chunk
 		}
-		
-		n, err := file.Write(pattern[:writeSize])
-		if err != nil {
-			return err
+	}
+
+	return -1, nil
+}
+
+// firstMismatch returns the index of the first differing byte, or -1
+func firstMismatch(a, b []byte) int {
+	if bytes.Equal(a, b) {
+		return -1
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return i
 		}
-		written += int64(n)
 	}
-	
-	return nil
+	return -1
 }
 
 // WipeBrowserData wipes browser cache, history, and temporary files
-func (s *Shredder) WipeBrowserData(options WipeOptions) error {
-	browserPaths := s.getBrowserPaths()
-	
-	var allPaths []string
-	for _, paths := range browserPaths {
-		allPaths = append(allPaths, paths...)
+func (s *Shredder) WipeBrowserData(ctx context.Context, options WipeOptions) ([]WipeResult, error) {
+	results := s.WipeFiles(ctx, s.BrowserTargets(), options)
+	if err := ctx.Err(); err != nil {
+		return results, err
 	}
 	
-	results := s.WipeFiles(allPaths, options)
-	
 	failed := 0
 	for _, result := range results {
 		if !result.Success {
@@ -219,17 +792,20 @@ func (s *Shredder) WipeBrowserData(options WipeOptions) error {
 	}
 	
 	if failed > 0 {
-		return fmt.Errorf("failed to wipe %d browser data files", failed)
+		return results, fmt.Errorf("failed to wipe %d browser data files", failed)
 	}
 	
-	return nil
+	return results, nil
 }
 
 // WipeSystemTemp wipes system temporary files
-func (s *Shredder) WipeSystemTemp(options WipeOptions) error {
-	tempPaths := s.getSystemTempPaths()
-	
-	results := s.WipeFiles(tempPaths, options)
+func (s *Shredder) WipeSystemTemp(ctx context.Context, options WipeOptions) ([]WipeResult, error) {
+	tempPaths := s.TempTargets()
+
+	results := s.WipeFiles(ctx, tempPaths, options)
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
 	
 	failed := 0
 	for _, result := range results {
@@ -240,10 +816,10 @@ func (s *Shredder) WipeSystemTemp(options WipeOptions) error {
 	}
 	
 	if failed > 0 {
-		return fmt.Errorf("failed to wipe %d temporary files", failed)
+		return results, fmt.Errorf("failed to wipe %d temporary files", failed)
 	}
 	
-	return nil
+	return results, nil
 }
 
 // getBrowserPaths returns paths to browser data based on OS
@@ -317,7 +893,7 @@ func (s *Shredder) getSystemTempPaths() []string {
 	var validPaths []string
 	for _, path := range paths {
 		if path != "" {
-			if _, err := os.Stat(path); err == nil {
+			if _, err := s.fs.Stat(path); err == nil {
 				validPaths = append(validPaths, path)
 			}
 		}
@@ -1,9 +1,14 @@
 package shredder
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,12 +32,14 @@ func TestShredder_WipeFile(t *testing.T) {
 		DryRun:    false,
 	}
 	
-	result := shredder.wipeFile(testFile, options)
+	result := shredder.wipeFile(context.Background(), testFile, options)
 	
 	assert.True(t, result.Success)
 	assert.NoError(t, result.Error)
 	assert.Equal(t, testFile, result.Path)
 	assert.Equal(t, int64(len(content)), result.Size)
+	assert.Equal(t, "standard", result.Method)
+	assert.Equal(t, 3, result.Passes)
 	
 	// Verify file is deleted
 	_, err = os.Stat(testFile)
@@ -56,7 +63,7 @@ func TestShredder_WipeFile_DryRun(t *testing.T) {
 		DryRun:    true,
 	}
 	
-	result := shredder.wipeFile(testFile, options)
+	result := shredder.wipeFile(context.Background(), testFile, options)
 	
 	assert.True(t, result.Success)
 	assert.NoError(t, result.Error)
@@ -91,13 +98,17 @@ func TestShredder_WipeDirectory(t *testing.T) {
 		DryRun:    false,
 	}
 	
-	results := shredder.wipeDirectory(testDir, options)
+	results := shredder.wipeDirectory(context.Background(), testDir, options)
 	
-	assert.Len(t, results, 2)
+	files := 0
 	for _, result := range results {
 		assert.True(t, result.Success)
 		assert.NoError(t, result.Error)
+		if !result.IsDir {
+			files++
+		}
 	}
+	assert.Equal(t, 2, files)
 	
 	// Verify directory is deleted
 	_, err = os.Stat(testDir)
@@ -127,7 +138,7 @@ func TestShredder_WipeFiles_Multiple(t *testing.T) {
 		DryRun:    false,
 	}
 	
-	results := shredder.WipeFiles(files, options)
+	results := shredder.WipeFiles(context.Background(), files, options)
 	
 	assert.Len(t, results, 3)
 	for _, result := range results {
@@ -159,7 +170,9 @@ func TestShredder_PerformPass(t *testing.T) {
 	shredder := New()
 	
 	// Perform an overwrite pass
-	err = shredder.performPass(file, int64(len(originalContent)), 0)
+	seed, err := newPassSeed()
+	require.NoError(t, err)
+	_, err = shredder.performPass(context.Background(), file, int64(len(originalContent)), passSpec{pattern: Pattern{Random: true}, seed: seed})
 	assert.NoError(t, err)
 	
 	// Read the file back
@@ -171,6 +184,877 @@ func TestShredder_PerformPass(t *testing.T) {
 	assert.Equal(t, len(originalContent), len(newContent))
 }
 
+func TestShredder_PerformPass_MultiBytePattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+
+	size := 10000
+	err := os.WriteFile(testFile, make([]byte, size), 0644)
+	require.NoError(t, err)
+
+	file, err := os.OpenFile(testFile, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	pattern := Pattern{Bytes: []byte{0x92, 0x49, 0x24}}
+	_, err = New().performPass(context.Background(), file, int64(size), passSpec{pattern: pattern})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	require.Len(t, content, size)
+	for i, b := range content {
+		if b != pattern.Bytes[i%3] {
+			t.Fatalf("byte %d = %#x, want %#x", i, b, pattern.Bytes[i%3])
+		}
+	}
+}
+
+func TestShredder_WipeFile_Method(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.txt")
+	err := os.WriteFile(testFile, []byte("method test"), 0644)
+	require.NoError(t, err)
+
+	method, err := ParseMethod("dod")
+	require.NoError(t, err)
+
+	result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 1, Force: true, Method: method})
+
+	assert.True(t, result.Success)
+	assert.Equal(t, "dod", result.Method)
+	assert.Equal(t, 3, result.Passes)
+}
+
+func TestShredder_WipeFile_Verify(t *testing.T) {
+	for _, mode := range []VerifyMode{VerifyLast, VerifyAll} {
+		t.Run(mode.String(), func(t *testing.T) {
+			tmpDir := t.TempDir()
+			testFile := filepath.Join(tmpDir, "test.txt")
+			err := os.WriteFile(testFile, make([]byte, 10000), 0644)
+			require.NoError(t, err)
+
+			result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 3, Force: true, Verify: mode})
+
+			assert.True(t, result.Success)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, VerifyPassed, result.Verification)
+		})
+	}
+}
+
+func TestShredder_WipeFile_HashContent(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("hello"), 0644))
+
+	result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 1, Force: true, HashContent: true})
+
+	assert.True(t, result.Success)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", result.ContentHash)
+}
+
+func TestShredder_VerifyPass_Mismatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+	size := int64(9000)
+	err := os.WriteFile(testFile, make([]byte, size), 0644)
+	require.NoError(t, err)
+
+	file, err := os.OpenFile(testFile, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	shredder := New()
+	pattern := Pattern{Random: true}
+	seed, err := newPassSeed()
+	require.NoError(t, err)
+	_, err = shredder.performPass(context.Background(), file, size, passSpec{pattern: pattern, seed: seed})
+	require.NoError(t, err)
+
+	offset, err := shredder.verifyPass(context.Background(), file, wholeFile(size), pattern, seed, make([]byte, 4096))
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), offset)
+
+	// Corrupt a single byte and make sure it is located exactly
+	buf := make([]byte, 1)
+	_, err = file.ReadAt(buf, 5000)
+	require.NoError(t, err)
+	buf[0] ^= 0xFF
+	_, err = file.WriteAt(buf, 5000)
+	require.NoError(t, err)
+
+	offset, err = shredder.verifyPass(context.Background(), file, wholeFile(size), pattern, seed, make([]byte, 4096))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), offset)
+
+	// A truncated file mismatches at the new end
+	require.NoError(t, file.Truncate(100))
+	offset, err = shredder.verifyPass(context.Background(), file, wholeFile(size), pattern, seed, make([]byte, 4096))
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), offset)
+}
+
+func TestShredder_WipeFile_Scrub(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "confidential-report.txt")
+	err := os.WriteFile(testFile, []byte("scrub me"), 0644)
+	require.NoError(t, err)
+
+	result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 1, Force: true})
+	require.True(t, result.Success)
+
+	actions := map[string]int{}
+	for _, step := range result.Scrub {
+		assert.NoError(t, step.Error, step.Action)
+		actions[step.Action]++
+	}
+	assert.Equal(t, 1, actions[ScrubTruncate])
+	assert.Equal(t, 1, actions[ScrubTimestamps])
+	assert.Equal(t, 1, actions[ScrubRemove])
+	assert.Greater(t, actions[ScrubRename], 1)
+
+	// Renamed names shrink and the last one is what gets removed
+	last := result.Scrub[len(result.Scrub)-1]
+	assert.Len(t, filepath.Base(last.Path), 1)
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShredder_WipeFile_NoScrub(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.txt")
+	err := os.WriteFile(testFile, []byte("no scrub"), 0644)
+	require.NoError(t, err)
+
+	result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 1, Force: true, NoScrub: true})
+
+	assert.True(t, result.Success)
+	assert.Empty(t, result.Scrub)
+	_, err = os.Stat(testFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestShredder_WipeDirectory_ScrubsDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	testDir := filepath.Join(tmpDir, "testdir")
+	require.NoError(t, os.MkdirAll(filepath.Join(testDir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "nested", "file.txt"), []byte("x"), 0644))
+
+	results := New().wipeDirectory(context.Background(), testDir, WipeOptions{Recursive: true, Passes: 1, Force: true})
+
+	var dirs []string
+	for _, result := range results {
+		if result.IsDir {
+			assert.True(t, result.Success)
+			assert.NotEmpty(t, result.Scrub)
+			dirs = append(dirs, result.Path)
+		}
+	}
+	assert.Equal(t, []string{filepath.Join(testDir, "nested"), testDir}, dirs)
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestShredder_WipeFiles_ParallelOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	treeDir := filepath.Join(tmpDir, "tree")
+	require.NoError(t, os.MkdirAll(filepath.Join(treeDir, "a", "b"), 0755))
+
+	var want []string
+	for _, name := range []string{"a/1.txt", "a/b/2.txt", "a/b/3.txt", "c.txt"} {
+		path := filepath.Join(treeDir, name)
+		require.NoError(t, os.WriteFile(path, make([]byte, 20000), 0644))
+		want = append(want, path)
+	}
+
+	var singles []string
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(tmpDir, fmt.Sprintf("file%02d.txt", i))
+		require.NoError(t, os.WriteFile(path, make([]byte, 5000), 0644))
+		singles = append(singles, path)
+	}
+
+	targets := append([]string{singles[0], treeDir}, singles[1:]...)
+	results := New().WipeFiles(context.Background(), targets, WipeOptions{Recursive: true, Passes: 2, Force: true, Jobs: 8})
+
+	var got []string
+	for _, result := range results {
+		require.True(t, result.Success, result.Path)
+		if !result.IsDir {
+			got = append(got, result.Path)
+		}
+	}
+
+	expected := append([]string{singles[0]}, want...)
+	expected = append(expected, singles[1:]...)
+	assert.Equal(t, expected, got)
+
+	// Directory results follow the files of their tree
+	assert.True(t, results[len(want)+1].IsDir)
+}
+
+func TestShredder_WipeFiles_Progress(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := []string{filepath.Join(tmpDir, "a.bin"), filepath.Join(tmpDir, "b.bin")}
+	sizes := []int{3 << 20, 1000}
+	for i, file := range files {
+		require.NoError(t, os.WriteFile(file, make([]byte, sizes[i]), 0644))
+	}
+
+	var events []ProgressEvent
+	sink := ProgressFunc(func(event ProgressEvent) {
+		events = append(events, event)
+	})
+
+	results := New().WipeFiles(context.Background(), files, WipeOptions{Passes: 2, Force: true, Jobs: 2, Progress: sink})
+	require.Len(t, results, 2)
+
+	require.NotEmpty(t, events)
+	first, last := events[0], events[len(events)-1]
+	assert.Equal(t, EventJobStarted, first.Kind)
+	assert.Equal(t, 2, first.Files)
+	assert.Equal(t, int64(2*(sizes[0]+sizes[1])), first.Total)
+	assert.Equal(t, EventJobFinished, last.Kind)
+
+	written := map[string]int64{}
+	counts := map[EventKind]int{}
+	for _, event := range events {
+		counts[event.Kind]++
+		if event.Kind == EventBytesWritten {
+			written[event.Path] += event.Bytes
+		}
+	}
+	assert.Equal(t, 2, counts[EventFileStarted])
+	assert.Equal(t, 4, counts[EventPassStarted])
+	assert.Equal(t, 2, counts[EventFileFinished])
+	assert.Zero(t, counts[EventError])
+	assert.Equal(t, int64(2*sizes[0]), written[files[0]])
+	assert.Equal(t, int64(2*sizes[1]), written[files[1]])
+}
+
+// memoryJournal is an in-memory Journal for tests
+type memoryJournal struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+	finished    map[string]error
+}
+
+func newMemoryJournal() *memoryJournal {
+	return &memoryJournal{checkpoints: map[string]Checkpoint{}, finished: map[string]error{}}
+}
+
+func (j *memoryJournal) Begin(targets []string, options WipeOptions) error { return nil }
+
+func (j *memoryJournal) Checkpoint(path string) (Checkpoint, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	cp, ok := j.checkpoints[path]
+	return cp, ok
+}
+
+func (j *memoryJournal) Record(path string, cp Checkpoint) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.checkpoints[path] = cp
+	return nil
+}
+
+func (j *memoryJournal) Finish(path string, err error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.finished[path] = err
+	return nil
+}
+
+func TestShredder_WipeFiles_Cancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	testDir := filepath.Join(tmpDir, "testdir")
+	require.NoError(t, os.Mkdir(testDir, 0755))
+	testFile := filepath.Join(testDir, "file.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("keep me"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	journal := newMemoryJournal()
+	results := New().WipeFiles(ctx, []string{testDir}, WipeOptions{Recursive: true, Passes: 1, Force: true, Journal: journal})
+
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.ErrorIs(t, results[0].Error, context.Canceled)
+	assert.ErrorIs(t, journal.finished[testFile], context.Canceled)
+
+	// Nothing was touched, not even the directory tree
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, "keep me", string(content))
+}
+
+func TestShredder_OverwriteFile_ResumesFromCheckpoint(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+	size := int64(3*4096 + 123)
+	require.NoError(t, os.WriteFile(testFile, make([]byte, size), 0644))
+
+	// Simulate a first run interrupted 5000 bytes into a random pass
+	seed, err := newPassSeed()
+	require.NoError(t, err)
+	stream, err := newPassStream(Pattern{Random: true}, seed)
+	require.NoError(t, err)
+	prefix := make([]byte, 5000)
+	stream.fill(prefix)
+	file, err := os.OpenFile(testFile, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = file.WriteAt(prefix, 0)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	journal := newMemoryJournal()
+	journal.checkpoints[testFile] = Checkpoint{Size: size, Pass: 0, Offset: 5000, Seed: seed}
+
+	method, err := ParseMethod("random")
+	require.NoError(t, err)
+	options := WipeOptions{Method: method, Verify: VerifyLast, Journal: journal}
+
+	file, err = os.OpenFile(testFile, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var result WipeResult
+	err = New().overwriteFile(context.Background(), file, testFile, options, &result)
+	require.NoError(t, err)
+
+	// Verification regenerates the whole pass from offset 0, so it only
+	// passes if the resumed writes continued the same stream
+	assert.Equal(t, VerifyPassed, result.Verification)
+	assert.Equal(t, 1, result.Passes)
+	assert.Equal(t, Checkpoint{Size: size, Pass: 1, Seed: seed}, journal.checkpoints[testFile])
+}
+
+func TestShredder_OverwriteFile_ResumesAfterLastPass(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+	size := int64(3*4096 + 123)
+
+	// Simulate a first run interrupted while verifying its only pass
+	seed, err := newPassSeed()
+	require.NoError(t, err)
+	stream, err := newPassStream(Pattern{Random: true}, seed)
+	require.NoError(t, err)
+	content := make([]byte, size)
+	stream.fill(content)
+	require.NoError(t, os.WriteFile(testFile, content, 0644))
+
+	journal := newMemoryJournal()
+	journal.checkpoints[testFile] = Checkpoint{Size: size, Pass: 1, Seed: seed}
+
+	method, err := ParseMethod("random")
+	require.NoError(t, err)
+	options := WipeOptions{Method: method, Verify: VerifyLast, Journal: journal}
+
+	file, err := os.OpenFile(testFile, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var result WipeResult
+	require.NoError(t, New().overwriteFile(context.Background(), file, testFile, options, &result))
+	assert.Equal(t, VerifyPassed, result.Verification)
+	assert.Equal(t, 1, result.Passes)
+
+	// No pass was written again
+	after, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, content, after)
+}
+
+func TestShredder_WipeFiles_RecordsScrubbedName(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "secret.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("data"), 0644))
+
+	journal := newMemoryJournal()
+	results := New().WipeFiles(context.Background(), []string{testFile}, WipeOptions{Passes: 1, Force: true, Journal: journal})
+	require.Len(t, results, 1)
+	require.True(t, results[0].Success, "%v", results[0].Error)
+
+	// The last name the file had before removal is in the journal
+	cp := journal.checkpoints[testFile]
+	require.NotEmpty(t, cp.Scrubbed)
+	assert.Equal(t, tmpDir, filepath.Dir(cp.Scrubbed))
+	assert.Equal(t, results[0].Scrub[len(results[0].Scrub)-2].Path, cp.Scrubbed)
+	assert.NoError(t, journal.finished[testFile])
+}
+
+func TestShredder_PerformPass_Interrupted(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+	size := int64(64 * 4096)
+	require.NoError(t, os.WriteFile(testFile, make([]byte, size), 0644))
+
+	file, err := os.OpenFile(testFile, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	written := int64(0)
+	report := func(n int64) {
+		written += n
+		if written >= 8*4096 {
+			cancel()
+		}
+	}
+
+	reached, err := New().performPass(ctx, file, size, passSpec{pattern: Pattern{Bytes: []byte{0xFF}}, buf: make([]byte, 4096), report: report})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, written, reached)
+	assert.Less(t, reached, size)
+}
+
+func TestShredder_WipeFiles_RefusesSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	outside := filepath.Join(tmpDir, "outside.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("not yours"), 0644))
+
+	testDir := filepath.Join(tmpDir, "testdir")
+	require.NoError(t, os.Mkdir(testDir, 0755))
+	inner := filepath.Join(testDir, "inner.txt")
+	require.NoError(t, os.WriteFile(inner, []byte("wipe me"), 0644))
+	link := filepath.Join(testDir, "link")
+	require.NoError(t, os.Symlink(outside, link))
+	topLink := filepath.Join(tmpDir, "toplink")
+	require.NoError(t, os.Symlink(outside, topLink))
+
+	results := New().WipeFiles(context.Background(), []string{topLink, testDir}, WipeOptions{Recursive: true, Passes: 1, Force: true})
+
+	refused := map[string]bool{}
+	for _, result := range results {
+		if result.Refused {
+			refused[result.Path] = true
+			assert.ErrorIs(t, result.Error, ErrSymlink)
+			assert.False(t, result.Success)
+		}
+	}
+	assert.Equal(t, map[string]bool{topLink: true, link: true}, refused)
+
+	content, err := os.ReadFile(outside)
+	require.NoError(t, err)
+	assert.Equal(t, "not yours", string(content))
+	_, err = os.Stat(inner)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestShredder_WipeDirectory_KeepsDirectoriesWithLeftovers(t *testing.T) {
+	tmpDir := t.TempDir()
+	testDir := filepath.Join(tmpDir, "testdir")
+	keptDir := filepath.Join(testDir, "kept")
+	removedDir := filepath.Join(testDir, "removed")
+	require.NoError(t, os.MkdirAll(keptDir, 0755))
+	require.NoError(t, os.MkdirAll(removedDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(removedDir, "file.txt"), []byte("data"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(keptDir, "file.txt"), []byte("data"), 0644))
+	link := filepath.Join(keptDir, "link")
+	require.NoError(t, os.Symlink(filepath.Join(tmpDir, "elsewhere"), link))
+
+	results := New().wipeDirectory(context.Background(), testDir, WipeOptions{Passes: 1, Force: true})
+
+	dirs := map[string]WipeResult{}
+	for _, result := range results {
+		if result.IsDir {
+			dirs[result.Path] = result
+		}
+	}
+	require.Len(t, dirs, 3)
+	assert.True(t, dirs[removedDir].Success)
+	assert.True(t, dirs[keptDir].Kept)
+	assert.True(t, dirs[testDir].Kept)
+	assert.False(t, dirs[testDir].Success)
+
+	// The refused link stays; everything that was wiped is gone
+	_, err := os.Lstat(link)
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(keptDir, "file.txt"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(removedDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestShredder_WipeFiles_FollowSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "target.txt")
+	require.NoError(t, os.WriteFile(target, []byte("wipe through link"), 0644))
+	link := filepath.Join(tmpDir, "link")
+	require.NoError(t, os.Symlink(target, link))
+
+	results := New().WipeFiles(context.Background(), []string{link}, WipeOptions{Passes: 1, Force: true, FollowSymlinks: true})
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success, "%v", results[0].Error)
+	_, err := os.Stat(target)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Lstat(link)
+	assert.NoError(t, err, "the link itself is left in place")
+}
+
+func TestOpenHandle_SymlinkedParent(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("intermediate components are only checked with openat")
+	}
+
+	tmpDir := t.TempDir()
+	realDir := filepath.Join(tmpDir, "real")
+	require.NoError(t, os.Mkdir(realDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(realDir, "file.txt"), []byte("data"), 0644))
+	root := filepath.Join(tmpDir, "root")
+	require.NoError(t, os.Mkdir(root, 0755))
+	require.NoError(t, os.Symlink(realDir, filepath.Join(root, "sub")))
+
+	_, err := openHandle(root, filepath.Join(root, "sub", "file.txt"), os.O_WRONLY)
+	assert.ErrorIs(t, err, ErrSymlink)
+}
+
+func TestHandle_RemoveReplaced(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "file.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("original"), 0644))
+
+	h, err := openHandle(tmpDir, testFile, os.O_WRONLY)
+	require.NoError(t, err)
+	defer h.Close()
+
+	// Swap the name for a different file after it was opened
+	require.NoError(t, os.Rename(testFile, filepath.Join(tmpDir, "moved.txt")))
+	require.NoError(t, os.WriteFile(testFile, []byte("replacement"), 0644))
+
+	err = h.Remove()
+	assert.ErrorIs(t, err, ErrReplaced)
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	assert.Equal(t, "replacement", string(content))
+}
+
+func TestPassStream_ChunkedFill(t *testing.T) {
+	seed, err := newPassSeed()
+	require.NoError(t, err)
+
+	for _, p := range []Pattern{{Random: true}, {Bytes: []byte{0x92, 0x49, 0x24}}} {
+		whole, err := newPassStream(p, seed)
+		require.NoError(t, err)
+		expected := make([]byte, 10000)
+		whole.fill(expected)
+
+		chunked, err := newPassStream(p, seed)
+		require.NoError(t, err)
+		actual := make([]byte, 0, len(expected))
+		for _, n := range []int{1, 7, 4096, 333, 5563} {
+			buf := make([]byte, n)
+			chunked.fill(buf)
+			actual = append(actual, buf...)
+		}
+		assert.Equal(t, expected, actual, p.String())
+	}
+
+	// Random passes never repeat a block
+	random, err := newPassStream(Pattern{Random: true}, seed)
+	require.NoError(t, err)
+	first := make([]byte, 4096)
+	second := make([]byte, 4096)
+	random.fill(first)
+	random.fill(second)
+	assert.NotEqual(t, first, second)
+}
+
+func TestShredder_WipeFile_BlockSizeAndDirectIO(t *testing.T) {
+	for _, direct := range []bool{false, true} {
+		t.Run(fmt.Sprintf("direct=%v", direct), func(t *testing.T) {
+			tmpDir := t.TempDir()
+			testFile := filepath.Join(tmpDir, "test.bin")
+			require.NoError(t, os.WriteFile(testFile, make([]byte, 3*8192+511), 0644))
+
+			result := New().wipeFile(context.Background(), testFile, WipeOptions{
+				Passes:    3,
+				Force:     true,
+				Verify:    VerifyAll,
+				BlockSize: 8192,
+				DirectIO:  direct,
+			})
+			assert.True(t, result.Success, "%v", result.Error)
+			assert.Equal(t, VerifyPassed, result.Verification)
+		})
+	}
+}
+
+func TestValidateBlockSize(t *testing.T) {
+	assert.NoError(t, ValidateBlockSize(0))
+	assert.NoError(t, ValidateBlockSize(4096))
+	assert.NoError(t, ValidateBlockSize(DefaultBlockSize))
+	assert.Error(t, ValidateBlockSize(1000))
+	assert.Error(t, ValidateBlockSize(MaxBlockSize+blockAlign))
+}
+
+func TestAlignedBuffer(t *testing.T) {
+	for _, size := range []int{4096, 1 << 20} {
+		buf := alignedBuffer(size)
+		assert.Len(t, buf, size)
+		assert.Zero(t, uintptr(unsafe.Pointer(&buf[0]))%blockAlign)
+	}
+}
+
+// benchmarkPass measures the throughput of a single overwrite pass of a
+// 64 MiB file with the given block size
+func benchmarkPass(b *testing.B, p Pattern, blockSize int) {
+	const size = 64 << 20
+	file, err := os.Create(filepath.Join(b.TempDir(), "bench.bin"))
+	require.NoError(b, err)
+	defer file.Close()
+	require.NoError(b, file.Truncate(size))
+
+	seed, err := newPassSeed()
+	require.NoError(b, err)
+	buf := alignedBuffer(blockSize)
+	s := New()
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.performPass(context.Background(), file, size, passSpec{pattern: p, seed: seed, buf: buf}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPerformPass_Random4K(b *testing.B) { benchmarkPass(b, Pattern{Random: true}, 4096) }
+
+func BenchmarkPerformPass_Random1M(b *testing.B) {
+	benchmarkPass(b, Pattern{Random: true}, DefaultBlockSize)
+}
+
+func BenchmarkPerformPass_Pattern4K(b *testing.B) {
+	benchmarkPass(b, Pattern{Bytes: []byte{0x92, 0x49, 0x24}}, 4096)
+}
+
+func BenchmarkPerformPass_Pattern1M(b *testing.B) {
+	benchmarkPass(b, Pattern{Bytes: []byte{0x92, 0x49, 0x24}}, DefaultBlockSize)
+}
+
+func BenchmarkPassStream_Random(b *testing.B) {
+	seed, err := newPassSeed()
+	require.NoError(b, err)
+	stream, err := newPassStream(Pattern{Random: true}, seed)
+	require.NoError(b, err)
+	buf := alignedBuffer(DefaultBlockSize)
+
+	b.SetBytes(int64(len(buf)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stream.fill(buf)
+	}
+}
+
+func TestShredder_PerformPass_Extents(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+	size := int64(5 * 4096)
+	require.NoError(t, os.WriteFile(testFile, make([]byte, size), 0644))
+
+	file, err := os.OpenFile(testFile, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer file.Close()
+
+	extents := []extent{{offset: 4096, length: 4096}, {offset: 3 * 4096, length: 100}}
+	written := int64(0)
+	_, err = New().performPass(context.Background(), file, size, passSpec{
+		pattern: Pattern{Bytes: []byte{0xFF}},
+		extents: extents,
+		report:  func(n int64) { written += n },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4096+100), written)
+
+	content, err := os.ReadFile(testFile)
+	require.NoError(t, err)
+	for i, b := range content {
+		inside := (i >= 4096 && i < 2*4096) || (i >= 3*4096 && i < 3*4096+100)
+		if inside != (b == 0xFF) {
+			t.Fatalf("byte %d = %#x, inside extent: %v", i, b, inside)
+		}
+	}
+}
+
+func TestShredder_WipeFile_Sparse(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "sparse.img")
+	size := int64(64 << 20)
+
+	file, err := os.Create(testFile)
+	require.NoError(t, err)
+	require.NoError(t, file.Truncate(size))
+	_, err = file.WriteAt(make([]byte, 4096), 1<<20)
+	require.NoError(t, err)
+	_, err = file.WriteAt([]byte("secret"), 40<<20)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 2, Force: true, Verify: VerifyAll})
+	require.True(t, result.Success, "%v", result.Error)
+	assert.Equal(t, size, result.Size)
+	assert.Equal(t, VerifyPassed, result.Verification)
+	if runtime.GOOS == "linux" {
+		assert.Less(t, result.PhysicalSize, size)
+		assert.GreaterOrEqual(t, result.PhysicalSize, int64(4096+6))
+	} else {
+		assert.Equal(t, size, result.PhysicalSize)
+	}
+}
+
+func TestShredder_OverwriteFile_Slack(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.bin")
+	size := int64(5000)
+	require.NoError(t, os.WriteFile(testFile, make([]byte, size), 0644))
+
+	file, err := os.OpenFile(testFile, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer file.Close()
+	info, err := file.Stat()
+	require.NoError(t, err)
+
+	var written int64
+	options := WipeOptions{Passes: 2, Verify: VerifyAll, Slack: true}
+	options.progress = newProgressEmitter(ProgressFunc(func(e ProgressEvent) {
+		if e.Kind == EventBytesWritten {
+			written += e.Bytes
+		}
+	}))
+
+	recorder := &orderFile{File: file}
+	var result WipeResult
+	require.NoError(t, New().overwriteFile(context.Background(), recorder, testFile, options, &result))
+	assert.Equal(t, VerifyPassed, result.Verification)
+
+	blockSize := fsBlockSize(info)
+	if blockSize == 0 {
+		assert.Zero(t, result.SlackBytes)
+	} else {
+		assert.Equal(t, blockSize-size%blockSize, result.SlackBytes)
+	}
+	assert.Equal(t, 2*(size+result.SlackBytes), written)
+
+	// Each pass reaches the disk, slack included, before the truncate that
+	// would otherwise zero it, and the read-back covers the slack
+	if result.SlackBytes > 0 {
+		assert.Equal(t, []string{"sync", "truncate", "sync", "sync", "truncate", "sync"}, recorder.ops)
+		assert.Equal(t, size+result.SlackBytes, recorder.readEnd)
+	}
+
+	// The file is back at its logical size
+	info, err = file.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, size, info.Size())
+}
+
+// orderFile records the syncs and truncates made on a file, and how far it
+// was read
+type orderFile struct {
+	*os.File
+	ops     []string
+	readEnd int64
+}
+
+func (f *orderFile) Sync() error {
+	f.ops = append(f.ops, "sync")
+	return f.File.Sync()
+}
+
+func (f *orderFile) Truncate(size int64) error {
+	f.ops = append(f.ops, "truncate")
+	return f.File.Truncate(size)
+}
+
+func (f *orderFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	if end := off + int64(n); end > f.readEnd {
+		f.readEnd = end
+	}
+	return n, err
+}
+
+func TestSlackExtents(t *testing.T) {
+	extents, slack := slackExtents([]extent{{0, 100}, {4096, 1000}}, 5096, 4096)
+	assert.Equal(t, int64(3096), slack)
+	assert.Equal(t, []extent{{0, 100}, {4096, 4096}}, extents)
+
+	// Ending in a hole or on a block boundary leaves no slack to cover
+	_, slack = slackExtents([]extent{{0, 100}}, 5096, 4096)
+	assert.Zero(t, slack)
+	_, slack = slackExtents([]extent{{0, 8192}}, 8192, 4096)
+	assert.Zero(t, slack)
+}
+
+func TestAssessOverwrite(t *testing.T) {
+	ext4 := filesystem{name: "ext4"}
+	btrfs := filesystem{name: "btrfs", copyOnWrite: true}
+	before := []physicalExtent{{logical: 0, physical: 4096, length: 8192}}
+	split := []physicalExtent{{logical: 0, physical: 4096, length: 4096}, {logical: 4096, physical: 8192, length: 4096}}
+	moved := []physicalExtent{{logical: 0, physical: 1 << 20, length: 8192}}
+	shared := []physicalExtent{{logical: 0, physical: 4096, length: 8192, flags: fiemapExtentShared}}
+	inline := []physicalExtent{{logical: 0, physical: 0, length: 100, flags: fiemapExtentDataInline}}
+
+	tests := []struct {
+		name      string
+		fs        filesystem
+		before    []physicalExtent
+		after     []physicalExtent
+		err       error
+		assurance Assurance
+	}{
+		{"unchanged", ext4, before, before, nil, AssuranceInPlace},
+		{"split but same blocks", ext4, before, split, nil, AssuranceInPlace},
+		{"moved", btrfs, before, moved, nil, AssuranceRelocated},
+		{"shared", ext4, shared, shared, nil, AssuranceRelocated},
+		{"inline", ext4, inline, inline, nil, AssuranceUnknown},
+		{"unmappable", ext4, nil, nil, fmt.Errorf("not supported"), AssuranceUnknown},
+		{"unmappable copy-on-write", btrfs, nil, nil, fmt.Errorf("not supported"), AssuranceRelocated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assurance, note := assessOverwrite(tt.fs, tt.before, tt.after, tt.err)
+			assert.Equal(t, tt.assurance, assurance)
+			assert.NotEmpty(t, note)
+		})
+	}
+}
+
+func TestShredder_WipeFile_Assurance(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.txt")
+	require.NoError(t, os.WriteFile(testFile, make([]byte, 64<<10), 0644))
+
+	result := New().wipeFile(context.Background(), testFile, WipeOptions{Passes: 1, Force: true})
+	require.True(t, result.Success, "%v", result.Error)
+	assert.NotEqual(t, AssuranceNone, result.Assurance)
+	assert.NotEmpty(t, result.AssuranceNote)
+	assert.NotEmpty(t, result.Filesystem)
+}
+
+func TestShredder_WipeFiles_Auto(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.txt")
+	require.NoError(t, os.WriteFile(testFile, make([]byte, 8192), 0644))
+
+	results := New().WipeFiles(context.Background(), []string{testFile}, WipeOptions{Passes: 3, Force: true, Auto: true})
+	require.Len(t, results, 1)
+	result := results[0]
+	require.True(t, result.Success, "%v", result.Error)
+	require.NotNil(t, result.Policy)
+	assert.Equal(t, result.Policy.Method.Name, result.Method)
+	assert.Equal(t, result.Policy.Method.PassCount(), result.Passes)
+}
+
 func TestGetSystemTempPaths(t *testing.T) {
 	shredder := New()
 	paths := shredder.getSystemTempPaths()
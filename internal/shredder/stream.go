@@ -0,0 +1,85 @@
+package shredder
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// seedSize is the length of the key used to generate a random pass
+const seedSize = 32
+
+// passStream produces the bytes written during a single pass. Random passes
+// are generated with AES-256-CTR keyed by a per-pass seed, so the exact same
+// stream can be regenerated later to verify what was written.
+type passStream struct {
+	pattern Pattern
+	block   cipher.Block
+	stream  cipher.Stream
+	offset  int64
+}
+
+// newPassSeed returns a fresh seed for a random pass
+func newPassSeed() ([]byte, error) {
+	seed := make([]byte, seedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	return seed, nil
+}
+
+// newPassStream creates the generator for a pass. The seed is only used by
+// random patterns and must be the same when writing and verifying.
+func newPassStream(p Pattern, seed []byte) (*passStream, error) {
+	ps := &passStream{pattern: p}
+	if !p.Random {
+		return ps, nil
+	}
+
+	block, err := aes.NewCipher(seed)
+	if err != nil {
+		return nil, err
+	}
+	ps.block = block
+	ps.stream = cipher.NewCTR(block, make([]byte, aes.BlockSize))
+	return ps, nil
+}
+
+// seek positions the stream at offset, so that resumed passes continue
+// with exactly the bytes an uninterrupted pass would have written
+func (ps *passStream) seek(offset int64) {
+	ps.offset = offset
+	if ps.block == nil {
+		return
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[aes.BlockSize-8:], uint64(offset/aes.BlockSize))
+	ps.stream = cipher.NewCTR(ps.block, iv)
+
+	if skip := offset % aes.BlockSize; skip > 0 {
+		discard := make([]byte, skip)
+		ps.stream.XORKeyStream(discard, discard)
+	}
+}
+
+// fill writes the next len(buf) bytes of the pass into buf
+func (ps *passStream) fill(buf []byte) {
+	if ps.stream != nil {
+		clear(buf)
+		ps.stream.XORKeyStream(buf, buf)
+	} else {
+		// Lay down one period of the pattern, then keep doubling it
+		p := ps.pattern.Bytes
+		phase := int(ps.offset % int64(len(p)))
+		filled := 0
+		for ; filled < len(buf) && filled < len(p); filled++ {
+			buf[filled] = p[(phase+filled)%len(p)]
+		}
+		for filled < len(buf) {
+			filled += copy(buf[filled:], buf[:filled])
+		}
+	}
+	ps.offset += int64(len(buf))
+}
@@ -0,0 +1,53 @@
+package shredder
+
+import "fmt"
+
+// VerifyMode selects which overwrite passes are read back and checked
+type VerifyMode int
+
+const (
+	// VerifyNone skips read-back verification
+	VerifyNone VerifyMode = iota
+	// VerifyLast verifies only the final pass
+	VerifyLast
+	// VerifyAll verifies every pass
+	VerifyAll
+)
+
+// String returns the flag value for the mode
+func (m VerifyMode) String() string {
+	switch m {
+	case VerifyLast:
+		return "last"
+	case VerifyAll:
+		return "all"
+	default:
+		return "none"
+	}
+}
+
+// ParseVerifyMode parses a --verify flag value
+func ParseVerifyMode(value string) (VerifyMode, error) {
+	switch value {
+	case "", "none":
+		return VerifyNone, nil
+	case "last":
+		return VerifyLast, nil
+	case "all":
+		return VerifyAll, nil
+	default:
+		return VerifyNone, fmt.Errorf("invalid verify mode %q (expected none, last or all)", value)
+	}
+}
+
+// VerifyStatus is the outcome of read-back verification for a file
+type VerifyStatus string
+
+const (
+	// VerifyNotRun means no pass was verified
+	VerifyNotRun VerifyStatus = ""
+	// VerifyPassed means every verified pass matched what was written
+	VerifyPassed VerifyStatus = "passed"
+	// VerifyFailed means a verified pass did not match what was written
+	VerifyFailed VerifyStatus = "failed"
+)
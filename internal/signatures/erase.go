@@ -0,0 +1,59 @@
+package signatures
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// backupRecord is one line of a backup file
+type backupRecord struct {
+	Type   string `json:"type"`
+	Usage  Usage  `json:"usage"`
+	Offset int64  `json:"offset"`
+	Data   []byte `json:"data"`
+}
+
+// WriteBackup saves the magic bytes of each signature to backup, one JSON
+// record per line, so that Restore can put them back after Erase
+func WriteBackup(backup io.Writer, found []Signature) error {
+	enc := json.NewEncoder(backup)
+	for _, s := range found {
+		if err := enc.Encode(backupRecord{Type: s.Type, Usage: s.Usage, Offset: s.Offset, Data: s.Magic}); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// Erase zeroes the magic bytes of each signature
+func Erase(w io.WriterAt, found []Signature) error {
+	for _, s := range found {
+		if _, err := w.WriteAt(make([]byte, len(s.Magic)), s.Offset); err != nil {
+			return fmt.Errorf("failed to erase %s: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// Restore writes back the bytes saved by Erase and returns the signatures
+// restored
+func Restore(w io.WriterAt, backup io.Reader) ([]Signature, error) {
+	var restored []Signature
+	scanner := bufio.NewScanner(backup)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record backupRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return restored, fmt.Errorf("backup line %d: %w", line, err)
+		}
+		if _, err := w.WriteAt(record.Data, record.Offset); err != nil {
+			return restored, fmt.Errorf("failed to restore %s at 0x%x: %w", record.Type, record.Offset, err)
+		}
+		restored = append(restored, Signature{Type: record.Type, Usage: record.Usage, Offset: record.Offset, Magic: record.Data})
+	}
+	return restored, scanner.Err()
+}
@@ -0,0 +1,316 @@
+// Package signatures detects and erases filesystem, partition table, RAID,
+// LVM and encryption signatures on block devices and disk images, in the
+// way wipefs does: only the magic bytes at their documented offsets are
+// overwritten, which is enough for every tool to stop recognizing them.
+package signatures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Usage classifies what a signature describes
+type Usage string
+
+// Signature usages
+const (
+	UsageFilesystem     Usage = "filesystem"
+	UsagePartitionTable Usage = "partition table"
+	UsageRaid           Usage = "raid"
+	UsageLVM            Usage = "lvm"
+	UsageCrypto         Usage = "crypto"
+	UsageSwap           Usage = "swap"
+)
+
+// Signature is a magic byte sequence found on a device
+type Signature struct {
+	// Type names the format, such as ext4, gpt or LVM2_member
+	Type  string
+	Usage Usage
+	// Offset is the position of the magic in bytes from the start of the
+	// device
+	Offset int64
+	// Magic holds the bytes found at Offset, which Erase zeroes
+	Magic []byte
+}
+
+// String describes the signature like "gpt at 0x200 (45 46 49 20 50 41 52 54)"
+func (s Signature) String() string {
+	return fmt.Sprintf("%s at 0x%x (% x)", s.Type, s.Offset, s.Magic)
+}
+
+// probe looks for one format. It returns nothing when the format is absent.
+type probe func(r *reader) []Signature
+
+// probes lists the supported formats
+var probes = []probe{
+	probeMBR,
+	probeGPT,
+	probeExt,
+	probeXFS,
+	probeBtrfs,
+	probeFAT,
+	probeNTFS,
+	probeExFAT,
+	probeISO9660,
+	probeSwap,
+	probeLVM,
+	probeLUKS,
+	probeMD,
+}
+
+// reader reads fixed ranges of a device, treating ranges past the end as
+// absent
+type reader struct {
+	r    io.ReaderAt
+	size int64
+}
+
+// at returns n bytes at offset, or nil when they cannot be read
+func (r *reader) at(offset int64, n int) []byte {
+	if offset < 0 || offset+int64(n) > r.size {
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := r.r.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil
+	}
+	return buf
+}
+
+// match reports whether magic is found at offset and returns its signature
+func (r *reader) match(typ string, usage Usage, offset int64, magic []byte) []Signature {
+	if !r.has(offset, magic) {
+		return nil
+	}
+	return []Signature{{Type: typ, Usage: usage, Offset: offset, Magic: append([]byte(nil), magic...)}}
+}
+
+// has reports whether magic is found at offset
+func (r *reader) has(offset int64, magic []byte) bool {
+	found := r.at(offset, len(magic))
+	return found != nil && bytes.Equal(found, magic)
+}
+
+// Scan detects every known signature on a device of size bytes, sorted by
+// offset
+func Scan(r io.ReaderAt, size int64) []Signature {
+	rd := &reader{r: r, size: size}
+	var found []Signature
+	for _, p := range probes {
+		found = append(found, p(rd)...)
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Offset < found[j].Offset })
+	return found
+}
+
+// Filter keeps the signatures whose type is listed in types, compared case
+// insensitively. Usages such as "raid" or "partition table" may be given
+// too.
+func Filter(found []Signature, types []string) []Signature {
+	var kept []Signature
+	for _, s := range found {
+		for _, t := range types {
+			t = strings.TrimSpace(t)
+			if strings.EqualFold(t, s.Type) || strings.EqualFold(t, string(s.Usage)) {
+				kept = append(kept, s)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// sectorSizes are the logical sector sizes probed for GPT
+var sectorSizes = []int64{512, 4096}
+
+// mbrMagic ends the boot sector of DOS partition tables and FAT volumes
+var mbrMagic = []byte{0x55, 0xAA}
+
+func probeMBR(r *reader) []Signature {
+	found := r.match("dos", UsagePartitionTable, 510, mbrMagic)
+	if found == nil {
+		return nil
+	}
+	// Filesystems like FAT and NTFS carry the same boot sector magic and
+	// report it themselves
+	if boot := r.at(0, 90); boot != nil && (bytes.HasPrefix(boot[3:], []byte("NTFS")) || bytes.HasPrefix(boot[3:], []byte("EXFAT")) ||
+		bytes.HasPrefix(boot[0x36:], []byte("FAT")) || bytes.HasPrefix(boot[0x52:], []byte("FAT"))) {
+		return nil
+	}
+	if entry := r.at(446+4, 1); entry != nil && entry[0] == 0xEE {
+		found[0].Type = "PMBR"
+	}
+	return found
+}
+
+// gptMagic opens both the primary and the backup GPT header
+var gptMagic = []byte("EFI PART")
+
+func probeGPT(r *reader) []Signature {
+	var found []Signature
+	for _, sector := range sectorSizes {
+		primary := r.match("gpt", UsagePartitionTable, sector, gptMagic)
+		if primary == nil {
+			continue
+		}
+		found = append(found, primary...)
+
+		// The primary header points at the backup header on the last LBA
+		if header := r.at(sector+32, 8); header != nil {
+			backup := int64(binary.LittleEndian.Uint64(header)) * sector
+			if backup > sector {
+				found = append(found, r.match("gpt", UsagePartitionTable, backup, gptMagic)...)
+			}
+		}
+		return found
+	}
+
+	// A lone backup header still lets tools restore the table
+	for _, sector := range sectorSizes {
+		if backup := r.match("gpt", UsagePartitionTable, r.size-sector, gptMagic); backup != nil {
+			return backup
+		}
+	}
+	return nil
+}
+
+func probeExt(r *reader) []Signature {
+	const superblock = 1024
+	found := r.match("ext2", UsageFilesystem, superblock+0x38, []byte{0x53, 0xEF})
+	if found == nil {
+		return nil
+	}
+	if features := r.at(superblock+0x5C, 12); features != nil {
+		compat := binary.LittleEndian.Uint32(features[0:])
+		incompat := binary.LittleEndian.Uint32(features[4:])
+		switch {
+		case incompat&0x40 != 0 || incompat&0x200 != 0:
+			// extents or flex_bg
+			found[0].Type = "ext4"
+		case compat&0x4 != 0:
+			// has_journal
+			found[0].Type = "ext3"
+		}
+	}
+	return found
+}
+
+func probeXFS(r *reader) []Signature {
+	return r.match("xfs", UsageFilesystem, 0, []byte("XFSB"))
+}
+
+func probeBtrfs(r *reader) []Signature {
+	var found []Signature
+	// Primary superblock and its mirrors at 64 MiB and 256 GiB
+	for _, super := range []int64{64 << 10, 64 << 20, 256 << 30} {
+		found = append(found, r.match("btrfs", UsageFilesystem, super+0x40, []byte("_BHRfS_M"))...)
+	}
+	return found
+}
+
+// bootSector adds the jump instruction and the boot sector magic to the
+// signature of a filesystem starting with a boot sector, like wipefs does.
+// Once only the label is erased, the remaining magic would be reported as
+// a DOS partition table.
+func (r *reader) bootSector(found []Signature) []Signature {
+	if found == nil {
+		return nil
+	}
+	typ := found[0].Type
+	if jump := r.at(0, 3); jump != nil && (jump[0] == 0xEB || jump[0] == 0xE9) {
+		found = append(found, Signature{Type: typ, Usage: UsageFilesystem, Offset: 0, Magic: jump})
+	}
+	return append(found, r.match(typ, UsageFilesystem, 510, mbrMagic)...)
+}
+
+func probeFAT(r *reader) []Signature {
+	if !r.has(510, mbrMagic) {
+		return nil
+	}
+	if found := r.match("vfat", UsageFilesystem, 0x52, []byte("FAT32   ")); found != nil {
+		return r.bootSector(found)
+	}
+	for _, magic := range []string{"FAT12   ", "FAT16   ", "FAT     "} {
+		if found := r.match("vfat", UsageFilesystem, 0x36, []byte(magic)); found != nil {
+			return r.bootSector(found)
+		}
+	}
+	return nil
+}
+
+func probeNTFS(r *reader) []Signature {
+	return r.bootSector(r.match("ntfs", UsageFilesystem, 3, []byte("NTFS    ")))
+}
+
+func probeExFAT(r *reader) []Signature {
+	return r.bootSector(r.match("exfat", UsageFilesystem, 3, []byte("EXFAT   ")))
+}
+
+func probeISO9660(r *reader) []Signature {
+	return r.match("iso9660", UsageFilesystem, 0x8001, []byte("CD001"))
+}
+
+func probeSwap(r *reader) []Signature {
+	// The magic ends the first page, whose size depends on the
+	// architecture that created the swap area
+	for _, page := range []int64{4096, 8192, 16384, 65536} {
+		for _, magic := range []string{"SWAPSPACE2", "SWAP-SPACE"} {
+			if found := r.match("swap", UsageSwap, page-10, []byte(magic)); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func probeLVM(r *reader) []Signature {
+	// The label sits in one of the first four sectors
+	for sector := int64(0); sector < 4; sector++ {
+		offset := sector * 512
+		if label := r.match("LVM2_member", UsageLVM, offset, []byte("LABELONE")); label != nil {
+			if r.has(offset+24, []byte("LVM2 001")) {
+				return label
+			}
+		}
+	}
+	return nil
+}
+
+// luks2Secondary lists the offsets the secondary LUKS2 header may start at
+var luks2Secondary = []int64{0x4000, 0x8000, 0x10000, 0x20000, 0x40000, 0x80000, 0x100000, 0x200000, 0x400000}
+
+func probeLUKS(r *reader) []Signature {
+	found := r.match("crypto_LUKS", UsageCrypto, 0, []byte("LUKS\xba\xbe"))
+	for _, offset := range luks2Secondary {
+		found = append(found, r.match("crypto_LUKS", UsageCrypto, offset, []byte("SKUL\xba\xbe"))...)
+	}
+	return found
+}
+
+// mdMagic is 0xa92b4efc in little endian
+var mdMagic = []byte{0xfc, 0x4e, 0x2b, 0xa9}
+
+func probeMD(r *reader) []Signature {
+	var offsets []int64
+	// Metadata 1.1 and 1.2 at the start of the device
+	offsets = append(offsets, 0, 4096)
+	// Metadata 1.0 in the last 8 KiB, 0.90 in the last 64 KiB aligned block
+	if r.size >= 8192 {
+		offsets = append(offsets, (r.size-8192)&^4095)
+	}
+	if r.size >= 128<<10 {
+		offsets = append(offsets, (r.size&^(64<<10-1))-64<<10)
+	}
+
+	var found []Signature
+	for _, offset := range offsets {
+		found = append(found, r.match("linux_raid_member", UsageRaid, offset, mdMagic)...)
+	}
+	return found
+}
@@ -0,0 +1,140 @@
+package signatures
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// image is an in-memory device
+type image []byte
+
+func (img image) ReadAt(p []byte, off int64) (int, error) {
+	return copy(p, img[off:]), nil
+}
+
+func (img image) WriteAt(p []byte, off int64) (int, error) {
+	return copy(img[off:], p), nil
+}
+
+// gptImage builds a 1 MiB disk with a protective MBR and both GPT headers
+func gptImage() image {
+	img := make(image, 1<<20)
+	img[446+4] = 0xEE
+	copy(img[510:], mbrMagic)
+	copy(img[512:], gptMagic)
+	binary.LittleEndian.PutUint64(img[512+32:], uint64(len(img)/512-1))
+	copy(img[len(img)-512:], gptMagic)
+	return img
+}
+
+func types(found []Signature) []string {
+	var names []string
+	for _, s := range found {
+		names = append(names, s.Type)
+	}
+	return names
+}
+
+func TestScan(t *testing.T) {
+	img := gptImage()
+	found := Scan(img, int64(len(img)))
+	assert.Equal(t, []string{"PMBR", "gpt", "gpt"}, types(found))
+	assert.Equal(t, int64(510), found[0].Offset)
+	assert.Equal(t, int64(512), found[1].Offset)
+	assert.Equal(t, int64(len(img)-512), found[2].Offset)
+}
+
+func TestScan_Formats(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int64
+		magic  []byte
+		extra  func(img image)
+		want   []string
+	}{
+		{"ext4", 1024 + 0x38, []byte{0x53, 0xEF}, func(img image) { img[1024+0x60] = 0x40 }, []string{"ext4"}},
+		{"ext3", 1024 + 0x38, []byte{0x53, 0xEF}, func(img image) { img[1024+0x5C] = 0x04 }, []string{"ext3"}},
+		{"xfs", 0, []byte("XFSB"), nil, []string{"xfs"}},
+		{"btrfs", 64<<10 + 0x40, []byte("_BHRfS_M"), nil, []string{"btrfs"}},
+		{"ntfs", 3, []byte("NTFS    "), func(img image) { copy(img[510:], mbrMagic) }, []string{"ntfs", "ntfs"}},
+		{"vfat", 0x52, []byte("FAT32   "), func(img image) { copy(img[510:], mbrMagic) }, []string{"vfat", "vfat"}},
+		{"swap", 4096 - 10, []byte("SWAPSPACE2"), nil, []string{"swap"}},
+		{"lvm", 512, []byte("LABELONE"), func(img image) { copy(img[512+24:], "LVM2 001") }, []string{"LVM2_member"}},
+		{"luks2", 0, []byte("LUKS\xba\xbe"), func(img image) { copy(img[0x4000:], "SKUL\xba\xbe") }, []string{"crypto_LUKS", "crypto_LUKS"}},
+		{"md 1.2", 4096, mdMagic, nil, []string{"linux_raid_member"}},
+		{"iso9660", 0x8001, []byte("CD001"), nil, []string{"iso9660"}},
+		{"dos", 510, mbrMagic, nil, []string{"dos"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := make(image, 1<<20)
+			copy(img[tt.offset:], tt.magic)
+			if tt.extra != nil {
+				tt.extra(img)
+			}
+			assert.Equal(t, tt.want, types(Scan(img, int64(len(img)))))
+		})
+	}
+
+	assert.Empty(t, Scan(make(image, 1<<20), 1<<20))
+}
+
+func TestFilter(t *testing.T) {
+	found := []Signature{{Type: "ext4", Usage: UsageFilesystem}, {Type: "gpt", Usage: UsagePartitionTable}, {Type: "PMBR", Usage: UsagePartitionTable}}
+	assert.Equal(t, []string{"ext4"}, types(Filter(found, []string{"EXT4"})))
+	assert.Equal(t, []string{"gpt", "PMBR"}, types(Filter(found, []string{"partition table"})))
+	assert.Empty(t, Filter(found, []string{"xfs"}))
+}
+
+func TestEraseAndRestore(t *testing.T) {
+	img := gptImage()
+	original := append(image(nil), img...)
+	found := Scan(img, int64(len(img)))
+
+	var backup bytes.Buffer
+	require.NoError(t, WriteBackup(&backup, found))
+	require.NoError(t, Erase(img, found))
+	assert.Empty(t, Scan(img, int64(len(img))))
+	// Only the magic bytes are touched
+	assert.Equal(t, byte(0xEE), img[446+4])
+
+	restored, err := Restore(img, &backup)
+	require.NoError(t, err)
+	assert.Len(t, restored, 3)
+	assert.Equal(t, original, img)
+}
+
+func TestErase_BootSectorFilesystems(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int64
+		label  string
+	}{
+		{"vfat", 0x52, "FAT32   "},
+		{"vfat16", 0x36, "FAT16   "},
+		{"ntfs", 3, "NTFS    "},
+		{"exfat", 3, "EXFAT   "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := make(image, 1<<20)
+			copy(img, []byte{0xEB, 0x58, 0x90})
+			copy(img[tt.offset:], tt.label)
+			copy(img[510:], mbrMagic)
+
+			found := Scan(img, int64(len(img)))
+			require.Len(t, found, 3)
+			assert.Equal(t, []int64{0, tt.offset, 510}, []int64{found[0].Offset, found[1].Offset, found[2].Offset})
+
+			// No DOS partition table may show up once the label is gone
+			require.NoError(t, Erase(img, found))
+			assert.Empty(t, Scan(img, int64(len(img))))
+		})
+	}
+}
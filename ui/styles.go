@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -130,4 +131,64 @@ func ProgressBar(current, total int, label string) string {
 		Bold(true)
 	
 	return style.Render(fmt.Sprintf("[%s] %.1f%% %s (%d/%d)", bar, percentage, label, current, total))
-} 
\ No newline at end of file
+} 
+
+// TransferBar renders a byte-based progress bar with throughput and ETA
+func TransferBar(done, total int64, elapsed time.Duration, label string) string {
+	if total <= 0 {
+		return ""
+	}
+	if done > total {
+		done = total
+	}
+
+	percentage := float64(done) / float64(total) * 100
+	barWidth := 30
+	filled := int(float64(barWidth) * percentage / 100)
+
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(done) / elapsed.Seconds()
+	}
+
+	eta := "--:--"
+	if rate > 0 {
+		remaining := time.Duration(float64(total-done) / rate * float64(time.Second))
+		eta = formatDuration(remaining)
+	}
+
+	style := lipgloss.NewStyle().
+		Foreground(colorSecondary).
+		Bold(true)
+
+	return style.Render(fmt.Sprintf("[%s] %5.1f%% %s/s ETA %s %s", bar, percentage, FormatBytes(int64(rate)), eta, label))
+}
+
+// FormatBytes formats a byte count using binary units
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatDuration formats a duration as m:ss or h:mm:ss
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	sec := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, sec)
+	}
+	return fmt.Sprintf("%d:%02d", m, sec)
+}